@@ -0,0 +1,16 @@
+package provider
+
+import (
+	"regexp"
+)
+
+// ansiEscape matches ANSI escape sequences: control sequences such as colors
+// and cursor movement, operating system commands such as window titles and
+// hyperlinks, and the remaining two character escapes such as a terminal
+// reset.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-~]`)
+
+// stripANSI returns b with any ANSI escape sequences removed.
+func stripANSI(b []byte) []byte {
+	return ansiEscape.ReplaceAll(b, nil)
+}
@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestStripANSI(t *testing.T) {
+	testCases := map[string]struct {
+		input    string
+		expected string
+	}{
+		"plain": {
+			input:    `{"key":"value"}`,
+			expected: `{"key":"value"}`,
+		},
+		"color": {
+			input:    "\x1b[1;32m{\"key\":\"value\"}\x1b[0m",
+			expected: `{"key":"value"}`,
+		},
+		"cursor": {
+			input:    "\x1b[2K\x1b[1Gdone",
+			expected: "done",
+		},
+		"hyperlink": {
+			input:    "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\",
+			expected: "link",
+		},
+		"title": {
+			input:    "\x1b]0;title\x07text",
+			expected: "text",
+		},
+		"reset": {
+			input:    "\x1bctext",
+			expected: "text",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			got := string(stripANSI([]byte(testCase.input)))
+
+			if got != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sensitiveKey matches the names of flags and key=value arguments whose
+// values are redacted from the audit log.
+var sensitiveKey = regexp.MustCompile(`(?i)passw(or)?d|passphrase|secret|token|credential|api[-_]?key|private[-_]?key`)
+
+const redacted = "<redacted>"
+
+// auditLog appends a record of each program execution to a file shared by
+// all resources of the provider.
+type auditLog struct {
+	mu   sync.Mutex
+	name string
+}
+
+// auditEntry is a line of the audit log.
+type auditEntry struct {
+	Timestamp  string   `json:"timestamp"`
+	Program    string   `json:"program"`
+	Args       []string `json:"args"`
+	ExitCode   *int     `json:"exit_code"`
+	DurationMs int64    `json:"duration_ms"`
+}
+
+// newAuditEntry returns the entry of an execution of args that started at
+// start and ended with err. The exit code is omitted when the program did not
+// exit normally, such as when it could not be started or was killed.
+func newAuditEntry(args []string, start time.Time, err error) auditEntry {
+	entry := auditEntry{
+		Timestamp:  start.UTC().Format(time.RFC3339Nano),
+		Program:    args[0],
+		Args:       redactArgs(args[1:]),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		code := 0
+		entry.ExitCode = &code
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		code := exitErr.ExitCode()
+		entry.ExitCode = &code
+	}
+
+	return entry
+}
+
+// redactArgs returns the arguments with the values of sensitive flags, given
+// either as the argument following the flag or after an equals sign, replaced.
+func redactArgs(args []string) []string {
+	redactedArgs := make([]string, len(args))
+
+	for idx, arg := range args {
+		redactedArgs[idx] = arg
+
+		if name, _, ok := strings.Cut(arg, "="); ok && sensitiveKey.MatchString(name) {
+			redactedArgs[idx] = name + "=" + redacted
+			continue
+		}
+
+		if idx > 0 && strings.HasPrefix(args[idx-1], "-") && !strings.Contains(args[idx-1], "=") &&
+			!strings.HasPrefix(arg, "-") && sensitiveKey.MatchString(args[idx-1]) {
+			redactedArgs[idx] = redacted
+		}
+	}
+
+	return redactedArgs
+}
+
+// record appends the entry to the log. Entries from concurrent executions are
+// written whole, one per line. A nil log records nothing.
+func (l *auditLog) record(entry auditEntry) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return appendJSONLine(l.name, entry)
+}
+
+// appendJSONLine appends v to the named file as a line of JSON, creating the
+// file, readable only by its owner, if needed.
+func appendJSONLine(name string, v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
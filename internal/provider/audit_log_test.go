@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRedactArgs(t *testing.T) {
+	testCases := map[string]struct {
+		args     []string
+		expected []string
+	}{
+		"none": {
+			args:     []string{"--region", "eu-west-1", "plan"},
+			expected: []string{"--region", "eu-west-1", "plan"},
+		},
+		"flag-value": {
+			args:     []string{"--api-key", "abc", "--password", "hunter2", "--verbose"},
+			expected: []string{"--api-key", redacted, "--password", redacted, "--verbose"},
+		},
+		"equals": {
+			args:     []string{"--token=abc", "DB_PASSWD=hunter2", "name=value"},
+			expected: []string{"--token=" + redacted, "DB_PASSWD=" + redacted, "name=value"},
+		},
+		"boolean-flag": {
+			args:     []string{"--no-token", "--verbose"},
+			expected: []string{"--no-token", "--verbose"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual := redactArgs(testCase.args)
+
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "audit.log")
+	log := &auditLog{name: name}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := log.record(newAuditEntry([]string{"tool", "--secret", "abc"}, time.Now(), nil)); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer f.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines++
+
+		var entry auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if entry.Program != "tool" || !reflect.DeepEqual(entry.Args, []string{"--secret", redacted}) {
+			t.Errorf("unexpected entry: %+v", entry)
+		}
+
+		if entry.ExitCode == nil || *entry.ExitCode != 0 {
+			t.Errorf("expected exit code 0, got %v", entry.ExitCode)
+		}
+	}
+
+	if lines != 10 {
+		t.Errorf("expected 10 lines, got %d", lines)
+	}
+}
+
+func TestRun_LoginShellRedaction(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	t.Setenv("SHELL", "/bin/sh")
+
+	dir := t.TempDir()
+	auditName := filepath.Join(dir, "audit.log")
+	metricsName := filepath.Join(dir, "metrics.log")
+
+	resource := &programResource{data: &providerData{
+		auditLog:    &auditLog{name: auditName},
+		metricsSink: &metricsSink{name: metricsName},
+	}}
+
+	// The arguments after the script are passed to it, and ignored.
+	_, diags := resource.run(context.Background(), testModel(t, map[string]interface{}{
+		"program":     []string{"sh", "-c", "printf '{}'", "sh", "--token", "abc"},
+		"login_shell": true,
+	}), nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	expectedArgs := []string{"-c", "printf '{}'", "sh", "--token", redacted}
+
+	for _, name := range []string{auditName, metricsName} {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if bytes.Contains(b, []byte("abc")) {
+			t.Errorf("expected the token to be redacted from %s, got: %s", filepath.Base(name), b)
+		}
+
+		var entry struct {
+			Program string   `json:"program"`
+			Args    []string `json:"args"`
+		}
+		if err := json.Unmarshal(b, &entry); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if entry.Program != "sh" || !reflect.DeepEqual(entry.Args, expectedArgs) {
+			t.Errorf("expected the program as configured in %s, got: %s %q", filepath.Base(name), entry.Program, entry.Args)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// cacheKey returns a hash of the inputs of the execution, which identifies
+// its output in the result cache. Files read by the program are not part of
+// the key.
+func (e *execution) cacheKey() (string, error) {
+	inputs, err := json.Marshal(struct {
+		Program              []string
+		Pipe                 [][]string
+		Dir                  string
+		Env                  []string
+		Stdin                []byte
+		QueryEnvFile         []byte
+		Secrets              []byte
+		ChrootDir            string
+		Pty                  bool
+		NetworkNamespace     string
+		ResultStream         string
+		OutputEncoding       string
+		StripANSI            bool
+		NormalizeLineEndings bool
+	}{e.program, e.pipe, e.dir, e.env, e.stdin, e.queryEnvFile, e.secrets, e.chrootDir, e.pty, e.networkNamespace, e.resultStream, e.outputEncoding, e.stripANSI, e.normalizeLineEndings})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(inputs)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// cacheEntry is the stored result of a successful execution.
+type cacheEntry struct {
+	Output   []byte `json:"output"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// readCache returns the cached output and exit code for key from the cache
+// directory, and whether they were found.
+func readCache(dir, key string) ([]byte, int, bool, error) {
+	b, err := os.ReadFile(filepath.Join(dir, key+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, 0, false, nil
+	}
+
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return nil, 0, false, err
+	}
+
+	return entry.Output, entry.ExitCode, true, nil
+}
+
+// writeCache stores the output and exit code for key in the cache
+// directory, creating the directory if needed. The entry is written to a
+// temporary file first so concurrent readers never see a partially written
+// entry.
+func writeCache(dir, key string, output []byte, exitCode int) error {
+	b, err := json.Marshal(cacheEntry{Output: output, ExitCode: exitCode})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(b)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(dir, key+".json"))
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	return err
+}
@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+
+	e := &execution{program: []string{"example"}, stdin: []byte(`{"key":"value"}`)}
+
+	key, err := e.cacheKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, _, ok, err := readCache(dir, key); ok || err != nil {
+		t.Fatalf("expected cache miss, got found: %t, error: %v", ok, err)
+	}
+
+	if err := writeCache(dir, key, []byte(`{"result":"cached"}`), 3); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	output, exitCode, ok, err := readCache(dir, key)
+	if !ok || err != nil {
+		t.Fatalf("expected cache hit, got found: %t, error: %v", ok, err)
+	}
+
+	if string(output) != `{"result":"cached"}` {
+		t.Errorf("unexpected output: %q", output)
+	}
+
+	if exitCode != 3 {
+		t.Errorf("unexpected exit code: %d", exitCode)
+	}
+
+	other := &execution{program: []string{"example"}, stdin: []byte(`{"key":"other"}`)}
+
+	otherKey, err := other.cacheKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if otherKey == key {
+		t.Error("expected different inputs to have different cache keys")
+	}
+}
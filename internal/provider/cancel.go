@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// cancelProgramTimeout is how long the on_cancel program is given to run
+// before it is stopped.
+const cancelProgramTimeout = 30 * time.Second
+
+// cancelInput returns the JSON object passed to the on_cancel program on
+// stdin, holding the query of the cancelled program and the output it wrote
+// before it was stopped.
+func cancelInput(query map[string]string, output []byte) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"query":  query,
+		"output": string(output),
+	})
+}
+
+// runCancelProgram runs the on_cancel program with the directory and
+// environment of the cancelled program, passing it stdin. It runs
+// independently of the cancelled context, limited to cancelProgramTimeout,
+// recording it in the audit log when one is given.
+func runCancelProgram(program []string, dir string, env []string, stdin []byte, audit *auditLog) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cancelProgramTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, program[0], program[1:]...)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdin = bytes.NewReader(stdin)
+
+	start := time.Now()
+	_, err := cmd.Output()
+
+	if auditErr := audit.record(newAuditEntry(program, start, err)); auditErr != nil && err == nil {
+		err = fmt.Errorf("recording in audit log: %w", auditErr)
+	}
+
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", cancelProgramTimeout)
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w\nError Message: %s", err, exitErr.Stderr)
+	}
+
+	return err
+}
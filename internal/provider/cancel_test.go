@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRunCancelProgram(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+
+	stdin, err := cancelInput(map[string]string{"name": "example"}, []byte("partial"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	program := []string{"sh", "-c", `cat > input.json; echo "$CANCEL_MARKER" > marker`}
+	env := append(os.Environ(), "CANCEL_MARKER=cancelled")
+
+	if err := runCancelProgram(program, dir, env, stdin, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "input.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var input struct {
+		Query  map[string]string `json:"query"`
+		Output string            `json:"output"`
+	}
+	if err := json.Unmarshal(b, &input); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if input.Query["name"] != "example" || input.Output != "partial" {
+		t.Errorf("unexpected input: %s", b)
+	}
+
+	marker, err := os.ReadFile(filepath.Join(dir, "marker"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.TrimSpace(string(marker)) != "cancelled" {
+		t.Errorf("expected the environment to be passed, got marker %q", marker)
+	}
+}
+
+func TestRunCancelProgram_Error(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	err := runCancelProgram([]string{"sh", "-c", "echo cleanup failed >&2; exit 1"}, t.TempDir(), nil, nil, nil)
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	if !strings.Contains(err.Error(), "cleanup failed") {
+		t.Errorf("expected error to contain the error output, got: %s", err)
+	}
+}
@@ -0,0 +1,23 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSHA256(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "program")
+	if err := os.WriteFile(name, []byte("hello\n"), 0o700); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	sum, err := fileSHA256(name)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if expected := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"; sum != expected {
+		t.Errorf("expected %s, got %s", expected, sum)
+	}
+}
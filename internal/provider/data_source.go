@@ -1,26 +1,31 @@
 package provider
 
 import (
-	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
-	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 )
 
 var (
-	_ resource.Resource = (*programResource)(nil)
+	_ resource.Resource                   = (*programResource)(nil)
+	_ resource.ResourceWithConfigure      = (*programResource)(nil)
+	_ resource.ResourceWithValidateConfig = (*programResource)(nil)
+	_ resource.ResourceWithModifyPlan     = (*programResource)(nil)
 	//_ resource.ResourceWithImportState = (*programResource)(nil)
 )
 
@@ -28,7 +33,65 @@ func NewExternalResource() resource.Resource {
 	return &programResource{}
 }
 
-type programResource struct{}
+// workspaceQueryKey is the query key the Terraform workspace is passed
+// under when include_workspace is set.
+const workspaceQueryKey = "terraform_workspace"
+
+// tempDirQueryKey is the query key holding the temporary directory the
+// program runs in when use_temp_dir is set.
+const tempDirQueryKey = "temp_dir"
+
+// invocationIDQueryKey is the query key holding the invocation_id when
+// pass_invocation_id is set.
+const invocationIDQueryKey = "invocation_id"
+
+// binaryOutputKey is the result key holding the base64 encoded output of a
+// program detected as binary by auto_detect_binary.
+const binaryOutputKey = "stdout_base64"
+
+// defaultPreviousResultKey is the key the previous result is passed under
+// when the program is re-run by update_in_place.
+const defaultPreviousResultKey = "previous_result"
+
+// defaultPreviousExitCodeKey is the key the exit code of the previous run is
+// passed under when the program is re-run by update_in_place.
+const defaultPreviousExitCodeKey = "previous_exit_code"
+
+// interruptGracePeriod is how long a program sent its interrupt_signal is
+// given to exit before it is killed.
+const interruptGracePeriod = 10 * time.Second
+
+const (
+	outputFormatJSON      = "json"
+	outputFormatJSONArray = "json_array"
+	outputFormatHCL       = "hcl"
+)
+
+const (
+	updateResultBehaviorPreserve = "preserve"
+	updateResultBehaviorUnknown  = "unknown"
+	updateResultBehaviorRerun    = "rerun"
+)
+
+type programResource struct {
+	data *providerData
+}
+
+func (r *programResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Configure is also called before the provider itself has been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData))
+		return
+	}
+
+	r.data = data
+}
 
 func (r *programResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_persisted"
@@ -55,188 +118,1620 @@ func (r *programResource) Schema(ctx context.Context, req resource.SchemaRequest
 				Computed:            true,
 				MarkdownDescription: "Identifier",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"id_template": schema.StringAttribute{
+				Description: "Go template rendered against the `query` to produce the `id` of the resource, " +
+					"such as `\"{{ .name }}\"`, which helps tell apart instances created with `for_each`. " +
+					"The `for_each` key is not available to the provider, so it must be passed in the query " +
+					"to be used. The functions of `result_transforms` are available. Keys added to the query " +
+					"by the provider, such as `seed`, are not. The id is kept when the resource is updated " +
+					"in place. Defaults to `\"example-id\"`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"program": schema.ListAttribute{
+				Description: "A list of strings, whose first element is the program to run and whose " +
+					"subsequent elements are optional command line arguments to the program. Terraform does " +
+					"not execute the program through a shell, so it is not necessary to escape shell " +
+					"metacharacters nor add quotes around arguments containing spaces. Exactly one of " +
+					"`program` or `script` must be set, unless `program_env` is set, in which case " +
+					"`program` is optional.",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"program_env": schema.StringAttribute{
+				Description: "Name of an environment variable of Terraform whose value, when set and not " +
+					"empty, is used as the program to run in place of the first element of `program`, so " +
+					"that operators can point the resource at a different binary per environment without " +
+					"changing the configuration. The remaining elements of `program` are still passed as " +
+					"arguments. When the variable is unset, `program` is used as is, and an error is " +
+					"raised if it is not set either. The variable is read when the program runs and is not " +
+					"part of the plan, so changing it between applies does not cause a diff; the new " +
+					"program only runs when the resource is next created or re-run. Cannot be combined " +
+					"with `script`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"script": schema.StringAttribute{
+				Description: "Content of a script to run instead of `program`, for small programs inlined " +
+					"in the configuration, such as with a heredoc. The script is written to a temporary " +
+					"file, executable by its owner on Unix, which is run by the `interpreter` and removed " +
+					"once the program has finished, whether or not it succeeded. The file name differs on " +
+					"every run, so `cache` never matches. Cannot be combined with `chroot_dir`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"interpreter": schema.ListAttribute{
+				Description: "A list of strings, whose first element is the program that runs the `script`, " +
+					"found using the `PATH` as `program` is, and whose subsequent elements are arguments " +
+					"given before the name of the script file, such as `[\"python3\", \"-u\"]`. Defaults " +
+					"to `[\"/bin/sh\"]`, except on Windows, where it must be set. The script file has no " +
+					"extension, so interpreters that require one cannot be used.",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"destroy_program": schema.ListAttribute{
+				Description: "A list of strings, whose first element is a program to run when the resource " +
+					"is destroyed and whose subsequent elements are its arguments. It runs in `working_dir` " +
+					"and receives a JSON object on stdin with the `id` and `result` of the resource, and its " +
+					"`results` when `output_format` is `\"json_array\"`, so it can clean up exactly what was " +
+					"created. If it fails, the resource is not destroyed.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"on_cancel": schema.ListAttribute{
+				Description: "A list of strings, whose first element is a program to run when the program " +
+					"is cancelled, because its timeout expired or Terraform was interrupted, and whose " +
+					"subsequent elements are its arguments. It runs with the working directory and " +
+					"environment of the program and receives a JSON object on stdin with the `query` and " +
+					"the `output` the program wrote before it was stopped, so it can clean up after an " +
+					"interrupted operation. It is best effort: it is stopped after 30 seconds, and its " +
+					"failure is logged rather than reported.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"json_args": schema.MapAttribute{
+				Description: "A map of placeholder names to lists of strings. Each `{{name}}` in an element " +
+					"of `program` is replaced with the JSON encoded array of the list of that name, such as " +
+					"`[\"a\",\"b\"]`, so that a list can be passed as a single argument with its quotes " +
+					"intact. Placeholders without an entry are left unchanged. Replacement happens before " +
+					"`expand_env_args`, which also expands any `$` in the encoded lists.",
+				Optional:    true,
+				ElementType: types.ListType{ElemType: types.StringType},
+				PlanModifiers: []planmodifier.Map{
+					mapRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"expand_env_args": schema.BoolAttribute{
+				Description: "When `true`, `$VAR` and `${VAR}` references in each element of `program`, " +
+					"including rendered `flags`, and in `working_dir` are expanded, so that " +
+					"`[\"${HOME}/bin/tool\"]` works without running a shell. References are expanded " +
+					"against the environment of Terraform with `environment` applied, before `root_relative` " +
+					"is applied; variables from `environment_files` are not available. Unset variables " +
+					"expand to the empty string. Every `$` in an argument starts a reference, which may " +
+					"expand unexpectedly, so leave this unset for programs taking literal dollar signs.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"flags": schema.MapAttribute{
+				Description: "A map of flag names to values, appended to the arguments in `program` as " +
+					"`--name value`, or as set by `flag_prefix` and `flag_separator`, in the order of " +
+					"the flag names. A flag with an empty value is appended as `--name` alone, for " +
+					"boolean flags. Each flag and value is passed as a separate argument without " +
+					"involving a shell, so values need no quoting and are passed exactly, including " +
+					"spaces. Use `program` for positional arguments, or flags that must appear in a " +
+					"particular order.",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.Map{
+					mapRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"query_as_args": schema.StringAttribute{
+				Description: "How the `query` is also passed to the program as command line arguments, " +
+					"for programs that do not read their input: `\"none\"` (the default), `\"flags\"` " +
+					"for `--key value` flags rendered like `flags`, following `flag_prefix` and " +
+					"`flag_separator`, or `\"positional\"` for `key=value` arguments. The arguments are " +
+					"in the order of the keys, after those in `program`, `json_args` and `flags`, and " +
+					"include only the configured query, not keys added by the provider such as `seed`. " +
+					"The query is still written to the standard input of the program.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"flag_prefix": schema.StringAttribute{
+				Description: "Prefix of each flag rendered from `flags`, or from `query` by " +
+					"`query_as_args`: `\"--\"` (the default), `\"-\"`, or `\"/\"` for Windows-style " +
+					"tools.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"flag_separator": schema.StringAttribute{
+				Description: "How each flag rendered from `flags` is joined to its value: `\" \"` (the " +
+					"default) passes the value as the next argument, as in `--name value`, while `\"=\"` " +
+					"passes a single argument, as in `--name=value`, for tools that require it. Flags with " +
+					"an empty value are rendered alone either way.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"working_dir": schema.StringAttribute{
+				Description: "Working directory of the program. If not supplied, the program will run " +
+					"in the current directory.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"template_working_dir": schema.BoolAttribute{
+				Description: "Render `working_dir` as a [Go template](https://pkg.go.dev/text/template) " +
+					"against the configured `query`, such as `builds/{{.environment}}`, so that resources " +
+					"created with `for_each` can run in a directory per query. The template is rendered " +
+					"before `working_dir` is expanded or resolved, and has the same functions as " +
+					"`result_transforms`. Keys missing from the query and templates rendering an empty " +
+					"directory are errors. Defaults to `false`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"use_temp_dir": schema.BoolAttribute{
+				Description: "When `true`, the program runs in a new, empty temporary directory, which is " +
+					"removed once the program has finished, even when it fails, times out or Terraform is " +
+					"interrupted. The path of the directory is passed to the program in the `temp_dir` " +
+					"query key, unless the query sets it, and the `TF_EXTERNAL_TEMP_DIR` environment " +
+					"variable. `working_dir` is still used to find a program given as a relative path, and " +
+					"`environment_files`, while `output_files` and `output_glob` are read from the " +
+					"temporary directory. As the directory differs on every run, `cache` never matches. " +
+					"Cannot be combined with `chroot_dir`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"include_run_metadata": schema.BoolAttribute{
+				Description: "When `true`, information about the Terraform run is passed to the program in " +
+					"environment variables, for correlating its logs: `TF_EXTERNAL_WORKSPACE`, the name of " +
+					"the selected workspace; `TF_EXTERNAL_RUN_ID`, a random identifier shared by the " +
+					"resources of a provider configuration during one Terraform command, which differs " +
+					"between the plan and apply of the same change; and `TF_EXTERNAL_ROOT_MODULE_PATH`, the absolute path of the root " +
+					"module directory. Variables set in `environment` or `environment_files` take " +
+					"precedence.",
+				Optional: true,
+			},
+			"environment": schema.MapAttribute{
+				Description: "Environment variables to set for the program, in addition to those " +
+					"inherited from Terraform. Values set here take precedence over inherited variables " +
+					"and those loaded from `environment_files`.",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.Map{
+					mapRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"clean_environment": schema.BoolAttribute{
+				Description: "Run the program without the environment variables of Terraform, in the " +
+					"manner of `env -i`, so that credentials and other secrets in the environment of " +
+					"Terraform are not exposed to it. The program receives only the variables named in " +
+					"`passthrough_env` and those set by the resource, such as `environment`, " +
+					"`environment_files` and `locale`. Without `PATH`, programs without a directory are " +
+					"still found using the `PATH` of Terraform, but the programs they run may not be. " +
+					"Defaults to `false`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"passthrough_env": schema.ListAttribute{
+				Description: "Names of environment variables of Terraform, such as `PATH` and `HOME`, " +
+					"passed to the program when `clean_environment` is `true`. Variables that are not " +
+					"set are skipped, and `environment` takes precedence over them. Ignored, with a " +
+					"warning, without `clean_environment`.",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"secrets": schema.MapAttribute{
+				Description: "Secrets to pass to the program without exposing them in its arguments or " +
+					"environment, where other processes could read them from the process table or " +
+					"`/proc/<pid>/environ`. The secrets are written as a JSON object of string keys and " +
+					"string values to file descriptor 3 of the program, which it reads until end of file, " +
+					"for example with `jq . <&3` in a shell script. Only the program receives the secrets, " +
+					"not its `pipe` stages. The values are still stored in the Terraform state. Not " +
+					"supported on Windows, where a warning is raised and the program runs without them.",
+				Optional:    true,
+				Sensitive:   true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.Map{
+					mapRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"locale": schema.StringAttribute{
+				Description: "Locale the program runs with, set as both `LC_ALL` and `LANG` in its " +
+					"environment, such as `\"C\"` or `\"en_US.UTF-8\"`. `\"C\"` gives deterministic output, " +
+					"avoiding numbers and dates formatted with locale specific separators that can break " +
+					"parsing. Variables in `environment` take precedence. If not supplied, the locale of " +
+					"Terraform is inherited.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"environment_files": schema.ListAttribute{
+				Description: "Paths to dotenv files, relative to `working_dir`, whose variables are set " +
+					"for the program. Each line of a file is a `KEY=VALUE` pair, optionally preceded by " +
+					"`export`; blank lines and lines starting with `#` are ignored, and values may be " +
+					"single or double quoted. Variables in later files take precedence over earlier ones.",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"replace_on_env_change": schema.BoolAttribute{
+				Description: "When `true`, a change to the variables set by `environment` and " +
+					"`environment_files` re-runs the program, including changes to the contents of the " +
+					"files, which Terraform does not otherwise see. A hash of the variables is stored in " +
+					"`environment_hash` when the program runs, and compared with the current variables " +
+					"when planning, reading the files relative to `working_dir` as configured, so changes " +
+					"are not detected with `template_working_dir`. The resource is replaced, unless " +
+					"`update_in_place` is `true` or `update_result_behavior` is `\"rerun\"`, in which " +
+					"case it is re-run in place. Defaults to `false`.",
+				Optional: true,
+			},
+			"environment_hash": schema.StringAttribute{
+				Description: "SHA-256 hash of the variables set by `environment` and `environment_files` " +
+					"when the program last ran, set when `replace_on_env_change` is `true`.",
+				Computed: true,
+			},
+			"exit_code_severity": schema.MapAttribute{
+				Description: "A map of non-zero exit codes of the program to how they are reported: " +
+					"`\"error\"`, `\"warning\"` to raise a warning and use the output as the result, or " +
+					"`\"ignore\"` to use the output as the result silently. Exit codes mapped to a warning " +
+					"or ignored are not retried. Exit codes that are not mapped remain errors.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"root_relative": schema.BoolAttribute{
+				Description: "When `true`, a relative path in the first element of `program` and a relative " +
+					"`working_dir` (or an unset one) are resolved against the root module directory, so " +
+					"they do not depend on where Terraform is run from. A program without a directory, " +
+					"such as `\"python\"`, is still found using the `PATH` environment variable.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"query": schema.MapAttribute{
+				Description: "A map of string values to pass to the external program as the query " +
+					"arguments. If not supplied, the program will receive an empty object as its input.",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.Map{
+					mapRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"pipe": schema.ListAttribute{
+				Description: "A list of further programs, each given as a list of strings in the same form " +
+					"as `program`, to run as a pipeline after `program`. The output of each program is " +
+					"passed as the input of the next, and the output of the last program is parsed as the " +
+					"result. No shell is involved in connecting the programs.",
+				Optional:    true,
+				ElementType: types.ListType{ElemType: types.StringType},
+				PlanModifiers: []planmodifier.List{
+					listRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"transform_program": schema.ListAttribute{
+				Description: "A program, given as a list of strings in the same form as `program`, that " +
+					"post-processes the output of the program, such as a formatter shared by several " +
+					"programs. It receives the output of the program, or of the last `pipe` stage, on " +
+					"stdin, and its own output is parsed as the result. It runs as the last stage of the " +
+					"pipeline, so errors name it as the stage that failed.",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"strict_query": schema.BoolAttribute{
+				Description: "When `true`, an error naming the offending key is raised for any `query` value " +
+					"that would not be passed to the program unchanged, such as empty values, which are " +
+					"otherwise silently omitted, and values containing double quotes, which are otherwise " +
+					"removed.",
+				Optional: true,
+			},
+			"stdin_encoding": schema.StringAttribute{
+				Description: "Encoding of the input passed to the program: `\"utf8\"`, `\"utf16le\"` " +
+					"(without a byte order mark), or `\"latin1\"`. Defaults to `\"utf8\"`. Useful for " +
+					"native Windows programs that do not read UTF-8.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"output_encoding": schema.StringAttribute{
+				Description: "Encoding of the output of the program, which is converted to UTF-8 before it " +
+					"is parsed: `\"utf8\"`, `\"utf16le\"` (a leading byte order mark is removed), or " +
+					"`\"latin1\"`. Defaults to `\"utf8\"`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"pretty_stdin": schema.BoolAttribute{
+				Description: "When `true`, the JSON passed to the program is indented across multiple lines " +
+					"for readability when debugging. Only the formatting changes, not the content. Defaults " +
+					"to `false`, passing compact JSON, as some programs are sensitive to whitespace.",
+				Optional: true,
+			},
+			"sort_stdin_keys": schema.BoolAttribute{
+				Description: "When `true` (the default), the keys of the JSON object passed to the program " +
+					"are sorted, so its input is byte for byte the same for the same query. When `false`, " +
+					"keys are written in the order they are merged: the keys of `query` in sorted order, " +
+					"then the keys added by the provider, such as `seed` and `os`, then the previous result " +
+					"of `update_in_place`. Either way the input is deterministic.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"query_records": schema.ListAttribute{
+				Description: "Records to process in a single run of a batch program, in place of the " +
+					"`query`. The records are written to stdin as newline-delimited JSON, one object of " +
+					"string values per line, and the program must write one JSON object per line for each " +
+					"record, in the same order, which are stored in `results`. A different number of output " +
+					"objects is an error. Only supported when `output_format` is `\"json_array\"`, and " +
+					"cannot be combined with `stdin_template`.",
+				Optional:    true,
+				ElementType: types.MapType{ElemType: types.StringType},
+				PlanModifiers: []planmodifier.List{
+					listRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"stdin_template": schema.StringAttribute{
+				Description: "A Go [text/template](https://pkg.go.dev/text/template) rendered against the " +
+					"query map, whose output is passed to the program instead of the JSON encoded query. " +
+					"This allows programs that expect other input formats, such as INI files or XML, to be " +
+					"used. Query values are available as `{{ .key }}`, and referencing a key missing from " +
+					"the query is an error.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"seed": schema.StringAttribute{
+				Description: "A seed added to the query under the `seed` key, unless `query` already contains " +
+					"that key, so that programs generating random output can reproduce it when re-run. " +
+					"When not supplied and `random_seed` is `true`, a random seed is generated and kept in " +
+					"the state.",
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"invocation_id": schema.StringAttribute{
+				Description: "A random identifier of this instance of the resource, generated when it is " +
+					"created and kept until it is replaced, so that the output of each instance of a " +
+					"resource using `for_each` or `count` can be traced back to it, such as in logs or " +
+					"files the program writes. Resources created before this attribute existed keep a " +
+					"`null` value until they are replaced or re-run.",
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"pass_invocation_id": schema.BoolAttribute{
+				Description: "When `true`, the `invocation_id` is added to the query under the " +
+					"`invocation_id` key, unless `query` already contains that key, so the program can " +
+					"tag its output with it.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"random_seed": schema.BoolAttribute{
+				Description: "When `true` and `seed` is not supplied, a random seed is generated when the " +
+					"program is first run.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"query_env_file": schema.BoolAttribute{
+				Description: "When `true`, the query is also written to a temporary file as `KEY='VALUE'` " +
+					"lines, quoted so that a POSIX shell can source it, and the path of the file is passed " +
+					"to the program in the `QUERY_ENV_FILE` environment variable. The file is created, " +
+					"readable only by the user running Terraform, before each execution of the program and " +
+					"removed once it exits. Every query key must be a valid environment variable name. " +
+					"The path is on the host, so the file is not reachable by a program run in `chroot_dir`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"include_platform": schema.BoolAttribute{
+				Description: "When `true`, the operating system and architecture Terraform is running on " +
+					"are added to the query under the `os` and `arch` keys, unless `query` already " +
+					"contains those keys. The values are those of Go's `GOOS` and `GOARCH`, such as " +
+					"`\"linux\"` and `\"amd64\"`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"login_shell": schema.BoolAttribute{
+				Description: "When `true`, the program is run through a login shell (`$SHELL -l -c`, or " +
+					"`/bin/sh` when `SHELL` is unset) so that PATH changes made by profile scripts, such " +
+					"as those of nvm or rbenv, apply when the program is found. The program and its " +
+					"arguments are quoted so no shell expansion takes place. Starting a login shell adds " +
+					"to the run time of the program and depends on the profile scripts of the user " +
+					"running Terraform, so this is off by default. Ignored on Windows, with a warning.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"path_prepend": schema.ListAttribute{
+				Description: "A list of directories added to the front of the `PATH` environment variable " +
+					"of the program, such as a project-local `bin` directory. The program itself is also " +
+					"searched for in these directories first. Relative directories are resolved against " +
+					"the directory Terraform is run from.",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"include_workspace": schema.BoolAttribute{
+				Description: "When `true`, the name of the current Terraform workspace is added to the " +
+					"query under the `terraform_workspace` key, unless `query` already contains that key, " +
+					"and is set in the `TF_WORKSPACE` environment variable of the program. The workspace " +
+					"is taken from the `TF_WORKSPACE` environment variable of Terraform, or else from the " +
+					"workspace selected in the Terraform data directory, falling back to `\"default\"` " +
+					"when neither is available.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"validate_program_exists": schema.BoolAttribute{
+				Description: "When `true`, Terraform checks during validation that the program can be " +
+					"found, raising a warning rather than an error if it cannot. This catches typos early " +
+					"in environments where the program may legitimately be missing, such as continuous " +
+					"integration hosts that only validate the configuration.",
+				Optional: true,
+			},
+			"pty": schema.BoolAttribute{
+				Description: "When `true`, the standard output of the program (or of the last `pipe` stage) " +
+					"is a pseudo-terminal rather than a pipe, for tools that buffer or format their output " +
+					"differently when not writing to a terminal. Standard input and standard error are " +
+					"unaffected. Programs writing to a terminal often emit color and other control " +
+					"sequences, which may need to be disabled or stripped for the output to parse. Only " +
+					"supported on Linux; on other platforms a warning is raised and a pipe is used.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"strip_ansi": schema.BoolAttribute{
+				Description: "When `true`, ANSI escape sequences, such as the color codes emitted by many " +
+					"command line tools, are removed from the output and error output of the program " +
+					"before they are parsed or stored. Defaults to `false`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"program_sha256": schema.StringAttribute{
+				Description: "Expected hex encoded SHA-256 checksum of the executable of the program, after " +
+					"it is found using `PATH`. The program is not run if its checksum differs, pinning the " +
+					"exact executable that is run. When `login_shell` is set, the checksum of the shell " +
+					"is checked instead.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"require_owner": schema.StringAttribute{
+				Description: "User name or numeric user ID that must own the executable of the program, " +
+					"after it is found using `PATH`, so that a program replaced by another user is not " +
+					"run. When `login_shell` is set, the owner of the shell is checked instead. Ignored " +
+					"on Windows, with a warning.",
+				Optional: true,
+			},
+			"normalize_line_endings": schema.BoolAttribute{
+				Description: "When `true`, Windows (CRLF) line endings in the output and error output of the " +
+					"program are converted to Unix (LF) line endings before they are parsed or stored. " +
+					"Defaults to `false`, keeping the output exactly as written.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"memory_limit": schema.Int64Attribute{
+				Description: "Maximum virtual memory, in bytes, of each process of the program, applied as " +
+					"`RLIMIT_AS`. Allocations beyond it fail, which usually terminates the program. The " +
+					"limit is applied immediately after each process starts. Only supported on Linux; on " +
+					"other platforms a warning is raised and the program runs without the limit.",
+				Optional: true,
+			},
+			"cpu_limit": schema.Int64Attribute{
+				Description: "Maximum CPU time, in seconds, of each process of the program, applied as " +
+					"`RLIMIT_CPU`. A process exceeding it is terminated. The limit is applied immediately " +
+					"after each process starts. Only supported on Linux; on other platforms a warning is " +
+					"raised and the program runs without the limit.",
+				Optional: true,
+			},
+			"chroot_dir": schema.StringAttribute{
+				Description: "Directory to use as the root directory of the program, jailing it from the " +
+					"rest of the filesystem. The first element of `program` must then be an absolute path " +
+					"inside this directory, and `working_dir` is also interpreted relative to it. Only " +
+					"supported on Linux, where Terraform must be running with root privileges (or " +
+					"`CAP_SYS_CHROOT`); on other platforms a warning is raised and the program runs without " +
+					"a jail.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"network_namespace": schema.StringAttribute{
+				Description: "Network namespace to run the program in, either the name of a namespace " +
+					"created by `ip netns add`, found in `/var/run/netns`, or the path of a namespace such " +
+					"as `/proc/1234/ns/net`. The program, its `pipe` stages and any guard or self-test " +
+					"commands are run through `nsenter`, which must be on the `PATH`, so Terraform must be " +
+					"running with root privileges (or `CAP_SYS_ADMIN`). Cannot be combined with " +
+					"`chroot_dir`. Only supported on Linux; on other platforms a warning is raised and the " +
+					"program runs in the network namespace of Terraform.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"interrupt_signal": schema.StringAttribute{
+				Description: "Name of the signal, such as `\"SIGINT\"` or `\"SIGHUP\"`, sent to the " +
+					"program when Terraform is interrupted. If the program has not exited " +
+					"10 seconds after the signal, it is killed. If not supplied, the program is " +
+					"killed immediately. Not supported on Windows, where a warning is raised and the " +
+					"program is always killed.",
+				Optional: true,
+			},
+			"only_if": schema.ListAttribute{
+				Description: "A guard command, as a list of the program and its arguments, run before the " +
+					"program. The program is only run when the guard exits successfully. Otherwise `changed` " +
+					"is `false` and the prior results are kept, or `result` is empty when the resource is " +
+					"created. The guard runs with the working directory and environment of the program.",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"unless": schema.ListAttribute{
+				Description: "A guard command like `only_if`, run after it, which skips the program when it " +
+					"exits successfully.",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"guard_stdin": schema.BoolAttribute{
+				Description: "When `true`, the guards of `only_if` and `unless` receive the query on stdin as " +
+					"the JSON object the program would, before any `stdin_template` or `stdin_encoding` is " +
+					"applied. Otherwise the guards receive empty stdin. The stdin of the program is not " +
+					"affected either way.",
+				Optional: true,
+			},
+			"self_test": schema.ListAttribute{
+				Description: "A command, such as `[\"my-tool\", \"--version\"]`, run before the program to " +
+					"check that it is installed and configured correctly. Each distinct self-test is run " +
+					"once per Terraform run, before the first resource using it; if it fails, every " +
+					"resource using it fails without running its program. The self-test is run with the " +
+					"environment and working directory of the first resource using it.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"timeout": schema.StringAttribute{
+				Description: "Maximum duration, such as `\"30s\"` or `\"5m\"`, the program may run for, " +
+					"including any retries, before it is stopped in the same way as when Terraform is " +
+					"interrupted. If not supplied, the program may run indefinitely.",
+				Optional: true,
+			},
+			"create_timeout": schema.StringAttribute{
+				Description: "Maximum duration the program may run for when the resource is created, " +
+					"used instead of `timeout`, which applies when it is not supplied.",
+				Optional: true,
+			},
+			"update_timeout": schema.StringAttribute{
+				Description: "Maximum duration the program may run for when it is re-run by an update, " +
+					"used instead of `timeout`, which applies when it is not supplied.",
+				Optional: true,
+			},
+			"destroy_timeout": schema.StringAttribute{
+				Description: "Maximum duration the `destroy_program` may run for, used instead of " +
+					"`timeout`, which applies when it is not supplied. Without either, the destroy " +
+					"program may run indefinitely.",
+				Optional: true,
+			},
+			"pass_deadline": schema.BoolAttribute{
+				Description: "When `true` and `timeout`, or the timeout of the phase, is set, the time the program will be stopped is " +
+					"passed in the query as `deadline`, in RFC 3339 format, so that it can finish its work " +
+					"before then. At the deadline the program is sent the `interrupt_signal`, if set, and " +
+					"is killed if it has not exited 10 seconds later; otherwise it is killed immediately. " +
+					"A `deadline` key set in `query` takes precedence.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"stream_logs": schema.BoolAttribute{
+				Description: "When `true`, each line the program writes to its output and error output is " +
+					"logged at the `INFO` level as it is written, giving feedback while long-running " +
+					"programs execute. The output is still captured and parsed once the program exits. " +
+					"Defaults to `false`.",
+				Optional: true,
+			},
+			"log_streams": schema.ListAttribute{
+				Description: "Streams logged by `stream_logs`, `\"stdout\"` and `\"stderr\"`, such as " +
+					"`[\"stdout\"]` when the result is read from the error output with `result_stream`. " +
+					"Defaults to both.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"result_stream": schema.StringAttribute{
+				Description: "Stream the result is read from: `\"stdout\"` (the default), or `\"stderr\"` " +
+					"for tools that print their data to the error output and their messages to the " +
+					"output. With `\"stderr\"` the error output of the last `pipe` stage, or of the " +
+					"program without one, is parsed, and its output is shown instead when it fails. The " +
+					"error output of earlier stages is never parsed.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"heartbeat_timeout": schema.StringAttribute{
+				Description: "Longest pause in the output of the program, such as `\"2m\"`, before it is " +
+					"considered stalled, stopped and an error is raised. This catches a hung program well " +
+					"before an overall `timeout`, for programs that regularly report progress. Output and " +
+					"error output both count. Only applies when `stream_logs` is `true`.",
+				Optional: true,
+			},
+			"heartbeat_interval": schema.StringAttribute{
+				Description: "How often the output of the program is checked against `heartbeat_timeout`, " +
+					"such as `\"10s\"`. Defaults to a quarter of `heartbeat_timeout`.",
+				Optional: true,
+			},
+			"max_line_bytes": schema.Int64Attribute{
+				Description: "Maximum length in bytes of a line logged by `stream_logs`. When the program " +
+					"writes a longer line, it is stopped and an error is raised, rather than buffering the " +
+					"line without bound. Only applies when `stream_logs` is `true`. If not supplied, lines " +
+					"are not limited.",
+				Optional: true,
+			},
+			"max_total_bytes": schema.Int64Attribute{
+				Description: "Maximum number of bytes of output and error output, combined, captured from " +
+					"the program. When the program writes more than this, it is stopped and an error is " +
+					"raised. If not supplied, output is not limited.",
+				Optional: true,
+			},
+			"ephemeral": schema.BoolAttribute{
+				Description: "When `true`, the program is also run whenever Terraform refreshes the " +
+					"resource, such as at the start of every `plan` and `apply`, so that `result` and the " +
+					"other computed attributes always reflect its latest output, as a data source would. " +
+					"Any side effects of the program are repeated on every refresh, and output that " +
+					"differs between runs is reported by Terraform as changed outside of Terraform on " +
+					"every plan. Refreshing with `-refresh=false` skips the run. The previous `result` is " +
+					"passed to the program as it is by `update_in_place`.",
+				Optional: true,
+			},
+			"replace_when_result_changes": schema.ListAttribute{
+				Description: "Result keys whose change replaces the resource, re-running the program as " +
+					"when it is created. Changes are only detected when the program is re-run by a " +
+					"refresh, so this requires `ephemeral` to be `true`: the refreshed values are compared " +
+					"with those recorded in `replace_result_snapshot` when the program last ran during an " +
+					"apply, and the plan replaces the resource when any of them differ, including keys " +
+					"added to or removed from `result`.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"replace_result_snapshot": schema.MapAttribute{
+				Description: "The values of the `replace_when_result_changes` keys of `result` when the " +
+					"program last ran during an apply.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"update_in_place": schema.BoolAttribute{
+				Description: "When `true`, changes to the arguments of the resource re-run the program in " +
+					"place and update its results, rather than replacing the resource. When re-run this " +
+					"way, the previous `result` is passed to the program as an object under the " +
+					"`previous_result_key` key of its input. That key is never present when the resource " +
+					"is created or refreshed.",
+				Optional: true,
+			},
+			"update_result_behavior": schema.StringAttribute{
+				Description: "What happens to the computed results, such as `result`, when arguments of " +
+					"the resource change without replacing it. `\"unknown\"` (the default) shows the results " +
+					"as known after apply in the plan, and the apply recomputes them by running the program " +
+					"as it is run on create, without the previous result. `\"preserve\"` does not re-run " +
+					"the program, and keeps the prior results, showing them unchanged in the plan. " +
+					"`\"rerun\"` re-runs the program in place, as `update_in_place` does, passing it the " +
+					"previous result.",
+				Optional: true,
+			},
+			"merge_result_keys": schema.ListAttribute{
+				Description: "Result keys refreshed when the program is re-run by `update_in_place`. Only " +
+					"these keys are taken from the new output, and every other key keeps its previous value " +
+					"in `result`. A listed key missing from the new output is removed from `result`. If not " +
+					"supplied, the new output replaces `result` entirely. Has no effect when the resource is " +
+					"created. Only supported when `output_format` is `\"json\"`.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"previous_result_key": schema.StringAttribute{
+				Description: "Key under which the previous `result` is passed to the program when it is " +
+					"re-run by an update. The key is never present when the resource is created or " +
+					"refreshed. Must not be a key of `query`. Defaults to `\"previous_result\"`.",
+				Optional: true,
+			},
+			"previous_exit_code_key": schema.StringAttribute{
+				Description: "Key under which the `exit_code` of the previous run is passed to the program, " +
+					"as a number, when it is re-run by an update. The key is never present when the " +
+					"resource is created or refreshed, nor for resources whose previous run recorded no " +
+					"exit code. Must not be a key of `query`. Defaults to `\"previous_exit_code\"`.",
+				Optional: true,
+			},
+			"startup_jitter": schema.StringAttribute{
+				Description: "Maximum random delay, such as `\"10s\"`, to wait before running the program " +
+					"when the resource is created. This spreads the load of many resources created at " +
+					"once against a rate limited service. The wait ends early if Terraform is interrupted, " +
+					"in which case the program is not run.",
+				Optional: true,
+			},
+			"lookup_retries": schema.Int64Attribute{
+				Description: "Number of times finding the program is retried when it is not found or not " +
+					"executable, for programs built or installed by another resource in the same apply " +
+					"that may not be ready yet. This is separate from `retries`, which retries failed " +
+					"executions. Not used with `chroot_dir`, where the program is not looked up. " +
+					"Defaults to `0`.",
+				Optional: true,
+			},
+			"lookup_retry_interval": schema.StringAttribute{
+				Description: "Duration to wait between attempts to find the program, such as `\"2s\"`. " +
+					"Defaults to `\"1s\"`.",
+				Optional: true,
+			},
+			"retries": schema.Int64Attribute{
+				Description: "Number of times the program is retried when its execution fails. " +
+					"If not supplied, the provider `default_retries` is used.",
+				Optional: true,
+			},
+			"retry_interval": schema.StringAttribute{
+				Description: "Duration to wait before the first retry, such as `\"5s\"`. " +
+					"If not supplied, the provider `default_retry_interval` is used.",
+				Optional: true,
+			},
+			"retry_backoff": schema.Float64Attribute{
+				Description: "Multiplier applied to the retry interval after each retry, which grows " +
+					"the interval up to 5 minutes. Must be at least `1`. If not supplied, the provider " +
+					"`default_retry_backoff` is used.",
+				Optional: true,
+			},
+			"cache": schema.BoolAttribute{
+				Description: "When `true`, the output of the program is stored in the provider `cache_dir` " +
+					"and reused, without running the program, by any later run with the same inputs. Only " +
+					"enable this for deterministic programs without side effects, as a cached run does " +
+					"nothing but return the stored output. Ignored, with a warning, when the provider " +
+					"`cache_dir` is not set.",
+				Optional: true,
+			},
+			"dry_run": schema.BoolAttribute{
+				Description: "When `true`, the program is looked up and the query is prepared as usual, but " +
+					"the program is not run. Instead `dry_run_result` is used as its output, or, when " +
+					"`output_format` is `\"json_array\"`, as the single element of its output. This allows " +
+					"the wiring of a configuration to be tested without the side effects of the program.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"dry_run_result": schema.MapAttribute{
+				Description: "A map of string values used as the output of the program when `dry_run` is " +
+					"`true`. If not supplied, the output is an empty object.",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.Map{
+					mapRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"output_format": schema.StringAttribute{
+				Description: "Format of the program output. `\"json\"` (the default) expects a JSON object " +
+					"whose values populate `result`. `\"json_array\"` expects a JSON array of objects, " +
+					"each of which becomes an element of `results`; any element that is not an object " +
+					"is reported as an error. `\"hcl\"` expects HCL native syntax whose top-level " +
+					"attributes, which must be constant values, populate `result` in the same way as the " +
+					"keys of a JSON object; blocks are reported as an error.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"result_from_exit_code": schema.BoolAttribute{
+				Description: "When `true`, the output of the program is ignored and its exit code is stored " +
+					"in `result` under the `code` key, for programs that only communicate through their " +
+					"exit code. Every exit code is treated as success, except those mapped to " +
+					"`\"error\"` in `exit_code_severity`. Only supported when `output_format` is " +
+					"`\"json\"`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"dedupe_results": schema.BoolAttribute{
+				Description: "When `true`, elements of the program output equal to an earlier element, " +
+					"with the same keys and values in any order, are removed from `results`, for tools " +
+					"that emit overlapping records. The first occurrence of each element is kept, in the " +
+					"order of the output, and `result_json` still holds the output as returned. Only " +
+					"supported when `output_format` is `\"json_array\"`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"reject_duplicate_keys": schema.BoolAttribute{
+				Description: "When `true`, program output with a key that appears more than once in the " +
+					"same object is an error, rather than silently using the last value of the key, which " +
+					"usually hides a bug in the program. The top-level object is checked, or each object " +
+					"in the array when `output_format` is `\"json_array\"`, but not nested objects. " +
+					"Defaults to `false`.",
+				Optional: true,
+			},
+			"require_canonical_output": schema.BoolAttribute{
+				Description: "When `true`, a warning is raised when the program output is not canonical " +
+					"JSON: compact, with object keys in sorted order and strings escaped only where " +
+					"necessary, apart from whitespace before and after it. Deterministic output keeps " +
+					"`output_sha256` and state diffs stable. Defaults to `false`. Only supported when " +
+					"`output_format` is `\"json\"` or `\"json_array\"`.",
+				Optional: true,
+			},
+			"error_pattern": schema.StringAttribute{
+				Description: "Regular expression, in [RE2 syntax](https://github.com/google/re2/wiki/Syntax), " +
+					"extracting the meaningful error from the error output of a program that fails. When it " +
+					"matches, the error reports the `message` named group, or the whole match without one, " +
+					"in place of the full error output, under the summary in the `summary` named group, if " +
+					"any. Other non-empty named groups, such as `(?P<line>\\d+)`, are listed after the " +
+					"program. Error output that does not match is reported in full.",
+				Optional: true,
+			},
+			"auto_detect_binary": schema.BoolAttribute{
+				Description: "Treat output containing NUL bytes or invalid UTF-8 as binary rather than " +
+					"failing to parse it as JSON. Binary output is stored base64 encoded under the " +
+					"`stdout_base64` key of `result` and `result_json`, and a warning is raised. Text " +
+					"output is parsed as usual. Defaults to `false`. Only supported when `output_format` " +
+					"is `\"json\"`, and cannot be combined with `success_regexp`, `result_from_exit_code` " +
+					"or `numeric_result_key`.",
+				Optional: true,
+			},
+			"success_regexp": schema.StringAttribute{
+				Description: "Regular expression, in [RE2 syntax](https://github.com/google/re2/wiki/Syntax), " +
+					"marking a successful run of programs that print neither JSON nor a meaningful exit " +
+					"code. When the output matches, the program succeeds whatever its exit code, unless it " +
+					"is mapped to `\"error\"` in `exit_code_severity`, and the output is not parsed as " +
+					"JSON: `result` holds the capture groups instead, under `match_0` for the whole match, " +
+					"`match_1` and up for each group, and the name of each named group, such as " +
+					"`(?P<version>\\d+)`. Output that does not match is an error. Only supported when " +
+					"`output_format` is `\"json\"`, and cannot be combined with `result_from_exit_code` " +
+					"or `numeric_result_key`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
 				},
 			},
-			"program": schema.ListAttribute{
-				Description: "A list of strings, whose first element is the program to run and whose " +
-					"subsequent elements are optional command line arguments to the program. Terraform does " +
-					"not execute the program through a shell, so it is not necessary to escape shell " +
-					"metacharacters nor add quotes around arguments containing spaces.",
-				Required:    true,
-				ElementType: types.StringType,
-				PlanModifiers: []planmodifier.List{
-					listplanmodifier.RequiresReplace(),
+			"parse_last_json": schema.BoolAttribute{
+				Description: "When `true`, the program output is parsed from the last complete JSON object " +
+					"it ends with, ignoring any text before it, such as a banner or warning printed by a " +
+					"chatty command. This is ambiguous when the noise itself contains JSON, so only use it " +
+					"for programs that cannot be made to print clean output. The output must still end with " +
+					"the object. Only supported when `output_format` is `\"json\"`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
 				},
 			},
-			"working_dir": schema.StringAttribute{
-				Description: "Working directory of the program. If not supplied, the program will run " +
-					"in the current directory.",
+			"stdout_log_file": schema.StringAttribute{
+				Description: "Path, relative to `working_dir`, of a file the output of the program is " +
+					"copied to as it is written, while still being parsed for `result`. The file is " +
+					"truncated before the program is run, and holds the output of every retry. This keeps " +
+					"full output for debugging without storing it in the state.",
+				Optional: true,
+			},
+			"stderr_log_file": schema.StringAttribute{
+				Description: "Path, relative to `working_dir`, of a file the error output of the program, " +
+					"and of every `pipe` stage, is copied to as it is written. The file is truncated before " +
+					"the program is run, and holds the error output of every retry.",
+				Optional: true,
+			},
+			"allow_empty_output": schema.BoolAttribute{
+				Description: "When `true`, output that is empty or only whitespace is treated as an empty " +
+					"result, so `result` is an empty map and `result_json` is `\"{}\"`, rather than an error. " +
+					"When `output_format` is `\"json_array\"`, `results` is an empty list instead. This " +
+					"suits programs that are run only for their side effects.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"non_object_output": schema.StringAttribute{
+				Description: "How output that is valid JSON, but not an object, such as an array or a " +
+					"string, is handled when `output_format` is `\"json\"`. `\"error\"` (the default) " +
+					"reports it as an error. `\"wrap\"` stores it in `result` under `non_object_key`, " +
+					"with arrays and objects encoded as JSON strings. `\"raw\"` leaves `result` empty, so " +
+					"the value is only available from `result_json`.",
 				Optional: true,
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					stringRequiresReplaceUnlessUpdateInPlace(),
 				},
 			},
-			"query": schema.MapAttribute{
-				Description: "A map of string values to pass to the external program as the query " +
-					"arguments. If not supplied, the program will receive an empty object as its input.",
+			"non_object_key": schema.StringAttribute{
+				Description: "Key of `result` under which output that is not a JSON object is stored when " +
+					"`non_object_output` is `\"wrap\"`. Defaults to `\"value\"`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"numeric_result_key": schema.StringAttribute{
+				Description: "When set, the output of the program must be a single number, such as that " +
+					"printed by `wc -l` or `date +%s`, rather than JSON. The number, with surrounding " +
+					"whitespace removed, is stored as a string in `result` under this key, and any other " +
+					"output is reported as an error. Only supported when `output_format` is `\"json\"`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"error_key": schema.StringAttribute{
+				Description: "Name of a key in the JSON output of the program used to report errors. When " +
+					"the key is present with a non-empty value, an error is raised with that value as its " +
+					"message, whatever the exit status of the program.",
+				Optional: true,
+			},
+			"error_detail_key": schema.StringAttribute{
+				Description: "Name of a key in the JSON output of the program holding further, possibly " +
+					"multi-line, details of an error reported under `error_key`.",
+				Optional: true,
+			},
+			"output_files": schema.MapAttribute{
+				Description: "A map of result keys to paths of files the program is expected to write. " +
+					"After the program has run, the contents of each file are stored in `result` under its " +
+					"key, replacing any value the program output for that key. Relative paths are resolved " +
+					"against `working_dir`. Files must contain UTF-8 text.",
 				Optional:    true,
 				ElementType: types.StringType,
 				PlanModifiers: []planmodifier.Map{
-					mapplanmodifier.RequiresReplace(),
+					mapRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"output_glob": schema.StringAttribute{
+				Description: "A glob pattern, such as `\"out/*.json\"`, matching files the program is " +
+					"expected to write, resolved against `working_dir` when relative. After the program has " +
+					"run, the contents of each matching regular file are stored in `glob_files`, keyed by its " +
+					"path relative to `working_dir`. No matching files give an empty `glob_files`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"output_glob_sha256": schema.BoolAttribute{
+				Description: "When `true`, `glob_files` stores the hex encoded SHA-256 checksum of each file " +
+					"matching `output_glob` instead of its contents, which suits large or binary artifacts. " +
+					"Otherwise files must contain UTF-8 text.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"glob_files": schema.MapAttribute{
+				Description: "The contents, or checksums when `output_glob_sha256` is `true`, of the files " +
+					"matching `output_glob`, keyed by their path relative to `working_dir`.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"parse_diagnostics": schema.BoolAttribute{
+				Description: "When `true`, a `diagnostics` array in the program output is removed from " +
+					"`result` and each of its elements, an object with a `severity` of `\"error\"` or " +
+					"`\"warning\"`, a `summary` and an optional `detail`, is reported as a Terraform " +
+					"diagnostic. Any error fails the resource. Only supported when `output_format` is " +
+					"`\"json\"`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"protocol_version": schema.StringAttribute{
+				Description: "Version of the protocol between the configuration and the program, such as " +
+					"`\"2\"` or `\"2.1\"`, added to the query under the `__protocol_version` key, unless " +
+					"`query` already contains that key.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringRequiresReplaceUnlessUpdateInPlace(),
 				},
 			},
+			"require_protocol_version": schema.BoolAttribute{
+				Description: "When `true`, the program must return a version compatible with " +
+					"`protocol_version` in its result under the `__protocol_version` key, or an error is " +
+					"raised. Versions are compatible when their major versions, before the first `.`, are " +
+					"equal. Only supported when `output_format` is `\"json\"`.",
+				Optional: true,
+			},
+			"echo_key": schema.StringAttribute{
+				Description: "A key of the query that the program must return unchanged under the same " +
+					"key of its result, as a check that it received and decoded its input correctly. A " +
+					"missing or different value is reported as an error. Only supported when " +
+					"`output_format` is `\"json\"`.",
+				Optional: true,
+			},
+			"manifest_file": schema.StringAttribute{
+				Description: "Path, relative to `working_dir`, to a JSON manifest declaring the protocol of " +
+					"the program, as an object with the optional `query_keys`, `required_query_keys` and " +
+					"`result_keys` lists of strings. When planning, a warning is raised for each `query` key " +
+					"the manifest does not declare, each required key missing from `query`, and each " +
+					"`result_types` key not in `result_keys`. The program is not run by this check.",
+				Optional: true,
+			},
+			"result_types": schema.MapAttribute{
+				Description: "A map of result keys to the type their values are expected to have, one " +
+					"of `\"string\"`, `\"number\"` or `\"bool\"`. Values are still stored as strings in " +
+					"`result`, but an error is raised when the program returns a value that cannot be " +
+					"interpreted as its declared type. Keys missing from the result are not checked.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"allowed_result_keys": schema.ListAttribute{
+				Description: "The only keys the program may return, enforcing its output contract: an " +
+					"error is raised listing any other key, such as a debug key left in by mistake. With " +
+					"`output_format` `\"json_array\"` the keys of every element are checked. Only the " +
+					"output of the program is checked, not keys added by `output_files`, " +
+					"`flatten_arrays` or `result_transforms`, and keys need not be present.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
 			"result": schema.MapAttribute{
 				Description: "A map of string values to pass to the external program as the query " +
 					"arguments. If not supplied, the program will receive an empty object as its input.",
 				Computed:    true,
 				ElementType: types.StringType,
 			},
+			"exit_code": schema.Int64Attribute{
+				Description: "Exit code of the program when it was last run, which is `0` unless a " +
+					"non-zero exit code was accepted, such as by `exit_code_severity`, or the program was " +
+					"not run, as for a dry run.",
+				Computed: true,
+			},
+			"partial_on_timeout": schema.BoolAttribute{
+				Description: "When `true`, a program stopped by its timeout is not an error if the output " +
+					"it wrote before it was stopped contains a complete result, which is used instead and " +
+					"`timed_out` is set. The result is taken from the complete lines of output: with " +
+					"`output_format` `\"json\"` the last JSON object, such as the latest line of a program " +
+					"streaming one object per line, with `\"json_array\"` the complete elements of the " +
+					"array, and with `\"hcl\"` the complete attributes. The result is likely to be " +
+					"incomplete, and may even be a nested object of a partial one, so only use this for " +
+					"best-effort data collection. A warning is raised when it applies. Defaults to `false`.",
+				Optional: true,
+			},
+			"timed_out": schema.BoolAttribute{
+				Description: "Whether the result is the partial output of a program stopped by its timeout, " +
+					"as allowed by `partial_on_timeout`.",
+				Computed: true,
+			},
+			"output_bytes": schema.Int64Attribute{
+				Description: "Size in bytes of the raw output of the external program.",
+				Computed:    true,
+			},
+			"output_sha256": schema.StringAttribute{
+				Description: "Hex encoded SHA256 hash of the raw output of the external program. This can " +
+					"be used to detect changes to the output without comparing the full `result`.",
+				Computed: true,
+			},
+			"results": schema.ListAttribute{
+				Description: "A list of maps of string values returned from the external program when " +
+					"`output_format` is `\"json_array\"`.",
+				Computed:    true,
+				ElementType: types.MapType{ElemType: types.StringType},
+			},
+			"result_json": schema.StringAttribute{
+				Description: "The complete output of the external program as compact JSON, preserving " +
+					"nested objects, arrays and the types of values that `result` flattens to strings. " +
+					"Use the `jsondecode` function to access it, such as " +
+					"`jsondecode(exec_persisted.example.result_json).foo.bar`.",
+				Computed: true,
+			},
+			"result_dynamic": dynamicAttribute{
+				Attribute: schema.StringAttribute{
+					Description: "The complete output of the external program with its structure and types, " +
+						"so nested values can be read without `jsondecode`, such as " +
+						"`exec_persisted.example.result_dynamic.foo.bar`. Objects are objects, arrays are tuples " +
+						"and numbers, booleans and `null` keep their types. It holds the same value as " +
+						"`result_json`.",
+					Computed: true,
+				},
+			},
+			"flatten_arrays": schema.BoolAttribute{
+				Description: "When `true`, each top-level array in the program output is expanded into " +
+					"`result` keys rather than being an error, for consumers of string maps: each element " +
+					"under the array key and its index, such as `items.0` and `items.1`, and the number of " +
+					"elements under `items.count`. Objects and arrays within an array are expanded the " +
+					"same way, such as `items.0.name` and `items.0.tags.0`, and numbers, booleans and " +
+					"`null` are converted to strings. Top-level objects are left as they are. " +
+					"`result_json` still holds the output as returned. Only supported when " +
+					"`output_format` is `\"json\"`.",
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"result_transforms": schema.MapAttribute{
+				Description: "A map of keys to add to `result` to Go [text/template](https://pkg.go.dev/text/template) " +
+					"expressions computing their values from the result of the program, such as " +
+					"`\"{{ .region }}/{{ .name | lower }}\"`. Result values are available as `{{ .key }}`, and " +
+					"referencing a missing key is an error. Besides the template builtins, the `lower`, " +
+					"`upper`, `trim`, `trimPrefix PREFIX`, `trimSuffix SUFFIX` and `replace OLD NEW` " +
+					"functions are available. Transforms see the program result, not each other, and " +
+					"replace program values with the same key. Only supported when `output_format` is " +
+					"`\"json\"`.",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.Map{
+					mapRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"no_change_output": schema.StringAttribute{
+				Description: "A sentinel value, such as `\"unchanged\"`, that the program prints instead of " +
+					"a result to signal that it made no changes. When the output of the program, with " +
+					"surrounding whitespace removed, equals this value, the result of the previous run is " +
+					"kept and `changed` is `false`. When the resource is created there is no previous " +
+					"result, so `result` is empty.",
+				Optional: true,
+			},
+			"changed": schema.BoolAttribute{
+				Description: "Whether the last run of the program produced a new result, which is `false` " +
+					"when its output matched `no_change_output`.",
+				Computed: true,
+			},
+			"result_fingerprint": schema.StringAttribute{
+				Description: "A SHA-256 hash of `result` and `results`, which only changes when their " +
+					"values do. Use it in the `triggers` or `replace_triggered_by` of dependent resources " +
+					"to replace them when the result of the program changes.",
+				Computed: true,
+			},
+			"result_sections": schema.ListAttribute{
+				Description: "Top-level keys of the program output whose values are objects to expose " +
+					"as separate maps in `sections` rather than in `result`. Each section must be present " +
+					"in the output and be a map of string keys and string values. Only supported when " +
+					"`output_format` is `\"json\"`.",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"sections": schema.MapAttribute{
+				Description: "A map of the sections named in `result_sections` to their maps of string " +
+					"values returned from the external program.",
+				Computed:    true,
+				ElementType: types.MapType{ElemType: types.StringType},
+			},
+			"result_attributes": schema.MapAttribute{
+				Description: "A map of result keys to their types, one of `\"string\"`, `\"number\"` or " +
+					"`\"bool\"`, which are decoded into `result_object`. Every declared key must be returned " +
+					"by the program with a value of its type. Numbers and bools may be returned as JSON " +
+					"values or as strings, while strings must be JSON strings. Only supported when " +
+					"`output_format` is `\"json\"`.",
+				Optional:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.Map{
+					mapRequiresReplaceUnlessUpdateInPlace(),
+				},
+			},
+			"result_object": dynamicAttribute{
+				Attribute: schema.StringAttribute{
+					Description: "The values of the keys declared in `result_attributes`, decoded to their " +
+						"types, as an object with an attribute of the declared type for each key, so a key " +
+						"declared as a number is read as the number `result_object.count`.",
+					Computed: true,
+				},
+			},
 		},
 	}
 }
 
-func (r *programResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	var plan execModelV0
+func (r *programResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config execModelV0
 
-	diags := req.Plan.Get(ctx, &plan)
-	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	program := make([]string, 0, len(plan.Program.Elements()))
-
-	for _, programArgRaw := range plan.Program.Elements() {
-		programArg := strings.Replace(programArgRaw.String(), "\"", "", -1)
-		if programArg == "" {
-			continue
+	switch behavior := config.UpdateResultBehavior.ValueString(); behavior {
+	case "", updateResultBehaviorUnknown, updateResultBehaviorPreserve, updateResultBehaviorRerun:
+		if config.UpdateInPlace.ValueBool() &&
+			(behavior == updateResultBehaviorUnknown || behavior == updateResultBehaviorPreserve) {
+			resp.Diagnostics.AddAttributeError(path.Root("update_result_behavior"), "Conflicting Update Result Behavior",
+				fmt.Sprintf("The update_result_behavior %q cannot be used when update_in_place is true, "+
+					"as the program is re-run on update.", behavior))
 		}
-		program = append(program, programArg)
+	default:
+		resp.Diagnostics.AddAttributeError(path.Root("update_result_behavior"), "Invalid Update Result Behavior",
+			fmt.Sprintf("The update_result_behavior must be one of %q, %q or %q, got: %q",
+				updateResultBehaviorUnknown, updateResultBehaviorPreserve, updateResultBehaviorRerun, behavior))
+	}
+
+	resp.Diagnostics.Append(validateStatic(config)...)
+
+	if !config.ManifestFile.IsNull() {
+		resp.Diagnostics.Append(validateManifest(config)...)
 	}
 
-	if len(program) == 0 {
-		resp.Diagnostics.AddError("External Program Missing", "The data source was configured without a program to execute. Verify the configuration contains at least one non-empty value.")
+	switch {
+	case !config.ProgramEnv.IsNull() && !config.Script.IsNull():
+		resp.Diagnostics.AddAttributeError(path.Root("program_env"), "Conflicting Program Environment Variable",
+			"The program_env attribute cannot be combined with script.")
+	case config.Program.IsNull() && config.Script.IsNull() && config.ProgramEnv.IsNull():
+		resp.Diagnostics.AddError("External Program Missing",
+			"Exactly one of the program or script attributes must be set.")
+	case !config.Program.IsNull() && !config.Script.IsNull():
+		resp.Diagnostics.AddAttributeError(path.Root("script"), "Conflicting Script",
+			"Exactly one of the program or script attributes must be set.")
+	case !config.Interpreter.IsNull() && config.Script.IsNull():
+		resp.Diagnostics.AddAttributeError(path.Root("interpreter"), "Interpreter Without Script",
+			"The interpreter attribute can only be used when the script attribute is set.")
+	}
+
+	// Programs inside a chroot are resolved relative to the jail, which may
+	// not exist until apply, and program_env is resolved when applying.
+	if !config.ValidateProgramExists.ValueBool() || !config.ChrootDir.IsNull() || config.Program.IsUnknown() ||
+		!config.ProgramEnv.IsNull() {
 		return
 	}
 
-	query := make(map[string]string)
+	for _, programArgRaw := range config.Program.Elements() {
+		programArg, ok := programArgRaw.(types.String)
+		if !ok || programArg.IsUnknown() {
+			return
+		}
 
-	for key, val := range plan.Query.Elements() {
-		valArg := strings.Replace(val.String(), "\"", "", -1)
-		if valArg == "" {
+		if programArg.ValueString() == "" {
 			continue
 		}
-		query[key] = valArg
-	}
-	queryJson, err := json.Marshal(query)
-	if err != nil {
-		resp.Diagnostics.AddError("Query Handling Failed", "The data source received an unexpected error while attempting to parse the query. "+
-			"This is always a bug in the external provider code and should be reported to the provider developers.")
+
+		if _, err := exec.LookPath(programArg.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeWarning(path.Root("program"), "External Program Not Found",
+				"The program could not be found on the platform where Terraform is validating the configuration. "+
+					"Applying the configuration on this platform would fail unless the program is made available first."+
+					fmt.Sprintf("\n\nPlatform: %s", runtime.GOOS)+
+					fmt.Sprintf("\nProgram: %s", programArg.ValueString())+
+					fmt.Sprintf("\nError: %s", err))
+		}
+
 		return
 	}
+}
+
+// validateManifest cross-checks the query and result_types of the
+// configuration against the manifest_file of the program. Values that are not
+// yet known are not checked.
+func validateManifest(config execModelV0) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if config.ManifestFile.IsUnknown() || config.WorkingDir.IsUnknown() ||
+		config.Query.IsUnknown() || config.ResultTypes.IsUnknown() {
+		return diags
+	}
 
-	// first element is assumed to be an executable command, possibly found
-	// using the PATH environment variable.
-	_, err = exec.LookPath(program[0])
+	name := config.ManifestFile.ValueString()
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(config.WorkingDir.ValueString(), name)
+	}
 
+	manifest, err := readManifest(name)
 	if err != nil {
-		resp.Diagnostics.AddError("External Program Lookup Failed",
-			`The data source received an unexpected error while attempting to find the program.
+		diags.AddAttributeWarning(path.Root("manifest_file"), "Program Manifest Not Read",
+			"The data source received an unexpected error while attempting to read the program manifest, "+
+				"so the configuration was not checked against it."+
+				fmt.Sprintf("\n\nFile: %s", name)+
+				fmt.Sprintf("\nError: %s", err))
+		return diags
+	}
 
-The program must be accessible according to the platform where Terraform is running.
+	queryKeys := make([]string, 0, len(config.Query.Elements()))
+	for key := range config.Query.Elements() {
+		queryKeys = append(queryKeys, key)
+	}
 
-If the expected program should be automatically found on the platform where Terraform is running, ensure that the program is in an expected directory. On Unix-based platforms, these directories are typically searched based on the '$PATH' environment variable. On Windows-based platforms, these directories are typically searched based on the '%PATH%' environment variable.
+	resultTypeKeys := make([]string, 0, len(config.ResultTypes.Elements()))
+	for key := range config.ResultTypes.Elements() {
+		resultTypeKeys = append(resultTypeKeys, key)
+	}
 
-If the expected program is relative to the Terraform configuration, it is recommended that the program name includes the interpolated value of 'path.module' before the program name to ensure that it is compatible with varying module usage. For example: "${path.module}/my-program"
+	return checkManifest(manifest, queryKeys, resultTypeKeys)
+}
 
-The program must also be executable according to the platform where Terraform is running. On Unix-based platforms, the file on the filesystem must have the executable bit set. On Windows-based platforms, no action is typically necessary.
-`+
-				fmt.Sprintf("\nPlatform: %s", runtime.GOOS)+
-				fmt.Sprintf("\nProgram: %s", program[0])+
-				fmt.Sprintf("\nError: %s", err))
+// ModifyPlan shows the prior computed values in the plan of an update when
+// update_result_behavior is "preserve", as Update keeps them unchanged.
+func (r *programResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing is planned on create or destroy.
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan, prior execModelV0
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
 
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	cmd := exec.CommandContext(ctx, program[0], program[1:]...)
-	cmd.Dir = plan.WorkingDir.ValueString()
-	cmd.Stdin = bytes.NewReader(queryJson)
+	// Result keys refreshed by an ephemeral resource that changed since the
+	// program last ran during an apply replace the resource, which is planned
+	// by leaving its result unknown.
+	if !plan.ReplaceWhenResultChanges.IsNull() && !prior.Result.IsNull() && !prior.ReplaceResultSnapshot.IsNull() {
+		var keys []string
+		result := make(map[string]string, len(prior.Result.Elements()))
+		snapshot := make(map[string]string, len(prior.ReplaceResultSnapshot.Elements()))
 
-	tflog.Trace(ctx, "Executing external program", map[string]interface{}{"program": cmd.String()})
+		resp.Diagnostics.Append(plan.ReplaceWhenResultChanges.ElementsAs(ctx, &keys, false)...)
+		resp.Diagnostics.Append(prior.Result.ElementsAs(ctx, &result, false)...)
+		resp.Diagnostics.Append(prior.ReplaceResultSnapshot.ElementsAs(ctx, &snapshot, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
 
-	resultJson, err := cmd.Output()
+		if changed := changedResultKeys(result, snapshot, keys); len(changed) > 0 {
+			tflog.Debug(ctx, "Replacing external program resource as its result changed", map[string]interface{}{"keys": changed})
 
-	tflog.Trace(ctx, "Executed external program", map[string]interface{}{"program": cmd.String(), "output": string(resultJson)})
+			plan.Result = types.MapUnknown(types.StringType)
+			resp.RequiresReplace = append(resp.RequiresReplace, path.Root("result"))
 
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			if exitErr.Stderr != nil && len(exitErr.Stderr) > 0 {
-				resp.Diagnostics.AddError("External Program Execution Failed",
-					"The data source received an unexpected error while attempting to execute the program."+
-						fmt.Sprintf("\n\nProgram: %s", cmd.Path)+
-						fmt.Sprintf("\nError Message: %s", string(exitErr.Stderr))+
-						fmt.Sprintf("\nState: %s", err))
-				return
+			resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+			return
+		}
+	}
+
+	// Changes to the contents of the environment_files are not seen by
+	// Terraform, so the environment is hashed again to detect them.
+	if plan.ReplaceOnEnvChange.ValueBool() && !prior.EnvironmentHash.IsNull() && !plan.TemplateWorkingDir.ValueBool() &&
+		!plan.Environment.IsUnknown() && !plan.EnvironmentFiles.IsUnknown() && !plan.WorkingDir.IsUnknown() {
+		if hash, ok := plannedEnvironmentHash(ctx, plan); ok && hash != prior.EnvironmentHash.ValueString() {
+			tflog.Debug(ctx, "Re-running external program as its environment changed")
+
+			plan.EnvironmentHash = types.StringUnknown()
+			plan.Result = types.MapUnknown(types.StringType)
+
+			replace, d := requiresReplaceUnlessUpdateInPlace(ctx, req.Config)
+			resp.Diagnostics.Append(d...)
+			if replace {
+				resp.RequiresReplace = append(resp.RequiresReplace, path.Root("environment_hash"))
 			}
 
-			resp.Diagnostics.AddError("External Program Execution Failed",
-				"The data source received an unexpected error while attempting to execute the program.\n\n"+
-					"The program was executed, however it returned no additional error messaging."+
-					fmt.Sprintf("\n\nProgram: %s", cmd.Path)+
-					fmt.Sprintf("\nState: %s", err))
+			resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
 			return
 		}
+	}
 
-		resp.Diagnostics.AddError("External Program Execution Failed",
-			"The data source received an unexpected error while attempting to execute the program."+
-				fmt.Sprintf("\n\nProgram: %s", cmd.Path)+
-				fmt.Sprintf("\nError: %s", err))
+	if plan.UpdateResultBehavior.ValueString() != updateResultBehaviorPreserve {
 		return
 	}
 
-	result := map[string]interface{}{}
-	err = json.Unmarshal(resultJson, &result)
-	if err != nil {
-		resp.Diagnostics.AddError("Unexpected External Program Results",
-			`The data source received unexpected results after executing the program.
+	// Nothing is preserved when the resource is replaced.
+	if replaced := replacedAttributes(ctx, req.Plan, req.State); len(replaced) > 0 {
+		tflog.Debug(ctx, "Not preserving external program results as the resource is replaced",
+			map[string]interface{}{"attributes": replaced})
+		return
+	}
 
-Program output must be a JSON encoded map of string keys and string values.
+	plan.preserveResults(prior)
 
-If the error is unclear, the output can be viewed by enabling Terraform's logging at TRACE level. Terraform documentation on logging: https://www.terraform.io/internals/debugging
-`+
-				fmt.Sprintf("\nProgram: %s", cmd.Path)+
-				fmt.Sprintf("\nResult Error: %s", err))
-		return
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+}
+
+// dryRunOutput returns the dry_run_result encoded as the output of a program
+// using the given output format would be.
+func dryRunOutput(ctx context.Context, dryRunResult types.Map, outputFormat string) ([]byte, error) {
+	result := make(map[string]string, len(dryRunResult.Elements()))
+	if diags := dryRunResult.ElementsAs(ctx, &result, false); diags.HasError() {
+		return nil, fmt.Errorf("%v", diags)
 	}
 
-	i := plan
-	i.Id = types.StringValue("example-id")
+	if outputFormat == outputFormatJSONArray {
+		return json.Marshal([]map[string]string{result})
+	}
 
-	var d diag.Diagnostics
-	i.Result, d = types.MapValueFrom(ctx, types.StringType, result)
+	return json.Marshal(result)
+}
+
+// randomSeed returns a random hex encoded 128 bit seed.
+func randomSeed() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func (r *programResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan execModelV0
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state, diags := r.run(ctx, plan, nil, phaseCreate)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	if len(d) > 0 {
-		resp.Diagnostics.Append(d...)
+	resp.Diagnostics.Append(state.snapshotReplaceResult(ctx)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	diags = resp.State.Set(ctx, i)
+	diags = resp.State.Set(ctx, state)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 }
 
-// Read does not need to perform any operations as the state in ReadResourceResponse is already populated.
-func (r *programResource) Read(context.Context, resource.ReadRequest, *resource.ReadResponse) {
+// selfTest runs the self_test command, unless it has already been run by
+// this provider process, in which case its earlier outcome is reported. The
+// command runs with the environment and working directory of the first
+// resource to use it.
+func (r *programResource) selfTest(ctx context.Context, selfTest types.List, e *execution) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var args []string
+	diags.Append(selfTest.ElementsAs(ctx, &args, false)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	if len(args) == 0 {
+		diags.AddAttributeError(path.Root("self_test"), "Invalid Self-Test",
+			"The self_test attribute must contain at least the command to run.")
+		return diags
+	}
+
+	test := func() error {
+		cmd := e.command(ctx, args)
+		_, err := cmd.Output()
+
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return fmt.Errorf("%w\nError Message: %s", err, exitErr.Stderr)
+		}
+
+		return err
+	}
+
+	var err error
+	if r.data != nil && r.data.selfTests != nil {
+		key, _ := json.Marshal(args)
+		err = r.data.selfTests.run(string(key), test)
+	} else {
+		err = test()
+	}
+
+	if err != nil {
+		diags.AddAttributeError(path.Root("self_test"), "Program Self-Test Failed",
+			"The self-test of the program failed, so the program was not run. Resources sharing this self-test "+
+				"will also fail until the problem is fixed and Terraform is run again."+
+				fmt.Sprintf("\n\nSelf-Test: %s", strings.Join(args, " "))+
+				fmt.Sprintf("\nError: %s", err))
+	}
+
+	return diags
+}
+
+// Read re-runs the program of ephemeral resources, refreshing the state with its latest output. Otherwise it does
+// not need to perform any operations as the state in ReadResourceResponse is already populated.
+func (r *programResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state execModelV0
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() || !state.Ephemeral.ValueBool() {
+		return
+	}
+
+	refreshed, diags := r.run(ctx, state, &state, phaseRead)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The id is kept for the lifetime of the resource.
+	refreshed.Id = state.Id
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &refreshed)...)
 }
 
-// Update ensures the plan value is copied to the state to complete the update.
+// Update re-runs the program when update_in_place is set or
+// update_result_behavior is "rerun", passing it the previous result, and runs
+// it afresh to recompute the results when update_result_behavior is
+// "unknown". When it is "preserve", the plan value is copied to the state to
+// complete the update, keeping the computed values of the prior state.
 func (r *programResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	var model execModelV0
+	var model, prior execModelV0
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &model)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	behavior := model.UpdateResultBehavior.ValueString()
+
+	if model.UpdateInPlace.ValueBool() || behavior != updateResultBehaviorPreserve {
+		// Results shown unknown are recomputed without the prior state, so
+		// the program is not passed the previous run's values.
+		priorRun := &prior
+		if !model.UpdateInPlace.ValueBool() && behavior != updateResultBehaviorRerun {
+			priorRun = nil
+		}
+
+		state, diags := r.run(ctx, model, priorRun, phaseUpdate)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		// The id is kept for the lifetime of the resource, as planned.
+		state.Id = prior.Id
+
+		resp.Diagnostics.Append(state.snapshotReplaceResult(ctx)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+		return
+	}
 
+	// The program is not re-run, so the prior results shown in the plan are
+	// written back.
+	model.preserveResults(prior)
+
+	resp.Diagnostics.Append(model.snapshotReplaceResult(ctx)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -244,15 +1739,234 @@ func (r *programResource) Update(ctx context.Context, req resource.UpdateRequest
 	resp.Diagnostics.Append(resp.State.Set(ctx, &model)...)
 }
 
-// Delete does not need to explicitly call resp.State.RemoveResource() as this is automatically handled by the
+// Delete runs the destroy_program when one is set. It does not need to explicitly call
+// resp.State.RemoveResource() as this is automatically handled by the
 // [framework](https://github.com/hashicorp/terraform-plugin-framework/pull/301).
-func (r *programResource) Delete(context.Context, resource.DeleteRequest, *resource.DeleteResponse) {
+func (r *programResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state execModelV0
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() || state.DestroyProgram.IsNull() {
+		return
+	}
+
+	resp.Diagnostics.Append(runDestroyProgram(ctx, state, r.data.audit())...)
 }
 
 type execModelV0 struct {
-	Id         types.String `tfsdk:"id"`
-	Program    types.List   `tfsdk:"program"`
-	WorkingDir types.String `tfsdk:"working_dir"`
-	Query      types.Map    `tfsdk:"query"`
-	Result     types.Map    `tfsdk:"result"`
+	Id                       types.String  `tfsdk:"id"`
+	IdTemplate               types.String  `tfsdk:"id_template"`
+	Program                  types.List    `tfsdk:"program"`
+	ProgramEnv               types.String  `tfsdk:"program_env"`
+	Script                   types.String  `tfsdk:"script"`
+	Interpreter              types.List    `tfsdk:"interpreter"`
+	DestroyProgram           types.List    `tfsdk:"destroy_program"`
+	OnCancel                 types.List    `tfsdk:"on_cancel"`
+	JsonArgs                 types.Map     `tfsdk:"json_args"`
+	ExpandEnvArgs            types.Bool    `tfsdk:"expand_env_args"`
+	Flags                    types.Map     `tfsdk:"flags"`
+	QueryAsArgs              types.String  `tfsdk:"query_as_args"`
+	FlagPrefix               types.String  `tfsdk:"flag_prefix"`
+	FlagSeparator            types.String  `tfsdk:"flag_separator"`
+	TemplateWorkingDir       types.Bool    `tfsdk:"template_working_dir"`
+	WorkingDir               types.String  `tfsdk:"working_dir"`
+	Pipe                     types.List    `tfsdk:"pipe"`
+	UseTempDir               types.Bool    `tfsdk:"use_temp_dir"`
+	IncludeRunMetadata       types.Bool    `tfsdk:"include_run_metadata"`
+	Environment              types.Map     `tfsdk:"environment"`
+	CleanEnvironment         types.Bool    `tfsdk:"clean_environment"`
+	PassthroughEnv           types.List    `tfsdk:"passthrough_env"`
+	Secrets                  types.Map     `tfsdk:"secrets"`
+	Locale                   types.String  `tfsdk:"locale"`
+	EnvironmentFiles         types.List    `tfsdk:"environment_files"`
+	ReplaceOnEnvChange       types.Bool    `tfsdk:"replace_on_env_change"`
+	EnvironmentHash          types.String  `tfsdk:"environment_hash"`
+	ExitCodeSeverity         types.Map     `tfsdk:"exit_code_severity"`
+	RootRelative             types.Bool    `tfsdk:"root_relative"`
+	Query                    types.Map     `tfsdk:"query"`
+	TransformProgram         types.List    `tfsdk:"transform_program"`
+	StrictQuery              types.Bool    `tfsdk:"strict_query"`
+	StdinEncoding            types.String  `tfsdk:"stdin_encoding"`
+	OutputEncoding           types.String  `tfsdk:"output_encoding"`
+	PrettyStdin              types.Bool    `tfsdk:"pretty_stdin"`
+	SortStdinKeys            types.Bool    `tfsdk:"sort_stdin_keys"`
+	QueryRecords             types.List    `tfsdk:"query_records"`
+	StdinTemplate            types.String  `tfsdk:"stdin_template"`
+	Seed                     types.String  `tfsdk:"seed"`
+	InvocationId             types.String  `tfsdk:"invocation_id"`
+	PassInvocationId         types.Bool    `tfsdk:"pass_invocation_id"`
+	RandomSeed               types.Bool    `tfsdk:"random_seed"`
+	QueryEnvFile             types.Bool    `tfsdk:"query_env_file"`
+	IncludePlatform          types.Bool    `tfsdk:"include_platform"`
+	LoginShell               types.Bool    `tfsdk:"login_shell"`
+	PathPrepend              types.List    `tfsdk:"path_prepend"`
+	IncludeWorkspace         types.Bool    `tfsdk:"include_workspace"`
+	ValidateProgramExists    types.Bool    `tfsdk:"validate_program_exists"`
+	Pty                      types.Bool    `tfsdk:"pty"`
+	StripAnsi                types.Bool    `tfsdk:"strip_ansi"`
+	ProgramSha256            types.String  `tfsdk:"program_sha256"`
+	RequireOwner             types.String  `tfsdk:"require_owner"`
+	NormalizeLineEndings     types.Bool    `tfsdk:"normalize_line_endings"`
+	MemoryLimit              types.Int64   `tfsdk:"memory_limit"`
+	CpuLimit                 types.Int64   `tfsdk:"cpu_limit"`
+	ChrootDir                types.String  `tfsdk:"chroot_dir"`
+	NetworkNamespace         types.String  `tfsdk:"network_namespace"`
+	InterruptSignal          types.String  `tfsdk:"interrupt_signal"`
+	OnlyIf                   types.List    `tfsdk:"only_if"`
+	Unless                   types.List    `tfsdk:"unless"`
+	GuardStdin               types.Bool    `tfsdk:"guard_stdin"`
+	SelfTest                 types.List    `tfsdk:"self_test"`
+	Timeout                  types.String  `tfsdk:"timeout"`
+	CreateTimeout            types.String  `tfsdk:"create_timeout"`
+	UpdateTimeout            types.String  `tfsdk:"update_timeout"`
+	DestroyTimeout           types.String  `tfsdk:"destroy_timeout"`
+	PassDeadline             types.Bool    `tfsdk:"pass_deadline"`
+	StreamLogs               types.Bool    `tfsdk:"stream_logs"`
+	LogStreams               types.List    `tfsdk:"log_streams"`
+	ResultStream             types.String  `tfsdk:"result_stream"`
+	HeartbeatTimeout         types.String  `tfsdk:"heartbeat_timeout"`
+	HeartbeatInterval        types.String  `tfsdk:"heartbeat_interval"`
+	MaxLineBytes             types.Int64   `tfsdk:"max_line_bytes"`
+	MaxTotalBytes            types.Int64   `tfsdk:"max_total_bytes"`
+	Ephemeral                types.Bool    `tfsdk:"ephemeral"`
+	ReplaceWhenResultChanges types.List    `tfsdk:"replace_when_result_changes"`
+	ReplaceResultSnapshot    types.Map     `tfsdk:"replace_result_snapshot"`
+	UpdateInPlace            types.Bool    `tfsdk:"update_in_place"`
+	UpdateResultBehavior     types.String  `tfsdk:"update_result_behavior"`
+	MergeResultKeys          types.List    `tfsdk:"merge_result_keys"`
+	PreviousResultKey        types.String  `tfsdk:"previous_result_key"`
+	PreviousExitCodeKey      types.String  `tfsdk:"previous_exit_code_key"`
+	StartupJitter            types.String  `tfsdk:"startup_jitter"`
+	LookupRetries            types.Int64   `tfsdk:"lookup_retries"`
+	LookupRetryInterval      types.String  `tfsdk:"lookup_retry_interval"`
+	Retries                  types.Int64   `tfsdk:"retries"`
+	RetryInterval            types.String  `tfsdk:"retry_interval"`
+	RetryBackoff             types.Float64 `tfsdk:"retry_backoff"`
+	Cache                    types.Bool    `tfsdk:"cache"`
+	DryRun                   types.Bool    `tfsdk:"dry_run"`
+	DryRunResult             types.Map     `tfsdk:"dry_run_result"`
+	OutputFormat             types.String  `tfsdk:"output_format"`
+	ResultFromExitCode       types.Bool    `tfsdk:"result_from_exit_code"`
+	DedupeResults            types.Bool    `tfsdk:"dedupe_results"`
+	RejectDuplicateKeys      types.Bool    `tfsdk:"reject_duplicate_keys"`
+	RequireCanonicalOutput   types.Bool    `tfsdk:"require_canonical_output"`
+	ErrorPattern             types.String  `tfsdk:"error_pattern"`
+	AutoDetectBinary         types.Bool    `tfsdk:"auto_detect_binary"`
+	SuccessRegexp            types.String  `tfsdk:"success_regexp"`
+	ParseLastJson            types.Bool    `tfsdk:"parse_last_json"`
+	StdoutLogFile            types.String  `tfsdk:"stdout_log_file"`
+	StderrLogFile            types.String  `tfsdk:"stderr_log_file"`
+	AllowEmptyOutput         types.Bool    `tfsdk:"allow_empty_output"`
+	NonObjectOutput          types.String  `tfsdk:"non_object_output"`
+	NonObjectKey             types.String  `tfsdk:"non_object_key"`
+	NumericResultKey         types.String  `tfsdk:"numeric_result_key"`
+	ErrorKey                 types.String  `tfsdk:"error_key"`
+	ErrorDetailKey           types.String  `tfsdk:"error_detail_key"`
+	OutputFiles              types.Map     `tfsdk:"output_files"`
+	ParseDiagnostics         types.Bool    `tfsdk:"parse_diagnostics"`
+	ProtocolVersion          types.String  `tfsdk:"protocol_version"`
+	RequireProtocolVersion   types.Bool    `tfsdk:"require_protocol_version"`
+	OutputGlob               types.String  `tfsdk:"output_glob"`
+	OutputGlobSha256         types.Bool    `tfsdk:"output_glob_sha256"`
+	EchoKey                  types.String  `tfsdk:"echo_key"`
+	ManifestFile             types.String  `tfsdk:"manifest_file"`
+	AllowedResultKeys        types.List    `tfsdk:"allowed_result_keys"`
+	ResultTypes              types.Map     `tfsdk:"result_types"`
+	Result                   types.Map     `tfsdk:"result"`
+	Results                  types.List    `tfsdk:"results"`
+	ResultJson               types.String  `tfsdk:"result_json"`
+	FlattenArrays            types.Bool    `tfsdk:"flatten_arrays"`
+	ResultTransforms         types.Map     `tfsdk:"result_transforms"`
+	NoChangeOutput           types.String  `tfsdk:"no_change_output"`
+	Changed                  types.Bool    `tfsdk:"changed"`
+	ResultFingerprint        types.String  `tfsdk:"result_fingerprint"`
+	ResultAttributes         types.Map     `tfsdk:"result_attributes"`
+	ResultSections           types.List    `tfsdk:"result_sections"`
+	Sections                 types.Map     `tfsdk:"sections"`
+	ResultObject             dynamicValue  `tfsdk:"result_object"`
+	ResultDynamic            dynamicValue  `tfsdk:"result_dynamic"`
+	GlobFiles                types.Map     `tfsdk:"glob_files"`
+	ExitCode                 types.Int64   `tfsdk:"exit_code"`
+	PartialOnTimeout         types.Bool    `tfsdk:"partial_on_timeout"`
+	TimedOut                 types.Bool    `tfsdk:"timed_out"`
+	OutputBytes              types.Int64   `tfsdk:"output_bytes"`
+	OutputSha256             types.String  `tfsdk:"output_sha256"`
+}
+
+// plannedEnvironmentHash returns the environmentHash of the planned
+// environment and environment_files, found relative to working_dir. It
+// reports false when a file cannot be read, which is reported by the run.
+func plannedEnvironmentHash(ctx context.Context, plan execModelV0) (string, bool) {
+	var environmentFiles []string
+	environment := make(map[string]string, len(plan.Environment.Elements()))
+
+	if plan.EnvironmentFiles.ElementsAs(ctx, &environmentFiles, false).HasError() ||
+		plan.Environment.ElementsAs(ctx, &environment, false).HasError() {
+		return "", false
+	}
+
+	var fileVars []string
+
+	for _, name := range environmentFiles {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(plan.WorkingDir.ValueString(), name)
+		}
+
+		vars, err := readDotenvFile(name)
+		if err != nil {
+			return "", false
+		}
+
+		fileVars = append(fileVars, vars...)
+	}
+
+	return environmentHash(fileVars, environment), true
+}
+
+// snapshotReplaceResult records the values of the replace_when_result_changes
+// keys of the result, which a later plan compares the refreshed result with.
+func (m *execModelV0) snapshotReplaceResult(ctx context.Context) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	m.ReplaceResultSnapshot = types.MapNull(types.StringType)
+
+	if m.ReplaceWhenResultChanges.IsNull() || m.Result.IsNull() || m.Result.IsUnknown() {
+		return diags
+	}
+
+	var keys []string
+	result := make(map[string]string, len(m.Result.Elements()))
+
+	diags.Append(m.ReplaceWhenResultChanges.ElementsAs(ctx, &keys, false)...)
+	diags.Append(m.Result.ElementsAs(ctx, &result, false)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	var d diag.Diagnostics
+	m.ReplaceResultSnapshot, d = types.MapValueFrom(ctx, types.StringType, resultSnapshot(result, keys))
+	diags.Append(d...)
+
+	return diags
+}
+
+// preserveResults copies the computed values of the prior state, which are
+// kept when an update does not re-run the program.
+func (m *execModelV0) preserveResults(prior execModelV0) {
+	m.Id = prior.Id
+	m.Result = prior.Result
+	m.Results = prior.Results
+	m.Sections = prior.Sections
+	m.ResultJson = prior.ResultJson
+	m.ResultObject = prior.ResultObject
+	m.ResultDynamic = prior.ResultDynamic
+	m.GlobFiles = prior.GlobFiles
+	m.ResultFingerprint = prior.ResultFingerprint
+	m.Changed = prior.Changed
+	m.OutputBytes = prior.OutputBytes
+	m.ExitCode = prior.ExitCode
+	m.TimedOut = prior.TimedOut
+	m.EnvironmentHash = prior.EnvironmentHash
+	m.OutputSha256 = prior.OutputSha256
+	m.InvocationId = prior.InvocationId
 }
@@ -1,14 +1,21 @@
 package provider
 
 import (
+	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -121,6 +128,226 @@ func TestDataSource_error(t *testing.T) {
 	})
 }
 
+func TestDataSource_OutputFormat_JSONArray(t *testing.T) {
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: protoV6ProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "exec_persisted" "test" {
+						program       = [%[1]q]
+						output_format = "json_array"
+
+						query = {
+							array = "true"
+							value = "pizza"
+						}
+					}
+				`, programPath),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("exec_persisted.test", "results.#", "2"),
+					resource.TestCheckResourceAttr("exec_persisted.test", "results.0.index", "0"),
+					resource.TestCheckResourceAttr("exec_persisted.test", "results.1.query_value", "pizza"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_Pipe(t *testing.T) {
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: protoV6ProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "exec_persisted" "test" {
+						program = [%[1]q, "first"]
+						pipe    = [[%[1]q, "second"]]
+
+						query = {
+							value = "pizza"
+						}
+					}
+				`, programPath),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("exec_persisted.test", "result.argument", "second"),
+					resource.TestCheckResourceAttr("exec_persisted.test", "result.result", "yes"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(`
+					resource "exec_persisted" "test" {
+						program = [%[1]q]
+						pipe    = [[%[1]q]]
+
+						query = {
+							fail = "true"
+						}
+					}
+				`, programPath),
+				ExpectError: regexp.MustCompile(`Stage: 1 of 2`),
+			},
+		},
+	})
+}
+
+func TestDataSource_UpdateInPlace(t *testing.T) {
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	config := `
+		resource "exec_persisted" "test" {
+			program         = [%[1]q]
+			update_in_place = true
+
+			query = {
+				value = %[2]q
+			}
+		}
+	`
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: protoV6ProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(config, programPath, "pizza"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("exec_persisted.test", "result.query_value", "pizza"),
+					resource.TestCheckNoResourceAttr("exec_persisted.test", "result.previous_query_value"),
+					resource.TestCheckNoResourceAttr("exec_persisted.test", "result.previous_exit_code"),
+					resource.TestCheckResourceAttr("exec_persisted.test", "exit_code", "0"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(config, programPath, "pasta"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("exec_persisted.test", "result.query_value", "pasta"),
+					resource.TestCheckResourceAttr("exec_persisted.test", "result.previous_query_value", "pizza"),
+					resource.TestCheckResourceAttr("exec_persisted.test", "result.previous_exit_code", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_ReplaceOnEnvChange(t *testing.T) {
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	envFile := filepath.Join(t.TempDir(), "test.env")
+
+	writeEnvFile := func(value string) func() {
+		return func() {
+			if err := os.WriteFile(envFile, []byte("TEST_ENV_VALUE="+value+"\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	config := fmt.Sprintf(`
+		resource "exec_persisted" "test" {
+			program               = [%[1]q]
+			environment_files     = [%[2]q]
+			replace_on_env_change = true
+
+			query = {
+				env = "TEST_ENV_VALUE"
+			}
+		}
+	`, programPath, envFile)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: protoV6ProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				PreConfig: writeEnvFile("one"),
+				Config:    config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("exec_persisted.test", "result.env_value", "one"),
+					resource.TestCheckResourceAttrSet("exec_persisted.test", "environment_hash"),
+				),
+			},
+			{
+				PreConfig: writeEnvFile("two"),
+				Config:    config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("exec_persisted.test", "result.env_value", "two"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_EphemeralRefresh(t *testing.T) {
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	envFile := filepath.Join(t.TempDir(), "test.env")
+
+	writeEnvFile := func(value string) func() {
+		return func() {
+			if err := os.WriteFile(envFile, []byte("TEST_ENV_VALUE="+value+"\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// The configuration does not change between the steps, so only the
+	// refresh re-runs the program.
+	config := fmt.Sprintf(`
+		resource "exec_persisted" "test" {
+			program           = [%[1]q]
+			environment_files = [%[2]q]
+			ephemeral         = true
+
+			query = {
+				env = "TEST_ENV_VALUE"
+			}
+		}
+	`, programPath, envFile)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: protoV6ProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				PreConfig: writeEnvFile("one"),
+				Config:    config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("exec_persisted.test", "result.env_value", "one"),
+				),
+			},
+			{
+				PreConfig: writeEnvFile("two"),
+				Config:    config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("exec_persisted.test", "result.env_value", "two"),
+				),
+			},
+		},
+	})
+}
+
 // Reference: https://github.com/hashicorp/terraform-provider-external/issues/110
 func TestDataSource_Program_OnlyEmptyString(t *testing.T) {
 	resource.UnitTest(t, resource.TestCase{
@@ -223,3 +450,400 @@ func TestDataSource_20MinuteTimeout(t *testing.T) {
 		},
 	})
 }
+
+// testModelValue returns the value of a resource with the given attributes
+// set, and all other attributes null. Attribute values are given as Go values,
+// or as tftypes values for values that are not yet known.
+func testModelValue(t *testing.T, attributes map[string]interface{}) tftypes.Value {
+	t.Helper()
+
+	ctx := context.Background()
+	schemaResp := &fwresource.SchemaResponse{}
+	(&programResource{}).Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	values := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+
+	for name, typ := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(typ, nil)
+	}
+
+	for name, value := range attributes {
+		typ, ok := objectType.AttributeTypes[name]
+		if !ok {
+			t.Fatalf("unknown attribute %q", name)
+		}
+
+		values[name] = testTerraformValue(t, typ, value)
+	}
+
+	return tftypes.NewValue(objectType, values)
+}
+
+func testTerraformValue(t *testing.T, typ tftypes.Type, value interface{}) tftypes.Value {
+	t.Helper()
+
+	switch value := value.(type) {
+	case tftypes.Value:
+		return value
+	case []string:
+		var elementType tftypes.Type
+		switch typ := typ.(type) {
+		case tftypes.List:
+			elementType = typ.ElementType
+		case tftypes.Set:
+			elementType = typ.ElementType
+		default:
+			t.Fatalf("unexpected type %s for %v", typ, value)
+		}
+
+		elements := make([]tftypes.Value, 0, len(value))
+		for _, element := range value {
+			elements = append(elements, tftypes.NewValue(elementType, element))
+		}
+
+		return tftypes.NewValue(typ, elements)
+	case map[string]string:
+		elements := make(map[string]tftypes.Value, len(value))
+		for key, element := range value {
+			elements[key] = tftypes.NewValue(tftypes.String, element)
+		}
+
+		return tftypes.NewValue(typ, elements)
+	default:
+		return tftypes.NewValue(typ, value)
+	}
+}
+
+// testModel returns the model of a resource with the given attributes set,
+// as described by testModelValue.
+func testModel(t *testing.T, attributes map[string]interface{}) execModelV0 {
+	t.Helper()
+
+	ctx := context.Background()
+	schemaResp := &fwresource.SchemaResponse{}
+	(&programResource{}).Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+
+	var model execModelV0
+
+	plan := tfsdk.Plan{Schema: schemaResp.Schema, Raw: testModelValue(t, attributes)}
+	if diags := plan.Get(ctx, &model); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	return model
+}
+
+// testRun runs the program of a resource with the given attributes set, as
+// described by testModelValue.
+func testRun(t *testing.T, attributes map[string]interface{}, prior *execModelV0, phase string) (execModelV0, diag.Diagnostics) {
+	t.Helper()
+
+	return (&programResource{}).run(context.Background(), testModel(t, attributes), prior, phase)
+}
+
+// testDiagnostic returns the first diagnostic with the summary, or nil.
+func testDiagnostic(diags diag.Diagnostics, summary string) diag.Diagnostic {
+	for _, d := range diags {
+		if d.Summary() == summary {
+			return d
+		}
+	}
+
+	return nil
+}
+
+// testPlanUpdate plans and applies an update of the resource from the prior
+// state to the planned model, whose computed values are unknown as the
+// framework plans them, returning the planned and the applied model.
+func testPlanUpdate(t *testing.T, prior, plan execModelV0) (planned, applied execModelV0) {
+	t.Helper()
+
+	ctx := context.Background()
+	modifyReq, modifyResp := testModifyPlan(t, prior, plan)
+
+	if diags := modifyResp.Plan.Get(ctx, &planned); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	updateResp := &fwresource.UpdateResponse{State: tfsdk.State{Schema: modifyReq.State.Schema, Raw: testModelValue(t, nil)}}
+
+	(&programResource{}).Update(ctx, fwresource.UpdateRequest{Config: modifyReq.Config, Plan: modifyResp.Plan, State: modifyReq.State}, updateResp)
+	if updateResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected apply diagnostics: %v", updateResp.Diagnostics)
+	}
+
+	if diags := updateResp.State.Get(ctx, &applied); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	return planned, applied
+}
+
+// testModifyPlan runs ModifyPlan for an update of the prior state to the
+// plan, which is also used as the configuration.
+func testModifyPlan(t *testing.T, prior, plan execModelV0) (fwresource.ModifyPlanRequest, *fwresource.ModifyPlanResponse) {
+	t.Helper()
+
+	ctx := context.Background()
+	schemaResp := &fwresource.SchemaResponse{}
+	(&programResource{}).Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+
+	state := tfsdk.State{Schema: schemaResp.Schema, Raw: testModelValue(t, nil)}
+	if diags := state.Set(ctx, &prior); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	tfPlan := tfsdk.Plan{Schema: schemaResp.Schema, Raw: testModelValue(t, nil)}
+	if diags := tfPlan.Set(ctx, &plan); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	modifyReq := fwresource.ModifyPlanRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: tfPlan.Raw},
+		Plan:   tfPlan,
+		State:  state,
+	}
+	modifyResp := &fwresource.ModifyPlanResponse{Plan: tfPlan}
+
+	(&programResource{}).ModifyPlan(ctx, modifyReq, modifyResp)
+	if modifyResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected plan diagnostics: %v", modifyResp.Diagnostics)
+	}
+
+	return modifyReq, modifyResp
+}
+
+// testUnknownResults sets the computed results of the model to unknown, as
+// the framework plans them for an update.
+func testUnknownResults(m execModelV0) execModelV0 {
+	m.Result = types.MapUnknown(types.StringType)
+	m.ResultJson = types.StringUnknown()
+	m.ResultDynamic = dynamicUnknown()
+	m.ResultFingerprint = types.StringUnknown()
+	m.OutputBytes = types.Int64Unknown()
+	m.OutputSha256 = types.StringUnknown()
+
+	return m
+}
+
+func TestResource_UpdateResultBehavior(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	// The script returns the number of times it was run in the working
+	// directory, and whether it was passed the previous result.
+	script := "n=$(cat count 2>/dev/null || echo 0)\nn=$((n+1))\necho $n > count\n" +
+		"case \"$(cat)\" in *previous_result*) p=true ;; *) p=false ;; esac\n" +
+		"printf '{\"run\":\"%s\",\"previous\":\"%s\"}' $n $p\n"
+
+	testCases := map[string]struct {
+		behavior        string
+		changeScript    bool
+		expectPreserved bool
+		expectRun       string
+		expectPrevious  string
+	}{
+		"unknown": {
+			behavior:       updateResultBehaviorUnknown,
+			expectRun:      "2",
+			expectPrevious: "false",
+		},
+		"default": {
+			expectRun:      "2",
+			expectPrevious: "false",
+		},
+		"preserve": {
+			behavior:        updateResultBehaviorPreserve,
+			expectPreserved: true,
+			expectRun:       "1",
+			expectPrevious:  "false",
+		},
+		"preserve-replaced": {
+			behavior:     updateResultBehaviorPreserve,
+			changeScript: true,
+		},
+		"rerun": {
+			behavior:       updateResultBehaviorRerun,
+			expectRun:      "2",
+			expectPrevious: "true",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			prior, diags := testRun(t, map[string]interface{}{
+				"script":                 script,
+				"working_dir":            t.TempDir(),
+				"update_result_behavior": testCase.behavior,
+			}, nil, phaseCreate)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			// stream_logs is updated in place, while script replaces the
+			// resource unless it is re-run.
+			plan := testUnknownResults(prior)
+			plan.StreamLogs = types.BoolValue(true)
+			if testCase.changeScript {
+				plan.Script = types.StringValue("# changed\n" + script)
+			}
+
+			if testCase.changeScript {
+				planned, _ := testPlanUpdate(t, prior, plan)
+
+				if !planned.Result.IsUnknown() {
+					t.Errorf("expected unknown result when replaced, got %s", planned.Result)
+				}
+				return
+			}
+
+			planned, applied := testPlanUpdate(t, prior, plan)
+
+			if testCase.expectPreserved {
+				if !planned.Result.Equal(prior.Result) || !planned.ResultDynamic.Equal(prior.ResultDynamic) {
+					t.Errorf("expected prior results in the plan, got %s", planned.Result)
+				}
+			} else if !planned.Result.IsUnknown() || !planned.ResultDynamic.IsUnknown() {
+				t.Errorf("expected unknown results in the plan, got %s", planned.Result)
+			}
+
+			if got := applied.Result.Elements()["run"]; got != types.StringValue(testCase.expectRun) {
+				t.Errorf("expected run %s after apply, got %s", testCase.expectRun, got)
+			}
+
+			if got := applied.Result.Elements()["previous"]; got != types.StringValue(testCase.expectPrevious) {
+				t.Errorf("expected previous result passed %s, got %s", testCase.expectPrevious, got)
+			}
+
+			if !applied.StreamLogs.ValueBool() {
+				t.Error("expected the updated stream_logs to be applied")
+			}
+		})
+	}
+}
+
+func TestResource_ReplaceWhenResultChanges(t *testing.T) {
+	testCases := map[string]struct {
+		result         map[string]string
+		expectReplaced bool
+	}{
+		"changed": {
+			result:         map[string]string{"version": "2", "other": "b"},
+			expectReplaced: true,
+		},
+		"unchanged": {
+			result: map[string]string{"version": "1", "other": "b"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			// The prior state holds the result of a refresh, while the
+			// snapshot was recorded when the program last ran during an apply.
+			prior := testModel(t, map[string]interface{}{
+				"program":                     []string{"program"},
+				"ephemeral":                   true,
+				"replace_when_result_changes": []string{"version"},
+				"result":                      testCase.result,
+				"replace_result_snapshot":     map[string]string{"version": "1"},
+			})
+
+			_, resp := testModifyPlan(t, prior, prior)
+
+			var planned execModelV0
+			if diags := resp.Plan.Get(context.Background(), &planned); diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			if !testCase.expectReplaced {
+				if len(resp.RequiresReplace) != 0 {
+					t.Errorf("expected no replacement, got %v", resp.RequiresReplace)
+				}
+				if !planned.Result.Equal(prior.Result) {
+					t.Errorf("expected prior result in the plan, got %s", planned.Result)
+				}
+				return
+			}
+
+			if len(resp.RequiresReplace) != 1 || resp.RequiresReplace[0].String() != "result" {
+				t.Errorf("expected result to require replacement, got %v", resp.RequiresReplace)
+			}
+			if !planned.Result.IsUnknown() {
+				t.Errorf("expected unknown result in the plan, got %s", planned.Result)
+			}
+		})
+	}
+}
+
+func TestResource_ValidateProgramExists(t *testing.T) {
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listType := tftypes.List{ElementType: tftypes.String}
+
+	testCases := map[string]struct {
+		program       interface{}
+		expectWarning bool
+	}{
+		"found": {
+			program: []string{programPath},
+		},
+		"missing": {
+			program:       []string{filepath.Join(t.TempDir(), "missing-program")},
+			expectWarning: true,
+		},
+		"unknown": {
+			program: tftypes.NewValue(listType, tftypes.UnknownValue),
+		},
+		"unknown-element": {
+			program: tftypes.NewValue(listType, []tftypes.Value{tftypes.NewValue(tftypes.String, tftypes.UnknownValue)}),
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			schemaResp := &fwresource.SchemaResponse{}
+			(&programResource{}).Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+
+			req := fwresource.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: testModelValue(t, map[string]interface{}{
+					"program":                 testCase.program,
+					"validate_program_exists": true,
+				})},
+			}
+			resp := &fwresource.ValidateConfigResponse{}
+
+			(&programResource{}).ValidateConfig(ctx, req, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			d := testDiagnostic(resp.Diagnostics, "External Program Not Found")
+			if !testCase.expectWarning {
+				if d != nil {
+					t.Errorf("unexpected warning: %v", d)
+				}
+				return
+			}
+
+			if d == nil {
+				t.Fatalf("expected warning, got: %v", resp.Diagnostics)
+			}
+
+			if got := d.(diag.DiagnosticWithPath).Path().String(); got != "program" {
+				t.Errorf("expected warning on program, got %s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// destroyInput returns the JSON object passed to the destroy_program on
+// stdin, holding the id and result of the resource being destroyed, and its
+// results when output_format is "json_array".
+func destroyInput(ctx context.Context, state execModelV0) ([]byte, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	result := map[string]string{}
+	if !state.Result.IsNull() && !state.Result.IsUnknown() {
+		diags.Append(state.Result.ElementsAs(ctx, &result, false)...)
+	}
+
+	input := map[string]interface{}{
+		"id":     state.Id.ValueString(),
+		"result": result,
+	}
+
+	if !state.Results.IsNull() && !state.Results.IsUnknown() {
+		var results []map[string]string
+		diags.Append(state.Results.ElementsAs(ctx, &results, false)...)
+		input["results"] = results
+	}
+
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	b, err := json.Marshal(input)
+	if err != nil {
+		diags.AddError("Destroy Program Input Handling Failed",
+			"The data source received an unexpected error while attempting to encode the destroy program input. "+
+				"This is always a bug in the external provider code and should be reported to the provider developers."+
+				fmt.Sprintf("\n\nError: %s", err))
+		return nil, diags
+	}
+
+	return b, diags
+}
+
+// runDestroyProgram runs the destroy_program of the resource being
+// destroyed in its working_dir, passing the prior state as described by
+// destroyInput on stdin, recording it in the audit log when one is given.
+func runDestroyProgram(ctx context.Context, state execModelV0, audit *auditLog) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var program []string
+	diags.Append(state.DestroyProgram.ElementsAs(ctx, &program, false)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	if len(program) == 0 {
+		diags.AddAttributeError(path.Root("destroy_program"), "Invalid Destroy Program",
+			"The destroy_program attribute must contain at least the program to run.")
+		return diags
+	}
+
+	stdin, d := destroyInput(ctx, state)
+	diags.Append(d...)
+	if diags.HasError() {
+		return diags
+	}
+
+	timeoutValue, timeoutAttribute := phaseTimeout(state, phaseDestroy)
+
+	if !timeoutValue.IsNull() {
+		timeout, err := time.ParseDuration(timeoutValue.ValueString())
+		if err != nil || timeout <= 0 {
+			diags.AddAttributeError(path.Root(timeoutAttribute), "Invalid Timeout",
+				fmt.Sprintf("The %s must be a positive duration string, such as \"30s\" or \"5m\".", timeoutAttribute)+
+					fmt.Sprintf("\n\nValue: %s", timeoutValue.ValueString()))
+			return diags
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, program[0], program[1:]...)
+	cmd.Dir = state.WorkingDir.ValueString()
+	cmd.Stdin = strings.NewReader(string(stdin))
+
+	start := time.Now()
+	_, err := cmd.Output()
+
+	if auditErr := audit.record(newAuditEntry(program, start, err)); auditErr != nil {
+		tflog.Warn(ctx, "Failed to record destroy program in audit log", map[string]interface{}{"error": auditErr.Error()})
+	}
+
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		diags.AddAttributeError(path.Root(timeoutAttribute), "Destroy Program Timed Out",
+			"The destroy program did not complete before the timeout and was stopped. The resource was not destroyed."+
+				fmt.Sprintf("\n\nProgram: %s", cmd.Path)+
+				fmt.Sprintf("\nTimeout: %s", timeoutValue.ValueString()))
+		return diags
+	}
+
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			err = fmt.Errorf("%w\nError Message: %s", err, exitErr.Stderr)
+		}
+
+		diags.AddAttributeError(path.Root("destroy_program"), "Destroy Program Failed",
+			"The data source received an unexpected error while attempting to run the destroy program. "+
+				"The resource was not destroyed."+
+				fmt.Sprintf("\n\nProgram: %s", cmd.Path)+
+				fmt.Sprintf("\nError: %s", err))
+	}
+
+	return diags
+}
@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestRunDestroyProgram(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+
+	state := execModelV0{
+		Id: types.StringValue("example-id"),
+		DestroyProgram: types.ListValueMust(types.StringType, []attr.Value{
+			types.StringValue("sh"),
+			types.StringValue("-c"),
+			types.StringValue("cat > input.json"),
+		}),
+		WorkingDir: types.StringValue(dir),
+		Result: types.MapValueMust(types.StringType, map[string]attr.Value{
+			"name":   types.StringValue("example"),
+			"region": types.StringValue("us-east-1"),
+		}),
+		Results: types.ListNull(types.MapType{ElemType: types.StringType}),
+	}
+
+	if diags := runDestroyProgram(context.Background(), state, nil); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "input.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var input struct {
+		Id      string            `json:"id"`
+		Result  map[string]string `json:"result"`
+		Results []interface{}     `json:"results"`
+	}
+	if err := json.Unmarshal(b, &input); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if input.Id != "example-id" {
+		t.Errorf("expected id %q, got %q", "example-id", input.Id)
+	}
+
+	if input.Result["name"] != "example" || input.Result["region"] != "us-east-1" || len(input.Result) != 2 {
+		t.Errorf("unexpected result: %v", input.Result)
+	}
+
+	if input.Results != nil {
+		t.Errorf("expected no results, got %v", input.Results)
+	}
+}
+
+func TestRunDestroyProgram_Error(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	state := execModelV0{
+		DestroyProgram: types.ListValueMust(types.StringType, []attr.Value{
+			types.StringValue("sh"),
+			types.StringValue("-c"),
+			types.StringValue("echo cleanup failed >&2; exit 1"),
+		}),
+		Result:  types.MapNull(types.StringType),
+		Results: types.ListNull(types.MapType{ElemType: types.StringType}),
+	}
+
+	if diags := runDestroyProgram(context.Background(), state, nil); !diags.HasError() {
+		t.Error("expected error, got none")
+	}
+}
+
+func TestRunDestroyProgram_Timeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	state := execModelV0{
+		DestroyProgram: types.ListValueMust(types.StringType, []attr.Value{
+			types.StringValue("sh"),
+			types.StringValue("-c"),
+			types.StringValue("exec sleep 10"),
+		}),
+		Timeout:        types.StringValue("1m"),
+		DestroyTimeout: types.StringValue("100ms"),
+		Result:         types.MapNull(types.StringType),
+		Results:        types.ListNull(types.MapType{ElemType: types.StringType}),
+	}
+
+	start := time.Now()
+
+	diags := runDestroyProgram(context.Background(), state, nil)
+	if !diags.HasError() {
+		t.Fatal("expected error, got none")
+	}
+
+	if summary := diags.Errors()[0].Summary(); summary != "Destroy Program Timed Out" {
+		t.Errorf("expected timeout error, got %q", summary)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the destroy program to be stopped, took %s", elapsed)
+	}
+}
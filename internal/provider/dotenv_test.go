@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	input := `
+# comment
+PLAIN=value
+export EXPORTED=yes
+EMPTY=
+SPACED = padded value   # trailing comment
+HASH=a#b
+SINGLE='literal \n # kept'
+DOUBLE="line\nbreak \"quoted\"" # comment
+`
+
+	env, err := parseDotenv(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{
+		"PLAIN=value",
+		"EXPORTED=yes",
+		"EMPTY=",
+		"SPACED=padded value",
+		"HASH=a#b",
+		`SINGLE=literal \n # kept`,
+		"DOUBLE=line\nbreak \"quoted\"",
+	}
+
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("expected %q, got %q", expected, env)
+	}
+}
+
+func TestParseDotenv_Errors(t *testing.T) {
+	testCases := map[string]string{
+		"no-equals":         "KEY",
+		"invalid-key":       "1KEY=value",
+		"unterminated":      `KEY="value`,
+		"unterminated-sq":   `KEY='value`,
+		"trailing-chars":    `KEY="value" extra`,
+		"trailing-chars-sq": `KEY='value' extra`,
+	}
+
+	for name, input := range testCases {
+		name, input := name, input
+
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseDotenv(strings.NewReader(input)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
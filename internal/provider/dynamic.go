@@ -0,0 +1,212 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// dynamicAttribute is a computed attribute whose type is only known once the
+// value is set, such as result_dynamic holding the parsed program output. The
+// framework has no dynamic attribute of its own, so a string attribute
+// supplies the description and flags while the type is replaced. Only the
+// methods of schema.Attribute are promoted, so the framework does not treat it
+// as a string attribute when validating or modifying the plan.
+type dynamicAttribute struct {
+	schema.Attribute
+}
+
+// GetType returns the dynamic type of the attribute.
+func (a dynamicAttribute) GetType() attr.Type {
+	return dynamicType{}
+}
+
+// ApplyTerraform5AttributePathStep returns the dynamic type for any step, as
+// the values within a dynamic value have no schema of their own.
+func (a dynamicAttribute) ApplyTerraform5AttributePathStep(step tftypes.AttributePathStep) (interface{}, error) {
+	return dynamicType{}, nil
+}
+
+// dynamicType is the type of a value whose Terraform type is only known once
+// it is set.
+type dynamicType struct{}
+
+var _ attr.Type = dynamicType{}
+
+// TerraformType returns the dynamic pseudo type.
+func (t dynamicType) TerraformType(ctx context.Context) tftypes.Type {
+	return tftypes.DynamicPseudoType
+}
+
+// ValueFromTerraform wraps the value, of any type.
+func (t dynamicType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	// The zero value is returned for attributes missing from the state.
+	if in.Type() == nil {
+		return dynamicNull(), nil
+	}
+
+	return dynamicValue{value: in}, nil
+}
+
+// ValueType returns the value type of the dynamic type.
+func (t dynamicType) ValueType(ctx context.Context) attr.Value {
+	return dynamicValue{}
+}
+
+// Equal returns true when o is also a dynamic type.
+func (t dynamicType) Equal(o attr.Type) bool {
+	_, ok := o.(dynamicType)
+	return ok
+}
+
+// String returns a human-readable representation of the type.
+func (t dynamicType) String() string {
+	return "dynamicType"
+}
+
+// ApplyTerraform5AttributePathStep returns the dynamic type for any step.
+func (t dynamicType) ApplyTerraform5AttributePathStep(step tftypes.AttributePathStep) (interface{}, error) {
+	return dynamicType{}, nil
+}
+
+// dynamicValue is a value of the dynamic type, holding a Terraform value of
+// any type.
+type dynamicValue struct {
+	value tftypes.Value
+}
+
+var _ attr.Value = dynamicValue{}
+
+// dynamicNull returns a null dynamic value.
+func dynamicNull() dynamicValue {
+	return dynamicValue{value: tftypes.NewValue(tftypes.DynamicPseudoType, nil)}
+}
+
+// dynamicUnknown returns an unknown dynamic value.
+func dynamicUnknown() dynamicValue {
+	return dynamicValue{value: tftypes.NewValue(tftypes.DynamicPseudoType, tftypes.UnknownValue)}
+}
+
+// Type returns the dynamic type.
+func (v dynamicValue) Type(ctx context.Context) attr.Type {
+	return dynamicType{}
+}
+
+// ToTerraformValue returns the wrapped value, or a null value for the zero
+// dynamic value.
+func (v dynamicValue) ToTerraformValue(ctx context.Context) (tftypes.Value, error) {
+	if v.value.Type() == nil {
+		return dynamicNull().value, nil
+	}
+
+	return v.value, nil
+}
+
+// Equal returns true when o is a dynamic value wrapping an equal value.
+func (v dynamicValue) Equal(o attr.Value) bool {
+	other, ok := o.(dynamicValue)
+	if !ok {
+		return false
+	}
+
+	a, _ := v.ToTerraformValue(context.Background())
+	b, _ := other.ToTerraformValue(context.Background())
+
+	return a.Equal(b)
+}
+
+// IsNull returns true when the value is null.
+func (v dynamicValue) IsNull() bool {
+	return v.value.Type() == nil || v.value.IsNull()
+}
+
+// IsUnknown returns true when the value is unknown.
+func (v dynamicValue) IsUnknown() bool {
+	return v.value.Type() != nil && !v.value.IsKnown()
+}
+
+// String returns a human-readable representation of the value.
+func (v dynamicValue) String() string {
+	if v.value.Type() == nil {
+		return "<null>"
+	}
+
+	return v.value.String()
+}
+
+// dynamicValueFromJSON converts JSON output into a dynamic value that keeps
+// its structure: objects become objects, arrays become tuples, as their
+// elements may differ in type, and numbers keep their precision.
+func dynamicValueFromJSON(output []byte) (dynamicValue, error) {
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	decoder.UseNumber()
+
+	var val interface{}
+	if err := decoder.Decode(&val); err != nil {
+		return dynamicNull(), err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return dynamicNull(), errors.New("invalid character after top-level value")
+	}
+
+	value, err := terraformValueFromJSON(val)
+	if err != nil {
+		return dynamicNull(), err
+	}
+
+	return dynamicValue{value: value}, nil
+}
+
+// terraformValueFromJSON recursively converts a value decoded from JSON, with
+// numbers decoded as json.Number, into a Terraform value.
+func terraformValueFromJSON(val interface{}) (tftypes.Value, error) {
+	switch val := val.(type) {
+	case nil:
+		return tftypes.NewValue(tftypes.DynamicPseudoType, nil), nil
+	case string:
+		return tftypes.NewValue(tftypes.String, val), nil
+	case bool:
+		return tftypes.NewValue(tftypes.Bool, val), nil
+	case json.Number:
+		number, _, err := big.ParseFloat(val.String(), 10, 512, big.ToNearestEven)
+		if err != nil {
+			return tftypes.Value{}, fmt.Errorf("invalid number %s: %w", val, err)
+		}
+		return tftypes.NewValue(tftypes.Number, number), nil
+	case []interface{}:
+		elemTypes := make([]tftypes.Type, 0, len(val))
+		elems := make([]tftypes.Value, 0, len(val))
+		for _, v := range val {
+			elem, err := terraformValueFromJSON(v)
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+			elemTypes = append(elemTypes, elem.Type())
+			elems = append(elems, elem)
+		}
+		return tftypes.NewValue(tftypes.Tuple{ElementTypes: elemTypes}, elems), nil
+	case map[string]interface{}:
+		attrTypes := make(map[string]tftypes.Type, len(val))
+		attrs := make(map[string]tftypes.Value, len(val))
+		for k, v := range val {
+			a, err := terraformValueFromJSON(v)
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+			attrTypes[k] = a.Type()
+			attrs[k] = a
+		}
+		return tftypes.NewValue(tftypes.Object{AttributeTypes: attrTypes}, attrs), nil
+	default:
+		return tftypes.Value{}, fmt.Errorf("unexpected JSON value type %T", val)
+	}
+}
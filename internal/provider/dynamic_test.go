@@ -0,0 +1,262 @@
+package provider
+
+import (
+	"context"
+	"math/big"
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestDynamicValueFromJSON(t *testing.T) {
+	testCases := map[string]struct {
+		output      string
+		expected    tftypes.Value
+		expectError bool
+	}{
+		"nested-object": {
+			output: `{"foo": {"bar": "baz"}}`,
+			expected: tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+				"foo": tftypes.Object{AttributeTypes: map[string]tftypes.Type{"bar": tftypes.String}},
+			}}, map[string]tftypes.Value{
+				"foo": tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{"bar": tftypes.String}}, map[string]tftypes.Value{
+					"bar": tftypes.NewValue(tftypes.String, "baz"),
+				}),
+			}),
+		},
+		"array": {
+			output: `[{"a": "1"}, {"b": "2"}]`,
+			expected: tftypes.NewValue(tftypes.Tuple{ElementTypes: []tftypes.Type{
+				tftypes.Object{AttributeTypes: map[string]tftypes.Type{"a": tftypes.String}},
+				tftypes.Object{AttributeTypes: map[string]tftypes.Type{"b": tftypes.String}},
+			}}, []tftypes.Value{
+				tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{"a": tftypes.String}}, map[string]tftypes.Value{
+					"a": tftypes.NewValue(tftypes.String, "1"),
+				}),
+				tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{"b": tftypes.String}}, map[string]tftypes.Value{
+					"b": tftypes.NewValue(tftypes.String, "2"),
+				}),
+			}),
+		},
+		"empty-array": {
+			output:   `[]`,
+			expected: tftypes.NewValue(tftypes.Tuple{ElementTypes: []tftypes.Type{}}, []tftypes.Value{}),
+		},
+		"mixed-types": {
+			output: `{"string": "s", "number": 12345678901234567890, "bool": true, "null": null, "list": [1.5, "two", false]}`,
+			expected: tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+				"string": tftypes.String,
+				"number": tftypes.Number,
+				"bool":   tftypes.Bool,
+				"null":   tftypes.DynamicPseudoType,
+				"list":   tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.Number, tftypes.String, tftypes.Bool}},
+			}}, map[string]tftypes.Value{
+				"string": tftypes.NewValue(tftypes.String, "s"),
+				"number": tftypes.NewValue(tftypes.Number, testBigFloat(t, "12345678901234567890")),
+				"bool":   tftypes.NewValue(tftypes.Bool, true),
+				"null":   tftypes.NewValue(tftypes.DynamicPseudoType, nil),
+				"list": tftypes.NewValue(tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.Number, tftypes.String, tftypes.Bool}}, []tftypes.Value{
+					tftypes.NewValue(tftypes.Number, testBigFloat(t, "1.5")),
+					tftypes.NewValue(tftypes.String, "two"),
+					tftypes.NewValue(tftypes.Bool, false),
+				}),
+			}),
+		},
+		"scalar": {
+			output:   `"value"`,
+			expected: tftypes.NewValue(tftypes.String, "value"),
+		},
+		"invalid": {
+			output:      `{"foo": `,
+			expectError: true,
+		},
+		"trailing-data": {
+			output:      `{} {}`,
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			got, err := dynamicValueFromJSON([]byte(testCase.output))
+
+			if testCase.expectError {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if !got.IsNull() {
+					t.Errorf("expected null value, got %s", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.value.Equal(testCase.expected) {
+				t.Errorf("expected %s, got %s", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func TestRun_ResultDynamic(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	ctx := context.Background()
+
+	state, diags := testRun(t, map[string]interface{}{
+		"script": "printf '{\"name\":\"web\",\"foo\":{\"bar\":\"baz\",\"port\":8080},\"tags\":[\"a\",true]}'\n",
+	}, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	// result holds the values in their string form.
+	expectedResult := map[string]string{
+		"name": "web",
+		"foo":  `{"bar":"baz","port":8080}`,
+		"tags": `["a",true]`,
+	}
+	for key, expected := range expectedResult {
+		if got := state.Result.Elements()[key]; got != types.StringValue(expected) {
+			t.Errorf("expected result %q to be %q, got %s", key, expected, got)
+		}
+	}
+
+	// The value is stored in the state and read back by its path, as
+	// result_dynamic.foo.bar is by a configuration.
+	schemaResp := &fwresource.SchemaResponse{}
+	(&programResource{}).Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+
+	tfState := tfsdk.State{Schema: schemaResp.Schema, Raw: testModelValue(t, nil)}
+	if diags := tfState.Set(ctx, &state); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	attributes := map[string]struct {
+		path     path.Path
+		expected tftypes.Value
+	}{
+		"foo.bar": {
+			path:     path.Root("result_dynamic").AtName("foo").AtName("bar"),
+			expected: tftypes.NewValue(tftypes.String, "baz"),
+		},
+		"foo.port": {
+			path:     path.Root("result_dynamic").AtName("foo").AtName("port"),
+			expected: tftypes.NewValue(tftypes.Number, big.NewFloat(8080)),
+		},
+		"tags[1]": {
+			path:     path.Root("result_dynamic").AtName("tags").AtListIndex(1),
+			expected: tftypes.NewValue(tftypes.Bool, true),
+		},
+	}
+
+	for name, attribute := range attributes {
+		var got dynamicValue
+		if diags := tfState.GetAttribute(ctx, attribute.path, &got); diags.HasError() {
+			t.Fatalf("unexpected diagnostics reading %s: %v", name, diags)
+		}
+
+		if !got.value.Equal(attribute.expected) {
+			t.Errorf("expected %s to be %s, got %s", name, attribute.expected, got)
+		}
+	}
+
+	var read execModelV0
+	if diags := tfState.Get(ctx, &read); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if !read.ResultDynamic.Equal(state.ResultDynamic) {
+		t.Errorf("expected result_dynamic %s, got %s", state.ResultDynamic, read.ResultDynamic)
+	}
+}
+
+func TestRun_ResultDynamicUnchanged(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	prior, diags := testRun(t, map[string]interface{}{
+		"script": "printf '{\"foo\":{\"bar\":\"baz\"}}'\n",
+	}, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	state, diags := testRun(t, map[string]interface{}{
+		"script":           "printf 'unchanged'\n",
+		"no_change_output": "unchanged",
+	}, &prior, phaseUpdate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if !state.ResultDynamic.Equal(prior.ResultDynamic) {
+		t.Errorf("expected prior result_dynamic %s, got %s", prior.ResultDynamic, state.ResultDynamic)
+	}
+}
+
+func testBigFloat(t *testing.T, s string) *big.Float {
+	t.Helper()
+
+	f, _, err := big.ParseFloat(s, 10, 512, big.ToNearestEven)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return f
+}
+
+func TestRun_ResultObject(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	ctx := context.Background()
+
+	state, diags := testRun(t, map[string]interface{}{
+		"script":            "printf '{\"name\":\"web\",\"count\":\"3\",\"enabled\":true}'\n",
+		"result_attributes": map[string]string{"name": "string", "count": "number", "enabled": "bool"},
+	}, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	schemaResp := &fwresource.SchemaResponse{}
+	(&programResource{}).Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+
+	tfState := tfsdk.State{Schema: schemaResp.Schema, Raw: testModelValue(t, nil)}
+	if diags := tfState.Set(ctx, &state); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	// Each declared key is an attribute of its declared type.
+	attributes := map[string]tftypes.Value{
+		"name":    tftypes.NewValue(tftypes.String, "web"),
+		"count":   tftypes.NewValue(tftypes.Number, big.NewFloat(3)),
+		"enabled": tftypes.NewValue(tftypes.Bool, true),
+	}
+
+	for name, expected := range attributes {
+		var got dynamicValue
+		if diags := tfState.GetAttribute(ctx, path.Root("result_object").AtName(name), &got); diags.HasError() {
+			t.Fatalf("unexpected diagnostics reading %s: %v", name, diags)
+		}
+
+		if !got.value.Equal(expected) {
+			t.Errorf("expected result_object.%s to be %s, got %s", name, expected, got)
+		}
+	}
+}
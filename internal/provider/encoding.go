@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	textEncodingUTF8    = "utf8"
+	textEncodingUTF16LE = "utf16le"
+	textEncodingLatin1  = "latin1"
+)
+
+// validTextEncoding reports whether name is a supported text encoding.
+func validTextEncoding(name string) bool {
+	switch name {
+	case textEncodingUTF8, textEncodingUTF16LE, textEncodingLatin1:
+		return true
+	}
+
+	return false
+}
+
+// encodeText transcodes UTF-8 text to the named encoding.
+func encodeText(b []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case textEncodingUTF16LE:
+		units := utf16.Encode(bytes.Runes(b))
+		out := make([]byte, 2*len(units))
+		for i, u := range units {
+			binary.LittleEndian.PutUint16(out[2*i:], u)
+		}
+
+		return out, nil
+	case textEncodingLatin1:
+		out := make([]byte, 0, len(b))
+		for i, r := range string(b) {
+			if r > 0xFF {
+				return nil, fmt.Errorf("character %q at byte offset %d cannot be represented in latin1", r, i)
+			}
+			out = append(out, byte(r))
+		}
+
+		return out, nil
+	}
+
+	return b, nil
+}
+
+// decodeText transcodes text in the named encoding to UTF-8. A leading byte
+// order mark is removed from UTF-16 text.
+func decodeText(b []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case textEncodingUTF16LE:
+		if len(b)%2 != 0 {
+			return nil, fmt.Errorf("utf16le text must have an even number of bytes, got %d", len(b))
+		}
+
+		units := make([]uint16, len(b)/2)
+		for i := range units {
+			units[i] = binary.LittleEndian.Uint16(b[2*i:])
+		}
+
+		if len(units) > 0 && units[0] == 0xFEFF {
+			units = units[1:]
+		}
+
+		out := make([]byte, 0, len(units))
+		for _, r := range utf16.Decode(units) {
+			out = utf8.AppendRune(out, r)
+		}
+
+		return out, nil
+	case textEncodingLatin1:
+		out := make([]byte, 0, len(b))
+		for _, c := range b {
+			out = utf8.AppendRune(out, rune(c))
+		}
+
+		return out, nil
+	}
+
+	return b, nil
+}
+
+// isBinary reports whether b looks like binary data rather than text: it
+// contains a NUL byte or is not valid UTF-8.
+func isBinary(b []byte) bool {
+	return bytes.IndexByte(b, 0) >= 0 || !utf8.Valid(b)
+}
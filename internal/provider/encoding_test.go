@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeText(t *testing.T) {
+	testCases := map[string]struct {
+		input       string
+		encoding    string
+		expected    []byte
+		expectError bool
+	}{
+		"utf8": {
+			input:    `{"k":"é"}`,
+			encoding: textEncodingUTF8,
+			expected: []byte(`{"k":"é"}`),
+		},
+		"utf16le": {
+			input:    "a€",
+			encoding: textEncodingUTF16LE,
+			expected: []byte{0x61, 0x00, 0xAC, 0x20},
+		},
+		"utf16le-surrogate": {
+			input:    "😀",
+			encoding: textEncodingUTF16LE,
+			expected: []byte{0x3D, 0xD8, 0x00, 0xDE},
+		},
+		"latin1": {
+			input:    "aé",
+			encoding: textEncodingLatin1,
+			expected: []byte{0x61, 0xE9},
+		},
+		"latin1-unrepresentable": {
+			input:       "€",
+			encoding:    textEncodingLatin1,
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			got, err := encodeText([]byte(testCase.input), testCase.encoding)
+
+			if testCase.expectError {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !bytes.Equal(got, testCase.expected) {
+				t.Errorf("expected %x, got %x", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	testCases := map[string]struct {
+		input       []byte
+		encoding    string
+		expected    string
+		expectError bool
+	}{
+		"utf16le": {
+			input:    []byte{0x61, 0x00, 0xAC, 0x20},
+			encoding: textEncodingUTF16LE,
+			expected: "a€",
+		},
+		"utf16le-bom": {
+			input:    []byte{0xFF, 0xFE, 0x7B, 0x00, 0x7D, 0x00},
+			encoding: textEncodingUTF16LE,
+			expected: "{}",
+		},
+		"utf16le-odd": {
+			input:       []byte{0x7B, 0x00, 0x7D},
+			encoding:    textEncodingUTF16LE,
+			expectError: true,
+		},
+		"latin1": {
+			input:    []byte{0x61, 0xE9},
+			encoding: textEncodingLatin1,
+			expected: "aé",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			got, err := decodeText(testCase.input, testCase.encoding)
+
+			if testCase.expectError {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if string(got) != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	testCases := map[string]struct {
+		input    []byte
+		expected bool
+	}{
+		"empty": {
+			input: []byte{},
+		},
+		"json": {
+			input: []byte(`{"name":"café"}`),
+		},
+		"nul": {
+			input:    []byte("{}\x00"),
+			expected: true,
+		},
+		"invalid-utf8": {
+			input:    []byte{0x89, 0x50, 0x4E, 0x47},
+			expected: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			if got := isBinary(testCase.input); got != testCase.expected {
+				t.Errorf("expected %t, got %t", testCase.expected, got)
+			}
+		})
+	}
+}
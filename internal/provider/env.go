@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// envKeyEqual reports whether two environment variable names are the same,
+// ignoring case on Windows where names are case insensitive.
+func envKeyEqual(a, b string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+
+	return a == b
+}
+
+// lookupEnv returns the value of the variable key in env.
+func lookupEnv(env []string, key string) (string, bool) {
+	for i := len(env) - 1; i >= 0; i-- {
+		k, v, _ := strings.Cut(env[i], "=")
+		if envKeyEqual(k, key) {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// expandEnv replaces $VAR and ${VAR} references in s with the values of the
+// variables in env. References to unset variables are replaced with the
+// empty string.
+func expandEnv(s string, env []string) string {
+	return os.Expand(s, func(key string) string {
+		value, _ := lookupEnv(env, key)
+		return value
+	})
+}
+
+// passthroughEnv returns the variables of env named in names, in the order
+// of env. The result is empty rather than nil when none are set, so that it
+// is not taken for the inherited environment.
+func passthroughEnv(env []string, names []string) []string {
+	result := []string{}
+
+	for _, kv := range env {
+		k, _, _ := strings.Cut(kv, "=")
+
+		for _, name := range names {
+			if envKeyEqual(k, name) {
+				result = append(result, kv)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// setEnv returns env with the variable key set to value, replacing any
+// existing entries for it.
+func setEnv(env []string, key, value string) []string {
+	result := make([]string, 0, len(env)+1)
+
+	for _, kv := range env {
+		k, _, _ := strings.Cut(kv, "=")
+		if envKeyEqual(k, key) {
+			continue
+		}
+		result = append(result, kv)
+	}
+
+	return append(result, key+"="+value)
+}
+
+// prependPath returns env with dirs, made absolute, added to the front of
+// its PATH.
+func prependPath(env []string, dirs []string) ([]string, error) {
+	elems := make([]string, 0, len(dirs)+1)
+
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, abs)
+	}
+
+	if current, ok := lookupEnv(env, "PATH"); ok && current != "" {
+		elems = append(elems, current)
+	}
+
+	return setEnv(env, "PATH", strings.Join(elems, string(os.PathListSeparator))), nil
+}
+
+// lookPathIn finds the executable named file in the given directories,
+// returning an empty string when it is not found there. Names containing a
+// path separator are not searched for, in the same way as exec.LookPath.
+func lookPathIn(file string, dirs []string) string {
+	if strings.ContainsRune(file, '/') || strings.ContainsRune(file, filepath.Separator) {
+		return ""
+	}
+
+	for _, dir := range dirs {
+		if path, err := exec.LookPath(filepath.Join(dir, file)); err == nil {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// environmentHash returns a hash of the variables set for the program by
+// environment_files, given as KEY=VALUE entries in the order they are read,
+// and the environment attribute, which takes precedence over them.
+func environmentHash(fileVars []string, environment map[string]string) string {
+	var env []string
+
+	for _, kv := range fileVars {
+		key, value, _ := strings.Cut(kv, "=")
+		env = setEnv(env, key, value)
+	}
+
+	for key, value := range environment {
+		env = setEnv(env, key, value)
+	}
+
+	sort.Strings(env)
+
+	sum := sha256.Sum256([]byte(strings.Join(env, "\x00")))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,202 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestSetEnv(t *testing.T) {
+	env := []string{"A=1", "B=2", "A=3"}
+
+	got := setEnv(env, "A", "4")
+	expected := []string{"B=2", "A=4"}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected environment: %v", got)
+	}
+
+	if value, ok := lookupEnv(got, "A"); !ok || value != "4" {
+		t.Errorf("unexpected value for A: %q", value)
+	}
+}
+
+func TestPassthroughEnv(t *testing.T) {
+	env := []string{"PATH=/usr/bin", "HOME=/home/user", "AWS_SECRET_ACCESS_KEY=secret", "TERM=xterm"}
+
+	testCases := map[string]struct {
+		names    []string
+		expected []string
+	}{
+		"none": {
+			expected: []string{},
+		},
+		"selected": {
+			names:    []string{"HOME", "PATH"},
+			expected: []string{"PATH=/usr/bin", "HOME=/home/user"},
+		},
+		"unset": {
+			names:    []string{"PATH", "GOPATH"},
+			expected: []string{"PATH=/usr/bin"},
+		},
+		"duplicate-names": {
+			names:    []string{"TERM", "TERM"},
+			expected: []string{"TERM=xterm"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual := passthroughEnv(env, testCase.names)
+
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPassthroughEnvOverride(t *testing.T) {
+	// The environment attribute is applied on top of the passed through
+	// variables, replacing them.
+	env := passthroughEnv([]string{"PATH=/usr/bin", "HOME=/home/user", "TOKEN=secret"}, []string{"PATH", "HOME"})
+	env = setEnv(env, "HOME", "/tmp")
+	env = setEnv(env, "LANG", "C")
+
+	expected := []string{"PATH=/usr/bin", "HOME=/tmp", "LANG=C"}
+
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("expected %v, got %v", expected, env)
+	}
+}
+
+func TestPrependPath(t *testing.T) {
+	dir := t.TempDir()
+
+	env, err := prependPath([]string{"PATH=/usr/bin"}, []string{dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	path, _ := lookupEnv(env, "PATH")
+	expected := dir + string(os.PathListSeparator) + "/usr/bin"
+
+	if path != expected {
+		t.Errorf("expected PATH %q, got %q", expected, path)
+	}
+}
+
+func TestLookPathIn(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test relies on the executable bit")
+	}
+
+	dir := t.TempDir()
+	program := filepath.Join(dir, "tool")
+
+	if err := os.WriteFile(program, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := lookPathIn("tool", []string{t.TempDir(), dir}); got != program {
+		t.Errorf("expected %q, got %q", program, got)
+	}
+
+	if got := lookPathIn("missing", []string{dir}); got != "" {
+		t.Errorf("expected no match, got %q", got)
+	}
+
+	if got := lookPathIn("./tool", []string{dir}); got != "" {
+		t.Errorf("expected paths to be ignored, got %q", got)
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	env := []string{"HOME=/home/user", "TOOL=tool", "HOME=/home/other"}
+
+	testCases := map[string]struct {
+		input    string
+		expected string
+	}{
+		"braces": {
+			input:    "${HOME}/bin/${TOOL}",
+			expected: "/home/other/bin/tool",
+		},
+		"bare": {
+			input:    "$TOOL-cli",
+			expected: "tool-cli",
+		},
+		"unset": {
+			input:    "${MISSING}value",
+			expected: "value",
+		},
+		"none": {
+			input:    "plain",
+			expected: "plain",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			if actual := expandEnv(testCase.input, env); actual != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestEnvironmentHash(t *testing.T) {
+	base := environmentHash([]string{"A=1", "B=2"}, map[string]string{"C": "3"})
+
+	testCases := map[string]struct {
+		fileVars    []string
+		environment map[string]string
+		expectSame  bool
+	}{
+		"same": {
+			fileVars:    []string{"A=1", "B=2"},
+			environment: map[string]string{"C": "3"},
+			expectSame:  true,
+		},
+		"reordered-files": {
+			fileVars:    []string{"B=2", "A=1"},
+			environment: map[string]string{"C": "3"},
+			expectSame:  true,
+		},
+		"overridden-file-value": {
+			fileVars:    []string{"A=1", "B=2", "C=0"},
+			environment: map[string]string{"C": "3"},
+			expectSame:  true,
+		},
+		"changed-file-value": {
+			fileVars:    []string{"A=1", "B=3"},
+			environment: map[string]string{"C": "3"},
+		},
+		"changed-environment": {
+			fileVars:    []string{"A=1", "B=2"},
+			environment: map[string]string{"C": "4"},
+		},
+		"added-variable": {
+			fileVars:    []string{"A=1", "B=2", "D=4"},
+			environment: map[string]string{"C": "3"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual := environmentHash(testCase.fileVars, testCase.environment)
+
+			if (actual == base) != testCase.expectSame {
+				t.Errorf("expected same hash %t, got %q and %q", testCase.expectSame, base, actual)
+			}
+		})
+	}
+}
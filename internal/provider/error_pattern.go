@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"regexp"
+	"strings"
+)
+
+// errorPatternGroupSummary and errorPatternGroupMessage are the named groups
+// of error_pattern that become the summary and detail of the diagnostic.
+const (
+	errorPatternGroupSummary = "summary"
+	errorPatternGroupMessage = "message"
+)
+
+// errorPatternMatch is the error of a failed program extracted from its
+// error output by error_pattern.
+type errorPatternMatch struct {
+	// summary is the text of the summary group, if any.
+	summary string
+
+	// message is the text of the message group, or the whole match when
+	// the pattern has no message group.
+	message string
+
+	// fields are the other non-empty named groups, as "Name: value" lines
+	// in the order of the groups in the pattern.
+	fields []string
+}
+
+// matchErrorPattern extracts the error of a failed program from stderr with
+// re. It reports false when re is nil or stderr does not match.
+func matchErrorPattern(re *regexp.Regexp, stderr []byte) (errorPatternMatch, bool) {
+	if re == nil {
+		return errorPatternMatch{}, false
+	}
+
+	match := re.FindSubmatch(stderr)
+	if match == nil {
+		return errorPatternMatch{}, false
+	}
+
+	result := errorPatternMatch{message: string(match[0])}
+
+	for index, name := range re.SubexpNames() {
+		value := strings.TrimSpace(string(match[index]))
+
+		switch name {
+		case "":
+		case errorPatternGroupSummary:
+			result.summary = value
+		case errorPatternGroupMessage:
+			result.message = value
+		default:
+			if value != "" {
+				result.fields = append(result.fields, name+": "+value)
+			}
+		}
+	}
+
+	result.message = strings.TrimSpace(result.message)
+
+	return result, true
+}
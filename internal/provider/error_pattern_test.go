@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestMatchErrorPattern(t *testing.T) {
+	testCases := map[string]struct {
+		pattern  string
+		stderr   string
+		expected errorPatternMatch
+		ok       bool
+	}{
+		"no-groups": {
+			pattern:  `(?m)^fatal: .*$`,
+			stderr:   "loading config\nfatal: bucket not found\nexiting\n",
+			expected: errorPatternMatch{message: "fatal: bucket not found"},
+			ok:       true,
+		},
+		"message-and-fields": {
+			pattern: `(?m)^(?P<file>\S+):(?P<line>\d+): error: (?P<message>.*)$(?P<hint>\n  hint: .*)?`,
+			stderr:  "warning: deprecated flag\nmain.tf:12: error: unknown variable \"region\"\n",
+			expected: errorPatternMatch{
+				message: "unknown variable \"region\"",
+				fields:  []string{"file: main.tf", "line: 12"},
+			},
+			ok: true,
+		},
+		"summary": {
+			pattern: `(?m)^(?P<summary>[A-Z][a-z]+Error): (?P<message>.*)$`,
+			stderr:  "Traceback (most recent call last):\n  File \"x.py\", line 1\nPermissionError: access denied\n",
+			expected: errorPatternMatch{
+				summary: "PermissionError",
+				message: "access denied",
+			},
+			ok: true,
+		},
+		"no-match": {
+			pattern: `(?m)^fatal: .*$`,
+			stderr:  "segmentation fault\n",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual, ok := matchErrorPattern(regexp.MustCompile(testCase.pattern), []byte(testCase.stderr))
+
+			if ok != testCase.ok {
+				t.Fatalf("expected ok %t, got %t", testCase.ok, ok)
+			}
+
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %#v, got %#v", testCase.expected, actual)
+			}
+		})
+	}
+}
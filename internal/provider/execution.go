@@ -0,0 +1,361 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// execution describes how a program is run, as resolved from the resource
+// configuration.
+type execution struct {
+	program []string
+	pipe    [][]string
+
+	// recordedProgram, when not nil, is recorded in the audit log and
+	// metrics in place of program, such as the program run by a login shell.
+	recordedProgram []string
+
+	dir string
+	// env is the environment of the program, which is inherited from
+	// Terraform while it is nil.
+	env   []string
+	stdin []byte
+
+	// queryEnvFile is written to a temporary file, whose path is passed in
+	// QUERY_ENV_FILE, for each run when it is not nil.
+	queryEnvFile []byte
+
+	// secrets is written to the program on file descriptor secretsFD, for
+	// each run when it is not nil.
+	secrets []byte
+
+	chrootDir        string
+	pty              bool
+	networkNamespace string
+	limits           resourceLimits
+	interruptSignal  os.Signal
+	retry            retryConfig
+
+	// maxTotalBytes limits the captured output when it is not negative.
+	maxTotalBytes int64
+
+	// audit records each run of the program when it is not nil.
+	audit *auditLog
+
+	// retries is set by run to the number of retries of its last call.
+	retries int64
+
+	// acceptExitCode reports whether a non-zero exit code, with the output
+	// of the program, is treated as success by the resource, in which case
+	// it is not retried.
+	acceptExitCode func(code int, output []byte) bool
+
+	// outputEncoding is the encoding of the program output, which is
+	// decoded to UTF-8.
+	outputEncoding string
+
+	// streamLogs logs each line of output as the program writes it, with
+	// lines limited to maxLineBytes when it is positive.
+	streamLogs   bool
+	maxLineBytes int64
+
+	// logStreams are the names of the streams logged with streamLogs, or nil
+	// to log both.
+	logStreams map[string]bool
+
+	// resultStream is the name of the stream the output is read from, which
+	// is standard output when empty.
+	resultStream string
+
+	// heartbeatTimeout, when positive, stops a program whose streamed output
+	// pauses for longer, checking every heartbeatInterval.
+	heartbeatTimeout  time.Duration
+	heartbeatInterval time.Duration
+
+	// stripANSI removes ANSI escape sequences from the captured output.
+	stripANSI bool
+
+	// normalizeLineEndings converts CRLF line endings in the captured
+	// output to LF.
+	normalizeLineEndings bool
+
+	// stdoutLog and stderrLog, if not nil, receive a copy of the output
+	// and error output of every attempt.
+	stdoutLog io.Writer
+	stderrLog io.Writer
+
+	// logCommand and logOutput control what is logged at TRACE level.
+	logCommand bool
+	logOutput  bool
+}
+
+// outputDecodeError reports that the program output could not be decoded
+// from its configured encoding.
+type outputDecodeError struct {
+	err error
+}
+
+func (e *outputDecodeError) Error() string {
+	return "decoding program output: " + e.err.Error()
+}
+
+// exitCode returns the exit code of the program, or of the failed stage of
+// a pipeline, when err reports that it exited unsuccessfully.
+func exitCode(err error) (int, bool) {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() < 0 {
+		return 0, false
+	}
+
+	return exitErr.ExitCode(), true
+}
+
+// recordedArgs returns the program arguments recorded in the audit log and
+// metrics.
+func (e *execution) recordedArgs() []string {
+	if e.recordedProgram != nil {
+		return e.recordedProgram
+	}
+
+	return e.program
+}
+
+// command returns a command running args with the execution settings applied.
+func (e *execution) command(ctx context.Context, args []string) *exec.Cmd {
+	if e.networkNamespace != "" {
+		args = networkNamespaceCommand(args, e.networkNamespace)
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = e.dir
+	cmd.Env = e.env
+
+	if e.chrootDir != "" {
+		setChroot(cmd, e.chrootDir)
+	}
+
+	if e.interruptSignal != nil {
+		cmd.Cancel = func() error {
+			return cmd.Process.Signal(e.interruptSignal)
+		}
+		cmd.WaitDelay = interruptGracePeriod
+	}
+
+	return cmd
+}
+
+// run executes the program, retrying failed executions according to the
+// retry configuration, and returns the output of the final attempt along with
+// the command that was run. If the output limit is exceeded the error is
+// errOutputLimitExceeded, if a streamed line is too long it is
+// errLineTooLong, and if streamed output pauses for longer than the heartbeat
+// timeout it is errProgramStalled. In these cases the program is not retried.
+func (e *execution) run(ctx context.Context) ([]byte, *exec.Cmd, error) {
+	if e.queryEnvFile != nil {
+		name, err := writeQueryEnvFile(e.queryEnvFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("writing query environment file: %w", err)
+		}
+		defer os.Remove(name)
+
+		env := e.env
+		if env == nil {
+			env = os.Environ()
+		}
+
+		withFile := *e
+		withFile.queryEnvFile = nil
+		withFile.env = setEnv(env, queryEnvFileVar, name)
+
+		output, cmd, err := withFile.run(ctx)
+		e.retries = withFile.retries
+
+		return output, cmd, err
+	}
+
+	var cmd *exec.Cmd
+	var output []byte
+	var err error
+
+	for attempt := int64(0); ; attempt++ {
+		e.retries = attempt
+
+		if attempt > 0 {
+			tflog.Debug(ctx, "Retrying external program", map[string]interface{}{"program": cmd.String(), "attempt": attempt, "error": err.Error()})
+
+			if waitErr := e.retry.wait(ctx, attempt); waitErr != nil {
+				return output, cmd, err
+			}
+		}
+
+		runCtx, cancel := context.WithCancel(ctx)
+
+		var limit *outputLimit
+		var wrap func(io.Writer, string) io.Writer
+		if e.maxTotalBytes >= 0 {
+			limit = newOutputLimit(e.maxTotalBytes, cancel)
+			wrap = func(w io.Writer, _ string) io.Writer {
+				return limit.wrap(w)
+			}
+		}
+
+		cmd = e.command(runCtx, e.program)
+		cmd.Stdin = bytes.NewReader(e.stdin)
+
+		var secrets *os.File
+		if e.secrets != nil {
+			secrets, err = secretsPipe(e.secrets)
+			if err != nil {
+				cancel()
+				return nil, cmd, fmt.Errorf("passing secrets: %w", err)
+			}
+			cmd.ExtraFiles = []*os.File{secrets}
+		}
+
+		var loggers []*lineLogger
+		if e.streamLogs {
+			limitWrap := wrap
+			wrap = func(w io.Writer, stream string) io.Writer {
+				if limitWrap != nil {
+					w = limitWrap(w, stream)
+				}
+
+				if e.logStreams != nil && !e.logStreams[stream] {
+					return w
+				}
+
+				logger := newLineLogger(ctx, cmd.String(), e.maxLineBytes, cancel)
+				loggers = append(loggers, logger)
+
+				return io.MultiWriter(w, logger)
+			}
+		}
+
+		var hb *heartbeat
+		if e.streamLogs && e.heartbeatTimeout > 0 {
+			hb = newHeartbeat(e.heartbeatTimeout, cancel)
+
+			streamWrap := wrap
+			wrap = func(w io.Writer, stream string) io.Writer {
+				if streamWrap != nil {
+					w = streamWrap(w, stream)
+				}
+
+				return hb.wrap(w)
+			}
+		}
+
+		if e.logCommand {
+			tflog.Trace(ctx, "Executing external program", map[string]interface{}{"program": cmd.String()})
+		}
+
+		cmds := []*exec.Cmd{cmd}
+		for _, stage := range e.pipe {
+			cmds = append(cmds, e.command(runCtx, stage))
+		}
+
+		opts := pipelineOptions{
+			wrap:         wrap,
+			resultStderr: e.resultStream == streamStderr,
+			tty:          e.pty,
+			stdoutLog:    e.stdoutLog,
+			stderrLog:    e.stderrLog,
+		}
+		if !e.limits.isZero() {
+			opts.started = func(cmd *exec.Cmd) error {
+				return applyResourceLimits(cmd, e.limits)
+			}
+		}
+
+		var stopHeartbeat func()
+		if hb != nil {
+			stopHeartbeat = hb.watch(e.heartbeatInterval)
+		}
+
+		start := time.Now()
+		output, err = runPipeline(cmds, opts)
+		cancel()
+
+		if auditErr := e.audit.record(newAuditEntry(e.recordedArgs(), start, err)); auditErr != nil {
+			tflog.Warn(ctx, "Failed to record external program in audit log", map[string]interface{}{"error": auditErr.Error()})
+		}
+
+		if secrets != nil {
+			secrets.Close()
+		}
+
+		if stopHeartbeat != nil {
+			stopHeartbeat()
+		}
+
+		lineTooLong := false
+		for _, logger := range loggers {
+			logger.Flush()
+			lineTooLong = lineTooLong || logger.Exceeded()
+		}
+
+		if e.outputEncoding != "" && e.outputEncoding != textEncodingUTF8 {
+			decoded, decodeErr := decodeText(output, e.outputEncoding)
+			if decodeErr != nil {
+				return output, cmd, &outputDecodeError{err: decodeErr}
+			}
+			output = decoded
+		}
+
+		if e.stripANSI {
+			output = stripANSI(output)
+
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				exitErr.Stderr = stripANSI(exitErr.Stderr)
+			}
+		}
+
+		if e.normalizeLineEndings {
+			output = bytes.ReplaceAll(output, []byte("\r\n"), []byte("\n"))
+
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				exitErr.Stderr = bytes.ReplaceAll(exitErr.Stderr, []byte("\r\n"), []byte("\n"))
+			}
+		}
+
+		switch {
+		case e.logCommand && e.logOutput:
+			tflog.Trace(ctx, "Executed external program", map[string]interface{}{"program": cmd.String(), "output": string(output)})
+		case e.logCommand:
+			tflog.Trace(ctx, "Executed external program", map[string]interface{}{"program": cmd.String()})
+		case e.logOutput:
+			tflog.Trace(ctx, "Executed external program", map[string]interface{}{"output": string(output)})
+		}
+
+		// A program exceeding the output limit would most likely do so again.
+		if limit != nil && limit.Exceeded() {
+			return output, cmd, errOutputLimitExceeded
+		}
+
+		if lineTooLong {
+			return output, cmd, errLineTooLong
+		}
+
+		if hb != nil && hb.Stalled() {
+			return output, cmd, errProgramStalled
+		}
+
+		if err == nil || attempt >= e.retry.retries {
+			return output, cmd, err
+		}
+
+		if code, ok := exitCode(err); ok && e.acceptExitCode != nil && e.acceptExitCode(code, output) {
+			return output, cmd, err
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	exitCodeSeverityError   = "error"
+	exitCodeSeverityWarning = "warning"
+	exitCodeSeverityIgnore  = "ignore"
+)
+
+// parseExitCodeSeverity converts the exit_code_severity attribute to a map
+// keyed by exit code.
+func parseExitCodeSeverity(ctx context.Context, m types.Map) (map[int]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	raw := make(map[string]string, len(m.Elements()))
+	diags.Append(m.ElementsAs(ctx, &raw, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	severities := make(map[int]string, len(raw))
+
+	for key, severity := range raw {
+		code, err := strconv.Atoi(key)
+		if err != nil || code == 0 {
+			diags.AddAttributeError(path.Root("exit_code_severity").AtMapKey(key), "Invalid Exit Code Severity",
+				fmt.Sprintf("The exit_code_severity keys must be non-zero integer exit codes, got: %q", key))
+			continue
+		}
+
+		switch severity {
+		case exitCodeSeverityError, exitCodeSeverityWarning, exitCodeSeverityIgnore:
+		default:
+			diags.AddAttributeError(path.Root("exit_code_severity").AtMapKey(key), "Invalid Exit Code Severity",
+				fmt.Sprintf("The exit_code_severity values must be one of %q, %q or %q, got: %q",
+					exitCodeSeverityError, exitCodeSeverityWarning, exitCodeSeverityIgnore, severity))
+			continue
+		}
+
+		severities[code] = severity
+	}
+
+	return severities, diags
+}
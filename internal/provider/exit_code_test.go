@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestParseExitCodeSeverity(t *testing.T) {
+	testCases := map[string]struct {
+		severity     map[string]string
+		expected     map[int]string
+		expectedPath string
+	}{
+		"valid": {
+			severity: map[string]string{"1": "error", "2": "warning", "3": "ignore"},
+			expected: map[int]string{1: exitCodeSeverityError, 2: exitCodeSeverityWarning, 3: exitCodeSeverityIgnore},
+		},
+		"empty": {
+			severity: map[string]string{},
+			expected: map[int]string{},
+		},
+		"non-numeric-key": {
+			severity:     map[string]string{"one": "warning"},
+			expectedPath: `exit_code_severity["one"]`,
+		},
+		"zero-key": {
+			severity:     map[string]string{"0": "warning"},
+			expectedPath: `exit_code_severity["0"]`,
+		},
+		"invalid-severity": {
+			severity:     map[string]string{"2": "fatal"},
+			expectedPath: `exit_code_severity["2"]`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			m, d := types.MapValueFrom(context.Background(), types.StringType, testCase.severity)
+			if d.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", d)
+			}
+
+			severities, diags := parseExitCodeSeverity(context.Background(), m)
+
+			if testCase.expectedPath != "" {
+				d := testDiagnostic(diags, "Invalid Exit Code Severity")
+				if d == nil {
+					t.Fatalf("expected diagnostic, got: %v", diags)
+				}
+				if got := d.(diag.DiagnosticWithPath).Path().String(); got != testCase.expectedPath {
+					t.Errorf("expected diagnostic on %s, got %s", testCase.expectedPath, got)
+				}
+				return
+			}
+
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			if !reflect.DeepEqual(severities, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, severities)
+			}
+		})
+	}
+}
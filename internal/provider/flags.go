@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"sort"
+)
+
+const (
+	defaultFlagPrefix = "--"
+
+	// flagSeparatorArgument passes the value of a flag as the argument
+	// following it, and flagSeparatorEquals in the same argument.
+	flagSeparatorArgument = " "
+	flagSeparatorEquals   = "="
+)
+
+// Values of query_as_args.
+const (
+	queryAsArgsNone       = "none"
+	queryAsArgsFlags      = "flags"
+	queryAsArgsPositional = "positional"
+)
+
+// flagPrefixes are the supported values of flag_prefix.
+var flagPrefixes = []string{defaultFlagPrefix, "-", "/"}
+
+// renderFlags renders the flags as command line arguments in the order of
+// their names, each name following the prefix. With flagSeparatorArgument the
+// value of each flag is the argument following it, and with
+// flagSeparatorEquals it is joined to the flag as in --name=value. A flag
+// with an empty value is rendered alone, as a boolean flag.
+func renderFlags(flags map[string]string, prefix, separator string) []string {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := make([]string, 0, 2*len(flags))
+
+	for _, name := range names {
+		value := flags[name]
+
+		switch {
+		case value == "":
+			args = append(args, prefix+name)
+		case separator == flagSeparatorEquals:
+			args = append(args, prefix+name+flagSeparatorEquals+value)
+		default:
+			args = append(args, prefix+name, value)
+		}
+	}
+
+	return args
+}
+
+// renderPositionalArgs renders the values as key=value command line
+// arguments in the order of their keys.
+func renderPositionalArgs(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, 0, len(values))
+
+	for _, key := range keys {
+		args = append(args, key+"="+values[key])
+	}
+
+	return args
+}
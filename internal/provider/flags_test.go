@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenderFlags(t *testing.T) {
+	flags := map[string]string{
+		"region":  "us-east-1",
+		"verbose": "",
+		"name":    "two words",
+	}
+
+	testCases := map[string]struct {
+		prefix    string
+		separator string
+		expected  []string
+	}{
+		"double-dash-argument": {
+			prefix:    "--",
+			separator: flagSeparatorArgument,
+			expected:  []string{"--name", "two words", "--region", "us-east-1", "--verbose"},
+		},
+		"double-dash-equals": {
+			prefix:    "--",
+			separator: flagSeparatorEquals,
+			expected:  []string{"--name=two words", "--region=us-east-1", "--verbose"},
+		},
+		"single-dash-argument": {
+			prefix:    "-",
+			separator: flagSeparatorArgument,
+			expected:  []string{"-name", "two words", "-region", "us-east-1", "-verbose"},
+		},
+		"single-dash-equals": {
+			prefix:    "-",
+			separator: flagSeparatorEquals,
+			expected:  []string{"-name=two words", "-region=us-east-1", "-verbose"},
+		},
+		"slash-argument": {
+			prefix:    "/",
+			separator: flagSeparatorArgument,
+			expected:  []string{"/name", "two words", "/region", "us-east-1", "/verbose"},
+		},
+		"slash-equals": {
+			prefix:    "/",
+			separator: flagSeparatorEquals,
+			expected:  []string{"/name=two words", "/region=us-east-1", "/verbose"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			if got := renderFlags(flags, testCase.prefix, testCase.separator); !reflect.DeepEqual(got, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func TestRenderPositionalArgs(t *testing.T) {
+	values := map[string]string{
+		"region": "us-east-1",
+		"name":   "two words",
+		"empty":  "",
+	}
+
+	expected := []string{"empty=", "name=two words", "region=us-east-1"}
+
+	if got := renderPositionalArgs(values); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// runGuard runs a guard command with the execution settings of the program
+// and the given stdin, which is empty when nil, and reports whether it exited
+// successfully. Exiting with a non-zero code is not an error.
+func runGuard(ctx context.Context, e *execution, args []string, stdin []byte) (bool, error) {
+	cmd := e.command(ctx, args)
+	cmd.Stdin = bytes.NewReader(stdin)
+
+	_, err := cmd.Output()
+	if err == nil {
+		return true, nil
+	}
+
+	if _, ok := exitCode(err); ok {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// runGuards runs the only_if and unless guards, in that order, and reports
+// whether the program should be skipped: when only_if exits unsuccessfully,
+// or unless exits successfully.
+func runGuards(ctx context.Context, onlyIf, unless types.List, e *execution, stdin []byte) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	for _, guard := range []struct {
+		attribute string
+		args      types.List
+		// skipOn is the success of the guard that skips the program.
+		skipOn bool
+	}{
+		{"only_if", onlyIf, false},
+		{"unless", unless, true},
+	} {
+		if guard.args.IsNull() {
+			continue
+		}
+
+		var args []string
+		diags.Append(guard.args.ElementsAs(ctx, &args, false)...)
+		if diags.HasError() {
+			return false, diags
+		}
+
+		if len(args) == 0 {
+			diags.AddAttributeError(path.Root(guard.attribute), "Invalid Guard",
+				fmt.Sprintf("The %s attribute must contain at least the command to run.", guard.attribute))
+			return false, diags
+		}
+
+		success, err := runGuard(ctx, e, args, stdin)
+		if err != nil {
+			diags.AddAttributeError(path.Root(guard.attribute), "Guard Execution Failed",
+				"The data source received an unexpected error while attempting to execute the guard command."+
+					fmt.Sprintf("\n\nGuard: %s", strings.Join(args, " "))+
+					fmt.Sprintf("\nError: %s", err))
+			return false, diags
+		}
+
+		if success == guard.skipOn {
+			return true, diags
+		}
+	}
+
+	return false, diags
+}
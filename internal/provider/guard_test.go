@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"context"
+	"runtime"
+	"testing"
+)
+
+func TestRunGuard(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	// The guard succeeds only when it receives the query on stdin.
+	args := []string{"sh", "-c", `test "$(cat)" = '{"name":"example"}'`}
+
+	testCases := map[string]struct {
+		stdin    []byte
+		expected bool
+	}{
+		"query": {
+			stdin:    []byte(`{"name":"example"}`),
+			expected: true,
+		},
+		"empty": {
+			stdin:    nil,
+			expected: false,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			e := &execution{stdin: []byte("main program stdin")}
+
+			actual, err := runGuard(context.Background(), e, args, testCase.stdin)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if actual != testCase.expected {
+				t.Errorf("expected %t, got %t", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRunGuard_NotFound(t *testing.T) {
+	_, err := runGuard(context.Background(), &execution{}, []string{"tf-acc-external-guard-not-found"}, nil)
+	if err == nil {
+		t.Error("expected error, got none")
+	}
+}
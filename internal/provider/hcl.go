@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// hclToJSON converts program output in the HCL native syntax to a JSON
+// object of its top-level attributes. Attribute values must be constant
+// expressions, as no variables or functions are available. Blocks are not
+// supported, since they have no single value to store.
+func hclToJSON(output []byte) ([]byte, error) {
+	file, diags := hclsyntax.ParseConfig(output, "output", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("unexpected HCL body type %T", file.Body)
+	}
+
+	if len(body.Blocks) > 0 {
+		block := body.Blocks[0]
+		return nil, fmt.Errorf("%s: blocks are not supported, only top-level attributes, got block %q", block.DefRange(), block.Type)
+	}
+
+	result := make(map[string]json.RawMessage, len(body.Attributes))
+
+	for name, attr := range body.Attributes {
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		b, err := ctyjson.Marshal(val, val.Type())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", attr.SrcRange, err)
+		}
+
+		result[name] = b
+	}
+
+	return json.Marshal(result)
+}
@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestHCLToJSON(t *testing.T) {
+	testCases := map[string]struct {
+		output      string
+		expected    string
+		expectError bool
+	}{
+		"attributes": {
+			output:   "name = \"example\"\ncount = 3\nenabled = true\n",
+			expected: `{"count":3,"enabled":true,"name":"example"}`,
+		},
+		"nested": {
+			output:   "tags = { env = \"prod\" }\nports = [80, 443]\n",
+			expected: `{"ports":[80,443],"tags":{"env":"prod"}}`,
+		},
+		"empty": {
+			output:   "",
+			expected: `{}`,
+		},
+		"block": {
+			output:      "resource \"a\" {\n}\n",
+			expectError: true,
+		},
+		"variable": {
+			output:      "name = var.name\n",
+			expectError: true,
+		},
+		"invalid": {
+			output:      "name = \n",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			got, err := hclToJSON([]byte(testCase.output))
+
+			if testCase.expectError {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if string(got) != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, got)
+			}
+		})
+	}
+}
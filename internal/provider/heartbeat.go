@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// errProgramStalled is returned when a program writes no output for longer
+// than its heartbeat timeout.
+var errProgramStalled = errors.New("program stalled")
+
+// heartbeat tracks when output was last written through the writers it
+// wraps, cancelling the program when none arrives within the timeout.
+type heartbeat struct {
+	mu      sync.Mutex
+	last    time.Time
+	stalled bool
+	cancel  context.CancelFunc
+	timeout time.Duration
+}
+
+func newHeartbeat(timeout time.Duration, cancel context.CancelFunc) *heartbeat {
+	return &heartbeat{
+		last:    time.Now(),
+		cancel:  cancel,
+		timeout: timeout,
+	}
+}
+
+// wrap returns a writer that writes to w while recording the time of each
+// write.
+func (h *heartbeat) wrap(w io.Writer) io.Writer {
+	return &heartbeatWriter{heartbeat: h, w: w}
+}
+
+// watch checks every interval whether output arrived within the timeout
+// until the returned function is called.
+func (h *heartbeat) watch(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				if h.check(now) {
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// check cancels the program when no output arrived within the timeout
+// before now, reporting whether it did.
+func (h *heartbeat) check(now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if now.Sub(h.last) <= h.timeout {
+		return false
+	}
+
+	h.stalled = true
+	h.cancel()
+
+	return true
+}
+
+// Stalled reports whether the program was cancelled for writing no output
+// within the timeout.
+func (h *heartbeat) Stalled() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.stalled
+}
+
+type heartbeatWriter struct {
+	heartbeat *heartbeat
+	w         io.Writer
+}
+
+func (hw *heartbeatWriter) Write(p []byte) (int, error) {
+	hw.heartbeat.mu.Lock()
+	hw.heartbeat.last = time.Now()
+	hw.heartbeat.mu.Unlock()
+
+	return hw.w.Write(p)
+}
@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := newHeartbeat(time.Minute, cancel)
+	w := h.wrap(io.Discard)
+
+	start := time.Now()
+
+	if h.check(start.Add(30 * time.Second)) {
+		t.Fatal("expected no stall within the timeout")
+	}
+
+	if _, err := w.Write([]byte("progress\n")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The write resets the timeout.
+	if h.check(time.Now().Add(59 * time.Second)) {
+		t.Fatal("expected no stall within the timeout of the last write")
+	}
+
+	if !h.check(time.Now().Add(2 * time.Minute)) {
+		t.Fatal("expected stall after the timeout")
+	}
+
+	if !h.Stalled() {
+		t.Error("expected heartbeat to report the stall")
+	}
+
+	if ctx.Err() == nil {
+		t.Error("expected the program to be cancelled")
+	}
+}
+
+func TestHeartbeat_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := newHeartbeat(10*time.Millisecond, cancel)
+	stop := h.watch(5 * time.Millisecond)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the watcher to cancel the program")
+	}
+
+	stop()
+
+	if !h.Stalled() {
+		t.Error("expected heartbeat to report the stall")
+	}
+}
+
+func TestExecutionRun_Stalled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	e := &execution{
+		program:           []string{"sh", "-c", "echo started; exec sleep 10"},
+		maxTotalBytes:     -1,
+		streamLogs:        true,
+		heartbeatTimeout:  100 * time.Millisecond,
+		heartbeatInterval: 20 * time.Millisecond,
+	}
+
+	start := time.Now()
+
+	_, _, err := e.run(context.Background())
+	if err != errProgramStalled {
+		t.Fatalf("expected errProgramStalled, got: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the stalled program to be stopped early, took %s", elapsed)
+	}
+}
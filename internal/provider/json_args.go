@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// renderJSONArgs replaces each {{name}} placeholder in the program arguments
+// with the JSON encoded array of the json_args entry of that name.
+// Placeholders without an entry are left unchanged.
+func renderJSONArgs(program []string, jsonArgs map[string][]string) ([]string, error) {
+	names := make([]string, 0, len(jsonArgs))
+	for name := range jsonArgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	replacements := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		values := jsonArgs[name]
+		if values == nil {
+			values = []string{}
+		}
+
+		encoded, err := json.Marshal(values)
+		if err != nil {
+			return nil, err
+		}
+
+		replacements = append(replacements, "{{"+name+"}}", string(encoded))
+	}
+
+	replacer := strings.NewReplacer(replacements...)
+
+	rendered := make([]string, len(program))
+	for idx, arg := range program {
+		rendered[idx] = replacer.Replace(arg)
+	}
+
+	return rendered, nil
+}
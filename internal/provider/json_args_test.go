@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenderJSONArgs(t *testing.T) {
+	testCases := map[string]struct {
+		program  []string
+		jsonArgs map[string][]string
+		expected []string
+	}{
+		"whole-argument": {
+			program:  []string{"tool", "--items", "{{items}}"},
+			jsonArgs: map[string][]string{"items": {"a", "b"}},
+			expected: []string{"tool", "--items", `["a","b"]`},
+		},
+		"embedded": {
+			program:  []string{"tool", "--items={{items}}"},
+			jsonArgs: map[string][]string{"items": {"a"}},
+			expected: []string{"tool", `--items=["a"]`},
+		},
+		"nested-quotes": {
+			program:  []string{"tool", "{{messages}}"},
+			jsonArgs: map[string][]string{"messages": {`say "hi"`, `back\slash`}},
+			expected: []string{"tool", `["say \"hi\"","back\\slash"]`},
+		},
+		"empty-list": {
+			program:  []string{"tool", "{{items}}"},
+			jsonArgs: map[string][]string{"items": nil},
+			expected: []string{"tool", "[]"},
+		},
+		"unknown-placeholder": {
+			program:  []string{"tool", "{{other}}"},
+			jsonArgs: map[string][]string{"items": {"a"}},
+			expected: []string{"tool", "{{other}}"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual, err := renderJSONArgs(testCase.program, testCase.jsonArgs)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// errOutputLimitExceeded is returned by the writers of an outputLimit once
+// the limit has been exceeded.
+var errOutputLimitExceeded = errors.New("output limit exceeded")
+
+// outputLimit bounds the combined number of bytes written through the
+// writers it wraps, cancelling the program once the bound is exceeded.
+type outputLimit struct {
+	mu        sync.Mutex
+	remaining int64
+	exceeded  bool
+	cancel    context.CancelFunc
+}
+
+func newOutputLimit(limit int64, cancel context.CancelFunc) *outputLimit {
+	return &outputLimit{
+		remaining: limit,
+		cancel:    cancel,
+	}
+}
+
+// wrap returns a writer that writes to w while counting against the limit.
+func (l *outputLimit) wrap(w io.Writer) io.Writer {
+	return &limitWriter{limit: l, w: w}
+}
+
+// Exceeded reports whether more output was written than the limit allows.
+func (l *outputLimit) Exceeded() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.exceeded
+}
+
+type limitWriter struct {
+	limit *outputLimit
+	w     io.Writer
+}
+
+func (lw *limitWriter) Write(p []byte) (int, error) {
+	l := lw.limit
+
+	l.mu.Lock()
+	if l.exceeded {
+		l.mu.Unlock()
+		return 0, errOutputLimitExceeded
+	}
+
+	if int64(len(p)) <= l.remaining {
+		l.remaining -= int64(len(p))
+		l.mu.Unlock()
+		return lw.w.Write(p)
+	}
+
+	n := l.remaining
+	l.remaining = 0
+	l.exceeded = true
+	l.mu.Unlock()
+
+	l.cancel()
+
+	written, err := lw.w.Write(p[:n])
+	if err != nil {
+		return written, err
+	}
+
+	return written, errOutputLimitExceeded
+}
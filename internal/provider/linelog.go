@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// errLineTooLong is returned by a lineLogger once a line longer than its
+// maximum has been written.
+var errLineTooLong = errors.New("output line too long")
+
+// lineLogger is a writer that logs each complete line written to it at INFO
+// level as it is written.
+type lineLogger struct {
+	ctx     context.Context
+	program string
+	partial []byte
+
+	// maxLineBytes limits the length of lines when it is positive. Once a
+	// longer line is written, the program is cancelled.
+	maxLineBytes int64
+	cancel       context.CancelFunc
+	exceeded     bool
+}
+
+func newLineLogger(ctx context.Context, program string, maxLineBytes int64, cancel context.CancelFunc) *lineLogger {
+	return &lineLogger{
+		ctx:          ctx,
+		program:      program,
+		maxLineBytes: maxLineBytes,
+		cancel:       cancel,
+	}
+}
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, errLineTooLong
+	}
+
+	l.partial = append(l.partial, p...)
+
+	for {
+		idx := bytes.IndexByte(l.partial, '\n')
+		if idx < 0 {
+			break
+		}
+
+		if l.tooLong(idx) {
+			return 0, l.exceed()
+		}
+
+		l.log(l.partial[:idx])
+		l.partial = l.partial[idx+1:]
+	}
+
+	if l.tooLong(len(l.partial)) {
+		return 0, l.exceed()
+	}
+
+	return len(p), nil
+}
+
+// Exceeded reports whether a line longer than the maximum was written.
+func (l *lineLogger) Exceeded() bool {
+	return l.exceeded
+}
+
+// Flush logs any final line that was not terminated by a newline.
+func (l *lineLogger) Flush() {
+	if len(l.partial) > 0 && !l.exceeded {
+		l.log(l.partial)
+	}
+
+	l.partial = nil
+}
+
+func (l *lineLogger) tooLong(n int) bool {
+	return l.maxLineBytes > 0 && int64(n) > l.maxLineBytes
+}
+
+func (l *lineLogger) exceed() error {
+	l.exceeded = true
+	l.partial = nil
+	l.cancel()
+
+	return errLineTooLong
+}
+
+func (l *lineLogger) log(line []byte) {
+	tflog.Info(l.ctx, string(bytes.TrimSuffix(line, []byte("\r"))), map[string]interface{}{"program": l.program})
+}
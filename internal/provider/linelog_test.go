@@ -0,0 +1,43 @@
+package provider
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLineLogger_MaxLineBytes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := newLineLogger(ctx, "example", 8, cancel)
+
+	if _, err := logger.Write([]byte("short\nlines\n")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := logger.Write([]byte("a line without")); err != errLineTooLong {
+		t.Fatalf("expected errLineTooLong, got: %v", err)
+	}
+
+	if !logger.Exceeded() {
+		t.Error("expected limit to be exceeded")
+	}
+
+	if ctx.Err() == nil {
+		t.Error("expected program to be cancelled")
+	}
+}
+
+func TestLineLogger_Unlimited(t *testing.T) {
+	logger := newLineLogger(context.Background(), "example", 0, func() {})
+
+	if _, err := logger.Write(make([]byte, 1<<20)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	logger.Flush()
+
+	if logger.Exceeded() {
+		t.Error("expected no limit")
+	}
+}
@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// programManifest declares the protocol of a program, as read from its
+// manifest_file.
+type programManifest struct {
+	// QueryKeys are the query keys the program accepts.
+	QueryKeys []string `json:"query_keys"`
+
+	// RequiredQueryKeys are the query keys the program requires. They are
+	// accepted even when not also listed in QueryKeys.
+	RequiredQueryKeys []string `json:"required_query_keys"`
+
+	// ResultKeys are the keys the program may return in its result.
+	ResultKeys []string `json:"result_keys"`
+}
+
+// readManifest reads and parses the manifest file with the given name.
+// Unknown fields are rejected to catch misspelled declarations.
+func readManifest(name string) (*programManifest, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+
+	var manifest programManifest
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+
+	return &manifest, nil
+}
+
+// checkManifest warns about configured query keys the manifest does not
+// accept, required query keys that are not configured, and result_types keys
+// the program does not return.
+func checkManifest(manifest *programManifest, queryKeys, resultTypeKeys []string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	accepted := make(map[string]bool, len(manifest.QueryKeys)+len(manifest.RequiredQueryKeys))
+	for _, key := range manifest.QueryKeys {
+		accepted[key] = true
+	}
+	for _, key := range manifest.RequiredQueryKeys {
+		accepted[key] = true
+	}
+
+	configured := make(map[string]bool, len(queryKeys))
+
+	for _, key := range sortedCopy(queryKeys) {
+		configured[key] = true
+
+		if !accepted[key] {
+			diags.AddAttributeWarning(path.Root("query").AtMapKey(key), "Query Key Not In Manifest",
+				"The program manifest does not declare this query key, so the program may ignore or reject it."+
+					fmt.Sprintf("\n\nKey: %s", key))
+		}
+	}
+
+	for _, key := range sortedCopy(manifest.RequiredQueryKeys) {
+		if !configured[key] {
+			diags.AddAttributeWarning(path.Root("query"), "Required Query Key Missing",
+				"The program manifest declares this query key as required, but the query does not contain it."+
+					fmt.Sprintf("\n\nKey: %s", key))
+		}
+	}
+
+	returned := make(map[string]bool, len(manifest.ResultKeys))
+	for _, key := range manifest.ResultKeys {
+		returned[key] = true
+	}
+
+	for _, key := range sortedCopy(resultTypeKeys) {
+		if !returned[key] {
+			diags.AddAttributeWarning(path.Root("result_types").AtMapKey(key), "Result Key Not In Manifest",
+				"The program manifest does not declare this result key, so the program is not expected to return it."+
+					fmt.Sprintf("\n\nKey: %s", key))
+		}
+	}
+
+	return diags
+}
+
+func sortedCopy(values []string) []string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+
+	return sorted
+}
@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.json")
+	if err := os.WriteFile(valid, []byte(`{"query_keys": ["a"], "required_query_keys": ["b"], "result_keys": ["c"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := readManifest(valid)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(manifest.QueryKeys) != 1 || len(manifest.RequiredQueryKeys) != 1 || len(manifest.ResultKeys) != 1 {
+		t.Errorf("unexpected manifest: %+v", manifest)
+	}
+
+	misspelled := filepath.Join(dir, "misspelled.json")
+	if err := os.WriteFile(misspelled, []byte(`{"querykeys": ["a"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := readManifest(misspelled); err == nil {
+		t.Error("expected error for unknown field, got none")
+	}
+}
+
+func TestCheckManifest(t *testing.T) {
+	manifest := &programManifest{
+		QueryKeys:         []string{"optional"},
+		RequiredQueryKeys: []string{"required"},
+		ResultKeys:        []string{"out"},
+	}
+
+	testCases := map[string]struct {
+		queryKeys      []string
+		resultTypeKeys []string
+		expectWarnings int
+	}{
+		"matching": {
+			queryKeys:      []string{"optional", "required"},
+			resultTypeKeys: []string{"out"},
+		},
+		"undeclared-query-key": {
+			queryKeys:      []string{"required", "extra"},
+			expectWarnings: 1,
+		},
+		"missing-required-key": {
+			queryKeys:      []string{"optional"},
+			expectWarnings: 1,
+		},
+		"undeclared-result-key": {
+			queryKeys:      []string{"required"},
+			resultTypeKeys: []string{"out", "other"},
+			expectWarnings: 1,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			diags := checkManifest(manifest, testCase.queryKeys, testCase.resultTypeKeys)
+
+			if diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+
+			if diags.WarningsCount() != testCase.expectWarnings {
+				t.Errorf("expected %d warnings, got %d: %v", testCase.expectWarnings, diags.WarningsCount(), diags)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"sync"
+	"time"
+)
+
+// metricsSink appends a metrics record of each program execution to a file
+// shared by all resources of the provider.
+type metricsSink struct {
+	mu   sync.Mutex
+	name string
+}
+
+// metricsRecord is a line of the metrics file.
+type metricsRecord struct {
+	Timestamp   string   `json:"timestamp"`
+	Phase       string   `json:"phase"`
+	Program     string   `json:"program"`
+	Args        []string `json:"args"`
+	ExitCode    *int     `json:"exit_code"`
+	DurationMs  int64    `json:"duration_ms"`
+	OutputBytes int      `json:"output_bytes"`
+	Retries     int64    `json:"retries"`
+}
+
+// newMetricsRecord returns the record of an execution of args during phase
+// that started at start, ended with err after retries retries, and returned
+// output. Sensitive arguments are redacted as in the audit log, and the exit
+// code is omitted when the program did not exit normally.
+func newMetricsRecord(phase string, args []string, start time.Time, err error, retries int64, output []byte) metricsRecord {
+	record := metricsRecord{
+		Timestamp:   start.UTC().Format(time.RFC3339Nano),
+		Phase:       phase,
+		Program:     args[0],
+		Args:        redactArgs(args[1:]),
+		DurationMs:  time.Since(start).Milliseconds(),
+		OutputBytes: len(output),
+		Retries:     retries,
+	}
+
+	if err == nil {
+		code := 0
+		record.ExitCode = &code
+	} else if code, ok := exitCode(err); ok {
+		record.ExitCode = &code
+	}
+
+	return record
+}
+
+// record appends the record to the metrics file. Records from concurrent
+// executions are written whole, one per line. A nil sink records nothing.
+func (s *metricsSink) record(record metricsRecord) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return appendJSONLine(s.name, record)
+}
@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsSink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses a POSIX shell")
+	}
+
+	name := filepath.Join(t.TempDir(), "metrics.log")
+	sink := &metricsSink{name: name}
+
+	exitErr := exec.Command("/bin/sh", "-c", "exit 3").Run()
+
+	records := []metricsRecord{
+		newMetricsRecord(phaseCreate, []string{"tool", "--token", "abc"}, time.Now(), nil, 0, []byte(`{"a":"b"}`)),
+		newMetricsRecord(phaseUpdate, []string{"tool"}, time.Now(), exitErr, 2, nil),
+	}
+
+	for _, record := range records {
+		if err := sink.record(record); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+
+	var first, second metricsRecord
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if first.Phase != phaseCreate || first.OutputBytes != 9 || first.Retries != 0 ||
+		!reflect.DeepEqual(first.Args, []string{"--token", redacted}) {
+		t.Errorf("unexpected record: %+v", first)
+	}
+
+	if first.ExitCode == nil || *first.ExitCode != 0 {
+		t.Errorf("expected exit code 0, got %v", first.ExitCode)
+	}
+
+	if second.Phase != phaseUpdate || second.OutputBytes != 0 || second.Retries != 2 {
+		t.Errorf("unexpected record: %+v", second)
+	}
+
+	if second.ExitCode == nil || *second.ExitCode != 3 {
+		t.Errorf("expected exit code 3, got %v", second.ExitCode)
+	}
+}
+
+func TestMetricsSinkNil(t *testing.T) {
+	var sink *metricsSink
+
+	if err := sink.record(newMetricsRecord(phaseCreate, []string{"tool"}, time.Now(), nil, 0, nil)); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// netnsDir is where named network namespaces created by `ip netns add` are
+// mounted.
+const netnsDir = "/var/run/netns"
+
+// networkNamespacePath returns the path of the network namespace, which is
+// either a path such as /proc/1234/ns/net or the name of a namespace in
+// netnsDir.
+func networkNamespacePath(namespace string) string {
+	if strings.ContainsRune(namespace, '/') {
+		return namespace
+	}
+
+	return filepath.Join(netnsDir, namespace)
+}
+
+// networkNamespaceCommand returns args wrapped to run inside the network
+// namespace by nsenter(1), which must be on the PATH of Terraform.
+func networkNamespaceCommand(args []string, namespace string) []string {
+	wrapped := make([]string, 0, len(args)+3)
+	wrapped = append(wrapped, "nsenter", "--net="+networkNamespacePath(namespace), "--")
+
+	return append(wrapped, args...)
+}
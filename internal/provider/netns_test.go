@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNetworkNamespaceCommand(t *testing.T) {
+	testCases := map[string]struct {
+		namespace string
+		expected  []string
+	}{
+		"name": {
+			namespace: "egress",
+			expected:  []string{"nsenter", "--net=/var/run/netns/egress", "--", "tool", "--flag"},
+		},
+		"path": {
+			namespace: "/proc/1234/ns/net",
+			expected:  []string{"nsenter", "--net=/proc/1234/ns/net", "--", "tool", "--flag"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual := networkNamespaceCommand([]string{"tool", "--flag"}, testCase.namespace)
+
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// readOutputFiles reads the files written by the program, returning their
+// contents keyed by result key. Relative paths are resolved against dir.
+func readOutputFiles(dir string, outputFiles map[string]string) (map[string]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	keys := make([]string, 0, len(outputFiles))
+	for key := range outputFiles {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	contents := make(map[string]string, len(outputFiles))
+
+	for _, key := range keys {
+		name := outputFiles[key]
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+
+		b, err := os.ReadFile(name)
+		if err != nil {
+			diags.AddAttributeError(path.Root("output_files").AtMapKey(key), "Output File Read Failed",
+				"The data source received an unexpected error while attempting to read a file the program was expected to write."+
+					fmt.Sprintf("\n\nKey: %s", key)+
+					fmt.Sprintf("\nFile: %s", name)+
+					fmt.Sprintf("\nError: %s", err))
+			continue
+		}
+
+		if !utf8.Valid(b) {
+			diags.AddAttributeError(path.Root("output_files").AtMapKey(key), "Output File Not Text",
+				"The file written by the program is not valid UTF-8 text, so it cannot be stored in the result."+
+					fmt.Sprintf("\n\nKey: %s", key)+
+					fmt.Sprintf("\nFile: %s", name))
+			continue
+		}
+
+		contents[key] = string(b)
+	}
+
+	return contents, diags
+}
+
+// readOutputGlob reads the regular files matching the pattern, which is
+// resolved against dir when relative, returning their contents, or their
+// SHA-256 checksums when hashes is set, keyed by their path relative to dir
+// in slash form. No matches result in an empty map.
+func readOutputGlob(dir, pattern string, hashes bool) (map[string]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		diags.AddAttributeError(path.Root("output_glob"), "Invalid Output Glob",
+			"The data source received an unexpected error while attempting to match the output_glob pattern."+
+				fmt.Sprintf("\n\nPattern: %s", pattern)+
+				fmt.Sprintf("\nError: %s", err))
+		return nil, diags
+	}
+
+	// Glob returns matches in lexical order.
+	contents := make(map[string]string, len(matches))
+
+	for _, name := range matches {
+		info, err := os.Stat(name)
+		if err == nil && !info.Mode().IsRegular() {
+			continue
+		}
+
+		var b []byte
+		if err == nil {
+			b, err = os.ReadFile(name)
+		}
+
+		if err != nil {
+			diags.AddAttributeError(path.Root("output_glob"), "Output File Read Failed",
+				"The data source received an unexpected error while attempting to read a file matching the output_glob."+
+					fmt.Sprintf("\n\nFile: %s", name)+
+					fmt.Sprintf("\nError: %s", err))
+			continue
+		}
+
+		key := name
+		if rel, err := filepath.Rel(dir, name); err == nil {
+			key = rel
+		}
+		key = filepath.ToSlash(key)
+
+		if hashes {
+			sum := sha256.Sum256(b)
+			contents[key] = hex.EncodeToString(sum[:])
+			continue
+		}
+
+		if !utf8.Valid(b) {
+			diags.AddAttributeError(path.Root("output_glob"), "Output File Not Text",
+				"A file matching the output_glob is not valid UTF-8 text, so it cannot be stored in glob_files. "+
+					"Set output_glob_sha256 to store checksums instead."+
+					fmt.Sprintf("\n\nFile: %s", name))
+			continue
+		}
+
+		contents[key] = string(b)
+	}
+
+	return contents, diags
+}
@@ -0,0 +1,171 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+func TestReadOutputFiles(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "work")
+
+	for name, content := range map[string]string{
+		"work/out.txt":    "alpha",
+		"work/binary.bin": "\xff\xfe",
+		"outside.txt":     "beta",
+	} {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := map[string]struct {
+		outputFiles  map[string]string
+		expected     map[string]string
+		expectError  string
+		expectedPath string
+	}{
+		"present": {
+			outputFiles: map[string]string{"out": "out.txt"},
+			expected:    map[string]string{"out": "alpha"},
+		},
+		"missing": {
+			outputFiles:  map[string]string{"out": "out.txt", "missing": "missing.txt"},
+			expectError:  "Output File Read Failed",
+			expectedPath: `output_files["missing"]`,
+		},
+		"not-text": {
+			outputFiles:  map[string]string{"binary": "binary.bin"},
+			expectError:  "Output File Not Text",
+			expectedPath: `output_files["binary"]`,
+		},
+		// Paths outside the working directory are read as given, relative
+		// paths being resolved against it.
+		"outside-relative": {
+			outputFiles: map[string]string{"outside": filepath.Join("..", "outside.txt")},
+			expected:    map[string]string{"outside": "beta"},
+		},
+		"outside-absolute": {
+			outputFiles: map[string]string{"outside": filepath.Join(root, "outside.txt")},
+			expected:    map[string]string{"outside": "beta"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			contents, diags := readOutputFiles(dir, testCase.outputFiles)
+
+			if testCase.expectError != "" {
+				d := testDiagnostic(diags, testCase.expectError)
+				if d == nil {
+					t.Fatalf("expected %q diagnostic, got: %v", testCase.expectError, diags)
+				}
+				if got := d.(diag.DiagnosticWithPath).Path().String(); got != testCase.expectedPath {
+					t.Errorf("expected diagnostic on %s, got %s", testCase.expectedPath, got)
+				}
+				return
+			}
+
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			if !reflect.DeepEqual(contents, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, contents)
+			}
+		})
+	}
+}
+
+func TestReadOutputGlob(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"out/a.txt":  "alpha",
+		"out/b.txt":  "beta",
+		"out/c.bin":  "\xff\xfe",
+		"out/d.txt/": "",
+	} {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if content == "" {
+			if err := os.MkdirAll(name, 0o700); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := map[string]struct {
+		pattern     string
+		hashes      bool
+		expected    map[string]string
+		expectError bool
+	}{
+		"contents": {
+			pattern: "out/*.txt",
+			expected: map[string]string{
+				"out/a.txt": "alpha",
+				"out/b.txt": "beta",
+			},
+		},
+		"hashes": {
+			pattern: "out/*.bin",
+			hashes:  true,
+			expected: map[string]string{
+				"out/c.bin": "b3d510ef04275ca8e698e5b3cbb0ece3949ef9252f0cdc839e9ee347409a2209",
+			},
+		},
+		"no-matches": {
+			pattern:  "missing/*",
+			expected: map[string]string{},
+		},
+		"binary-contents": {
+			pattern:     "out/*.bin",
+			expectError: true,
+		},
+		"invalid-pattern": {
+			pattern:     "out/[",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual, diags := readOutputGlob(dir, testCase.pattern, testCase.hashes)
+
+			if testCase.expectError {
+				if !diags.HasError() {
+					t.Fatal("expected error, got none")
+				}
+				return
+			}
+
+			if diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
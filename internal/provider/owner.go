@@ -0,0 +1,32 @@
+package provider
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+)
+
+// checkFileOwner verifies that the file with the given name is owned by
+// owner, which is either a user name or a numeric user ID.
+func checkFileOwner(name, owner string) error {
+	uid := owner
+
+	if _, err := strconv.ParseUint(owner, 10, 32); err != nil {
+		u, err := user.Lookup(owner)
+		if err != nil {
+			return err
+		}
+		uid = u.Uid
+	}
+
+	fileUID, err := fileOwnerUID(name)
+	if err != nil {
+		return err
+	}
+
+	if fileUID != uid {
+		return fmt.Errorf("file is owned by user ID %s, expected %s (%s)", fileUID, uid, owner)
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+//go:build !windows
+// +build !windows
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// fileOwnerUID returns the user ID owning the file with the given name,
+// following symbolic links.
+func fileOwnerUID(name string) (string, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return "", err
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", fmt.Errorf("file ownership is not available for %s", name)
+	}
+
+	return strconv.FormatUint(uint64(stat.Uid), 10), nil
+}
@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestCheckFileOwner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file ownership checks are not supported on Windows")
+	}
+
+	name := filepath.Join(t.TempDir(), "program")
+	if err := os.WriteFile(name, nil, 0o700); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	uid := strconv.Itoa(os.Getuid())
+
+	if err := checkFileOwner(name, uid); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if err := checkFileOwner(name, strconv.Itoa(os.Getuid()+1)); err == nil {
+		t.Error("expected error")
+	}
+}
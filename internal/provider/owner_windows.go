@@ -0,0 +1,11 @@
+package provider
+
+import (
+	"errors"
+)
+
+// fileOwnerUID is not supported on Windows, where files are owned by
+// security identifiers rather than user IDs.
+func fileOwnerUID(_ string) (string, error) {
+	return "", errors.New("file ownership checks are not supported on Windows")
+}
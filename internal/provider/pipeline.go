@@ -0,0 +1,235 @@
+package provider
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// pipelineError records which stage of a pipeline failed.
+type pipelineError struct {
+	stage int
+	cmd   *exec.Cmd
+	err   error
+}
+
+func (e *pipelineError) Error() string {
+	return fmt.Sprintf("pipeline stage %d (%s): %s", e.stage+1, e.cmd.Path, e.err)
+}
+
+func (e *pipelineError) Unwrap() error {
+	return e.err
+}
+
+// Names of the output streams of a program.
+const (
+	streamStdout = "stdout"
+	streamStderr = "stderr"
+)
+
+// pipelineOptions adjusts how runPipeline runs its commands.
+type pipelineOptions struct {
+	// wrap, if not nil, is applied to the writers capturing the standard
+	// error of each stage and the standard output of the last stage, given
+	// the name of the stream.
+	wrap func(w io.Writer, stream string) io.Writer
+
+	// resultStderr returns the standard error of the last stage rather than
+	// its standard output, which is then the error output carried by its
+	// *exec.ExitError instead.
+	resultStderr bool
+
+	// stdoutLog and stderrLog, if not nil, receive a copy of the standard
+	// output of the last stage and the standard error of every stage. Errors
+	// writing to them are ignored so that they never interrupt the program.
+	stdoutLog io.Writer
+	stderrLog io.Writer
+
+	// tty makes the standard output of the last stage a pseudo-terminal
+	// rather than a pipe.
+	tty bool
+
+	// started, if not nil, is called after each stage starts. If it returns
+	// an error the stage is killed and the error is reported as a failure to
+	// start it.
+	started func(*exec.Cmd) error
+}
+
+// runPipeline runs the commands concurrently, connecting the standard output
+// of each to the standard input of the next as a shell pipeline would, and
+// returns the standard output of the last command, or its standard error with
+// resultStderr. The standard input of the first command must already be set
+// by the caller.
+//
+// When more than one stage fails, the first failing stage is reported, as
+// later stages usually only fail because of its missing output. Stages
+// killed by SIGPIPE are only reported when no other stage failed, since they
+// are the victims of a later stage exiting early. Failures are returned as a
+// *pipelineError and, like (*exec.Cmd).Output, an *exec.ExitError carries
+// the standard error of its stage.
+func runPipeline(cmds []*exec.Cmd, opts pipelineOptions) ([]byte, error) {
+	var stdout bytes.Buffer
+	var ptyMaster *os.File
+	wrap := opts.wrap
+	stderrs := make([]bytes.Buffer, len(cmds))
+	pipes := make([]*os.File, 0, 2*(len(cmds)-1))
+
+	closePipes := func() {
+		for _, f := range pipes {
+			f.Close()
+		}
+	}
+
+	if wrap == nil {
+		wrap = func(w io.Writer, _ string) io.Writer { return w }
+	}
+
+	wrapStdout := func(w io.Writer) io.Writer { return teeLog(wrap(w, streamStdout), opts.stdoutLog) }
+
+	for idx, cmd := range cmds {
+		cmd.Stderr = teeLog(wrap(&stderrs[idx], streamStderr), opts.stderrLog)
+
+		if idx == len(cmds)-1 && opts.tty {
+			master, slave, err := openPty()
+			if err != nil {
+				closePipes()
+				return nil, err
+			}
+			pipes = append(pipes, slave)
+			ptyMaster = master
+
+			setControllingTerminal(cmd)
+			cmd.Stdout = slave
+			break
+		}
+
+		if idx == len(cmds)-1 {
+			cmd.Stdout = wrapStdout(&stdout)
+			break
+		}
+
+		r, w, err := os.Pipe()
+		if err != nil {
+			closePipes()
+			return nil, err
+		}
+		pipes = append(pipes, r, w)
+
+		cmd.Stdout = w
+		cmds[idx+1].Stdin = r
+	}
+
+	var startErr, waitErr, brokenPipeErr error
+	started := 0
+
+	for idx, cmd := range cmds {
+		if err := cmd.Start(); err != nil {
+			startErr = &pipelineError{stage: idx, cmd: cmd, err: err}
+			break
+		}
+		started++
+
+		if opts.started != nil {
+			if err := opts.started(cmd); err != nil {
+				cmd.Process.Kill()
+				startErr = &pipelineError{stage: idx, cmd: cmd, err: err}
+				break
+			}
+		}
+	}
+
+	// The children hold their own copies of the pipe ends, so closing ours
+	// lets each stage see EOF (or EPIPE) once its neighbour exits.
+	closePipes()
+
+	// Reading the pseudo-terminal fails with EIO rather than returning EOF
+	// once every process holding the slave end has exited, so the error of
+	// the copy is ignored.
+	var copied chan struct{}
+	if ptyMaster != nil {
+		copied = make(chan struct{})
+		go func() {
+			defer close(copied)
+			_, _ = io.Copy(wrapStdout(&stdout), ptyMaster)
+		}()
+	}
+
+	for idx, cmd := range cmds[:started] {
+		err := cmd.Wait()
+		if err == nil {
+			continue
+		}
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitErr.Stderr = stderrs[idx].Bytes()
+
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() && status.Signal() == syscall.SIGPIPE {
+				if brokenPipeErr == nil {
+					brokenPipeErr = &pipelineError{stage: idx, cmd: cmd, err: err}
+				}
+				continue
+			}
+		}
+
+		if waitErr == nil {
+			waitErr = &pipelineError{stage: idx, cmd: cmd, err: err}
+		}
+	}
+
+	if ptyMaster != nil {
+		<-copied
+		ptyMaster.Close()
+	}
+
+	output := stdout.Bytes()
+
+	if opts.resultStderr {
+		last := len(cmds) - 1
+		output = stderrs[last].Bytes()
+
+		for _, err := range []error{waitErr, brokenPipeErr} {
+			var pipeErr *pipelineError
+			var exitErr *exec.ExitError
+			if errors.As(err, &pipeErr) && pipeErr.stage == last && errors.As(err, &exitErr) {
+				exitErr.Stderr = stdout.Bytes()
+			}
+		}
+	}
+
+	// Stages after one that failed to start never ran, so that failure is
+	// the most relevant one to report.
+	if startErr != nil {
+		return output, startErr
+	}
+
+	if waitErr != nil {
+		return output, waitErr
+	}
+
+	return output, brokenPipeErr
+}
+
+// teeLog returns a writer writing to w and copying to log, ignoring errors
+// writing to log. It returns w unchanged when log is nil.
+func teeLog(w, log io.Writer) io.Writer {
+	if log == nil {
+		return w
+	}
+
+	return io.MultiWriter(w, ignoreErrorsWriter{log})
+}
+
+// ignoreErrorsWriter reports every write to its writer as successful.
+type ignoreErrorsWriter struct {
+	w io.Writer
+}
+
+func (w ignoreErrorsWriter) Write(p []byte) (int, error) {
+	_, _ = w.w.Write(p)
+	return len(p), nil
+}
@@ -0,0 +1,227 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRunPipeline(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	cmds := []*exec.Cmd{
+		exec.Command("sh", "-c", "printf 'b\\na\\n'"),
+		exec.Command("sort"),
+	}
+
+	out, err := runPipeline(cmds, pipelineOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if string(out) != "a\nb\n" {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
+
+func TestRunPipeline_StageError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	cmds := []*exec.Cmd{
+		exec.Command("sh", "-c", "echo first failed >&2; exit 3"),
+		exec.Command("sh", "-c", "cat >/dev/null; exit 4"),
+	}
+
+	_, err := runPipeline(cmds, pipelineOptions{})
+
+	pipeErr, ok := err.(*pipelineError)
+	if !ok {
+		t.Fatalf("expected *pipelineError, got: %#v", err)
+	}
+
+	if pipeErr.stage != 0 {
+		t.Errorf("expected first stage to be reported, got stage %d", pipeErr.stage)
+	}
+
+	exitErr, ok := pipeErr.err.(*exec.ExitError)
+	if !ok {
+		t.Fatalf("expected *exec.ExitError, got: %#v", pipeErr.err)
+	}
+
+	if !strings.Contains(string(exitErr.Stderr), "first failed") {
+		t.Errorf("unexpected stderr: %q", exitErr.Stderr)
+	}
+}
+
+func TestRunPipeline_OutputLimit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	limit := newOutputLimit(1024, cancel)
+	cmds := []*exec.Cmd{
+		exec.CommandContext(ctx, "sh", "-c", "while :; do echo flood; echo flood >&2; done"),
+	}
+
+	out, err := runPipeline(cmds, pipelineOptions{wrap: func(w io.Writer, _ string) io.Writer { return limit.wrap(w) }})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if !limit.Exceeded() {
+		t.Error("expected limit to be exceeded")
+	}
+
+	if len(out) > 1024 {
+		t.Errorf("expected at most 1024 bytes of output, got %d", len(out))
+	}
+}
+
+func TestRunPipeline_Pty(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("pseudo-terminals are only supported on Linux")
+	}
+
+	cmds := []*exec.Cmd{
+		exec.Command("sh", "-c", "if [ -t 1 ]; then echo tty; else echo pipe; fi; [ -t 0 ] || echo stdin-pipe"),
+	}
+	cmds[0].Stdin = strings.NewReader("{}")
+
+	out, err := runPipeline(cmds, pipelineOptions{tty: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if string(out) != "tty\nstdin-pipe\n" {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
+
+func TestRunPipeline_Logs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	cmds := []*exec.Cmd{
+		exec.Command("sh", "-c", "echo first >&2; echo out"),
+		exec.Command("sh", "-c", "cat; echo second >&2"),
+	}
+
+	dir := t.TempDir()
+
+	stdoutLog, err := os.Create(filepath.Join(dir, "stdout.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdoutLog.Close()
+
+	stderrLog, err := os.Create(filepath.Join(dir, "stderr.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderrLog.Close()
+
+	out, err := runPipeline(cmds, pipelineOptions{stdoutLog: stdoutLog, stderrLog: stderrLog})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	stdout, err := os.ReadFile(stdoutLog.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(out) != "out\n" || string(stdout) != "out\n" {
+		t.Errorf("unexpected output %q and output log %q", out, stdout)
+	}
+
+	stderr, err := os.ReadFile(stderrLog.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(stderr), "first\n") || !strings.Contains(string(stderr), "second\n") {
+		t.Errorf("unexpected error output log: %q", stderr)
+	}
+}
+
+func TestRunPipeline_ResultStderr(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	testCases := map[string]struct {
+		script         string
+		resultStderr   bool
+		expectedOutput string
+		expectedError  string
+	}{
+		"stdout": {
+			script:         "echo result; echo log >&2",
+			expectedOutput: "result\n",
+		},
+		"stderr": {
+			script:         "echo log; echo result >&2",
+			resultStderr:   true,
+			expectedOutput: "result\n",
+		},
+		"stdout-failed": {
+			script:         "echo result; echo failure >&2; exit 1",
+			expectedOutput: "result\n",
+			expectedError:  "failure\n",
+		},
+		"stderr-failed": {
+			script:         "echo failure; echo result >&2; exit 1",
+			resultStderr:   true,
+			expectedOutput: "result\n",
+			expectedError:  "failure\n",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			var logged []string
+			wrap := func(w io.Writer, stream string) io.Writer {
+				logged = append(logged, stream)
+				return w
+			}
+
+			cmds := []*exec.Cmd{exec.Command("sh", "-c", testCase.script)}
+
+			out, err := runPipeline(cmds, pipelineOptions{wrap: wrap, resultStderr: testCase.resultStderr})
+
+			if string(out) != testCase.expectedOutput {
+				t.Errorf("expected output %q, got %q", testCase.expectedOutput, out)
+			}
+
+			var exitErr *exec.ExitError
+			switch {
+			case testCase.expectedError == "" && err != nil:
+				t.Fatalf("unexpected error: %s", err)
+			case testCase.expectedError != "" && !errors.As(err, &exitErr):
+				t.Fatalf("expected exit error, got: %v", err)
+			case testCase.expectedError != "" && string(exitErr.Stderr) != testCase.expectedError:
+				t.Errorf("expected error output %q, got %q", testCase.expectedError, exitErr.Stderr)
+			}
+
+			if strings.Join(logged, ",") != streamStderr+","+streamStdout {
+				t.Errorf("unexpected wrapped streams: %q", logged)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package provider
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+const requiresReplaceUnlessUpdateInPlaceDescription = "If the value of this attribute changes, Terraform will " +
+	"destroy and recreate the resource, unless update_in_place is true or update_result_behavior is \"rerun\", " +
+	"in which case the program is re-run in place."
+
+// requiresReplaceUnlessUpdateInPlace reports whether a change to an attribute
+// requires the resource to be replaced, which is the case unless the
+// configuration sets update_in_place or an update_result_behavior of "rerun".
+func requiresReplaceUnlessUpdateInPlace(ctx context.Context, config tfsdk.Config) (bool, diag.Diagnostics) {
+	var updateInPlace types.Bool
+	var updateResultBehavior types.String
+
+	diags := config.GetAttribute(ctx, path.Root("update_in_place"), &updateInPlace)
+	diags.Append(config.GetAttribute(ctx, path.Root("update_result_behavior"), &updateResultBehavior)...)
+
+	rerun := updateInPlace.ValueBool() || updateResultBehavior.ValueString() == updateResultBehaviorRerun
+
+	return !rerun, diags
+}
+
+func boolRequiresReplaceUnlessUpdateInPlace() planmodifier.Bool {
+	return boolplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.BoolRequest, resp *boolplanmodifier.RequiresReplaceIfFuncResponse) {
+			resp.RequiresReplace, resp.Diagnostics = requiresReplaceUnlessUpdateInPlace(ctx, req.Config)
+		},
+		requiresReplaceUnlessUpdateInPlaceDescription,
+		requiresReplaceUnlessUpdateInPlaceDescription,
+	)
+}
+
+func listRequiresReplaceUnlessUpdateInPlace() planmodifier.List {
+	return listplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.ListRequest, resp *listplanmodifier.RequiresReplaceIfFuncResponse) {
+			resp.RequiresReplace, resp.Diagnostics = requiresReplaceUnlessUpdateInPlace(ctx, req.Config)
+		},
+		requiresReplaceUnlessUpdateInPlaceDescription,
+		requiresReplaceUnlessUpdateInPlaceDescription,
+	)
+}
+
+func mapRequiresReplaceUnlessUpdateInPlace() planmodifier.Map {
+	return mapplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.MapRequest, resp *mapplanmodifier.RequiresReplaceIfFuncResponse) {
+			resp.RequiresReplace, resp.Diagnostics = requiresReplaceUnlessUpdateInPlace(ctx, req.Config)
+		},
+		requiresReplaceUnlessUpdateInPlaceDescription,
+		requiresReplaceUnlessUpdateInPlaceDescription,
+	)
+}
+
+func stringRequiresReplaceUnlessUpdateInPlace() planmodifier.String {
+	return stringplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+			resp.RequiresReplace, resp.Diagnostics = requiresReplaceUnlessUpdateInPlace(ctx, req.Config)
+		},
+		requiresReplaceUnlessUpdateInPlaceDescription,
+		requiresReplaceUnlessUpdateInPlaceDescription,
+	)
+}
+
+// replacedAttributes returns the names of the attributes whose planned change
+// replaces the resource, as their requiresReplaceUnlessUpdateInPlace plan
+// modifiers do when the program is not re-run in place. The framework only
+// adds these attributes to RequiresReplace after the ModifyPlan of the
+// resource, which cannot see them there.
+func replacedAttributes(ctx context.Context, plan tfsdk.Plan, state tfsdk.State) []string {
+	var names []string
+
+	for name, attribute := range plan.Schema.GetAttributes() {
+		var modifiers []planmodifier.Describer
+
+		switch attribute := attribute.(type) {
+		case schema.BoolAttribute:
+			for _, modifier := range attribute.PlanModifiers {
+				modifiers = append(modifiers, modifier)
+			}
+		case schema.ListAttribute:
+			for _, modifier := range attribute.PlanModifiers {
+				modifiers = append(modifiers, modifier)
+			}
+		case schema.MapAttribute:
+			for _, modifier := range attribute.PlanModifiers {
+				modifiers = append(modifiers, modifier)
+			}
+		case schema.StringAttribute:
+			for _, modifier := range attribute.PlanModifiers {
+				modifiers = append(modifiers, modifier)
+			}
+		}
+
+		requiresReplace := false
+		for _, modifier := range modifiers {
+			requiresReplace = requiresReplace || modifier.Description(ctx) == requiresReplaceUnlessUpdateInPlaceDescription
+		}
+
+		if !requiresReplace {
+			continue
+		}
+
+		attributePath := tftypes.NewAttributePath().WithAttributeName(name)
+
+		planValue, _, err := tftypes.WalkAttributePath(plan.Raw, attributePath)
+		if err != nil {
+			continue
+		}
+
+		stateValue, _, err := tftypes.WalkAttributePath(state.Raw, attributePath)
+		if err != nil {
+			continue
+		}
+
+		if !planValue.(tftypes.Value).Equal(stateValue.(tftypes.Value)) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -2,9 +2,15 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"os"
+
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 func New() provider.Provider {
@@ -19,10 +25,142 @@ func (p *p) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provid
 	resp.TypeName = "exec"
 }
 
-func (p *p) Schema(context.Context, provider.SchemaRequest, *provider.SchemaResponse) {
+func (p *p) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"default_retries": schema.Int64Attribute{
+				Description: "Number of times a failed program execution is retried by resources that do " +
+					"not set `retries`. Defaults to `0`.",
+				Optional: true,
+			},
+			"default_retry_interval": schema.StringAttribute{
+				Description: "Duration to wait before the first retry, such as `\"5s\"`, for resources " +
+					"that do not set `retry_interval`. Defaults to `\"1s\"`.",
+				Optional: true,
+			},
+			"default_retry_backoff": schema.Float64Attribute{
+				Description: "Multiplier applied to the retry interval after each retry, for resources " +
+					"that do not set `retry_backoff`. Defaults to `1`.",
+				Optional: true,
+			},
+			"cache_dir": schema.StringAttribute{
+				Description: "Directory in which the output of resources that set `cache` is stored, keyed " +
+					"by a hash of the program, its arguments, `pipe`, working directory, environment and " +
+					"standard input. A resource whose inputs match a stored entry uses that output instead " +
+					"of running the program. Entries never expire: delete the directory, or its files, to " +
+					"invalidate them, for example when the program itself or the files it reads change.",
+				Optional: true,
+			},
+			"audit_log_file": schema.StringAttribute{
+				Description: "File to which a line of JSON is appended for every execution of a program " +
+					"or `destroy_program` by any resource, recording its `timestamp`, the `program`, its " +
+					"`args`, its `exit_code`, which is `null` when it did not exit normally, and its " +
+					"`duration_ms`. The values of arguments that look like credentials, such as those " +
+					"following a `--password` or `--api-key` flag or given as `TOKEN=...`, are redacted. " +
+					"The file is created if needed, readable only by its owner.",
+				Optional: true,
+			},
+			"metrics_file": schema.StringAttribute{
+				Description: "File to which a line of JSON is appended after every execution of a program " +
+					"by any resource, for monitoring where time is spent during an apply. Each line records " +
+					"its `timestamp`, the `phase` (`\"create\"`, `\"read\"` or `\"update\"`), the " +
+					"`program` and its `args`, redacted as in `audit_log_file`, its `exit_code`, which is " +
+					"`null` when it did not exit normally, its `duration_ms` including retries, the " +
+					"`output_bytes` it returned and the number of `retries` used. Cached and skipped runs " +
+					"are not recorded. The file is created if needed, readable only by its owner.",
+				Optional: true,
+			},
+			"log_output": schema.StringAttribute{
+				Description: "Controls what is logged at the `TRACE` level when programs are executed: " +
+					"`\"none\"`, `\"command\"` for the command line only, `\"output\"` for the program " +
+					"output only, or `\"all\"` for both. Defaults to `\"all\"`.",
+				Optional: true,
+			},
+		},
+	}
 }
 
-func (p *p) Configure(context.Context, provider.ConfigureRequest, *provider.ConfigureResponse) {
+func (p *p) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	var config providerModelV0
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	logOutput := logOutputAll
+	if !config.LogOutput.IsNull() {
+		logOutput = config.LogOutput.ValueString()
+	}
+
+	switch logOutput {
+	case logOutputNone, logOutputCommand, logOutputOutput, logOutputAll:
+	default:
+		resp.Diagnostics.AddAttributeError(path.Root("log_output"), "Invalid Log Output",
+			fmt.Sprintf("The log_output attribute must be one of %q, %q, %q or %q, got: %q",
+				logOutputNone, logOutputCommand, logOutputOutput, logOutputAll, logOutput))
+		return
+	}
+
+	// Invalid defaults would otherwise fail every resource that uses them.
+	resp.Diagnostics.Append(validateRetry("default_", config.DefaultRetries, config.DefaultRetryInterval, config.DefaultRetryBackoff)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var audit *auditLog
+	if name := config.AuditLogFile.ValueString(); name != "" {
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("audit_log_file"), "Invalid Audit Log File",
+				"The provider received an unexpected error while attempting to open the audit log file."+
+					fmt.Sprintf("\n\nFile: %s", name)+
+					fmt.Sprintf("\nError: %s", err))
+			return
+		}
+		f.Close()
+
+		audit = &auditLog{name: name}
+	}
+
+	var metrics *metricsSink
+	if name := config.MetricsFile.ValueString(); name != "" {
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("metrics_file"), "Invalid Metrics File",
+				"The provider received an unexpected error while attempting to open the metrics file."+
+					fmt.Sprintf("\n\nFile: %s", name)+
+					fmt.Sprintf("\nError: %s", err))
+			return
+		}
+		f.Close()
+
+		metrics = &metricsSink{name: name}
+	}
+
+	// Terraform starts providers in the root module directory, so the
+	// working directory is only unavailable if it has since been removed,
+	// in which case root_relative reports an error.
+	rootDir, _ := os.Getwd()
+
+	// Each Terraform command starts its own provider process, so this
+	// identifies the run to programs. It is omitted if it cannot be generated.
+	runID, _ := randomSeed()
+
+	data := &providerData{
+		defaultRetries:       config.DefaultRetries,
+		defaultRetryInterval: config.DefaultRetryInterval,
+		defaultRetryBackoff:  config.DefaultRetryBackoff,
+		logOutput:            logOutput,
+		cacheDir:             config.CacheDir.ValueString(),
+		rootDir:              rootDir,
+		selfTests:            newSelfTests(),
+		runID:                runID,
+		auditLog:             audit,
+		metricsSink:          metrics,
+	}
+
+	resp.ResourceData = data
 }
 
 func (p *p) Resources(context.Context) []func() resource.Resource {
@@ -34,3 +172,79 @@ func (p *p) Resources(context.Context) []func() resource.Resource {
 func (p *p) DataSources(context.Context) []func() datasource.DataSource {
 	return nil
 }
+
+type providerModelV0 struct {
+	DefaultRetries       types.Int64   `tfsdk:"default_retries"`
+	DefaultRetryInterval types.String  `tfsdk:"default_retry_interval"`
+	DefaultRetryBackoff  types.Float64 `tfsdk:"default_retry_backoff"`
+	CacheDir             types.String  `tfsdk:"cache_dir"`
+	LogOutput            types.String  `tfsdk:"log_output"`
+	AuditLogFile         types.String  `tfsdk:"audit_log_file"`
+	MetricsFile          types.String  `tfsdk:"metrics_file"`
+}
+
+// providerData is handed to resources in Configure and carries the
+// provider-wide settings they fall back to.
+type providerData struct {
+	defaultRetries       types.Int64
+	defaultRetryInterval types.String
+	defaultRetryBackoff  types.Float64
+
+	// logOutput is one of the logOutput constants.
+	logOutput string
+
+	// cacheDir is the result cache directory, or empty when caching is
+	// disabled.
+	cacheDir string
+
+	// rootDir is the root module directory, or empty when it could not be
+	// determined.
+	rootDir string
+
+	// selfTests are shared by all resources of the provider.
+	selfTests *selfTests
+
+	// runID is a random identifier of the provider process, or empty.
+	runID string
+
+	// auditLog records program executions, or is nil when disabled.
+	auditLog *auditLog
+
+	// metricsSink records execution metrics, or is nil when disabled.
+	metricsSink *metricsSink
+}
+
+const (
+	logOutputNone    = "none"
+	logOutputCommand = "command"
+	logOutputOutput  = "output"
+	logOutputAll     = "all"
+)
+
+// logsCommand reports whether the command line of programs is logged.
+func (d *providerData) logsCommand() bool {
+	return d == nil || d.logOutput == logOutputCommand || d.logOutput == logOutputAll
+}
+
+// logsOutput reports whether the output of programs is logged.
+func (d *providerData) logsOutput() bool {
+	return d == nil || d.logOutput == logOutputOutput || d.logOutput == logOutputAll
+}
+
+// audit returns the audit log of the provider, or nil.
+func (d *providerData) audit() *auditLog {
+	if d == nil {
+		return nil
+	}
+
+	return d.auditLog
+}
+
+// metrics returns the metrics sink of the provider, or nil.
+func (d *providerData) metrics() *metricsSink {
+	if d == nil {
+		return nil
+	}
+
+	return d.metricsSink
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package provider
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// openPty is not supported on platforms other than Linux.
+func openPty() (*os.File, *os.File, error) {
+	return nil, nil, errors.New("pseudo-terminals are not supported on this platform")
+}
+
+// setControllingTerminal is a no-op on platforms other than Linux.
+func setControllingTerminal(_ *exec.Cmd) {}
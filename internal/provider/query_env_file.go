@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// queryEnvFileVar is the environment variable the path of the query
+// environment file is passed to the program in when query_env_file is set.
+const queryEnvFileVar = "QUERY_ENV_FILE"
+
+var shellVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// formatQueryEnvFile returns the query as KEY='VALUE' lines sorted by key,
+// quoted so that the file can be sourced by a POSIX shell. Keys that are not
+// valid shell variable names are rejected.
+func formatQueryEnvFile(query map[string]string) ([]byte, error) {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if !shellVariableName.MatchString(key) {
+			return nil, fmt.Errorf("query key %q is not a valid environment variable name", key)
+		}
+
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key)
+		b.WriteString("='")
+		b.WriteString(strings.ReplaceAll(query[key], "'", `'\''`))
+		b.WriteString("'\n")
+	}
+
+	return []byte(b.String()), nil
+}
+
+// writeQueryEnvFile writes content to a new temporary file readable only by
+// the current user and returns its path. The caller removes the file.
+func writeQueryEnvFile(content []byte) (string, error) {
+	f, err := os.CreateTemp("", "terraform-external-query-*.env")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFormatQueryEnvFile(t *testing.T) {
+	testCases := map[string]struct {
+		query       map[string]string
+		expected    string
+		expectError bool
+	}{
+		"empty": {
+			query:    map[string]string{},
+			expected: "",
+		},
+		"sorted": {
+			query:    map[string]string{"b": "2", "a": "1"},
+			expected: "a='1'\nb='2'\n",
+		},
+		"quoted": {
+			query:    map[string]string{"value": "it's $HOME\nand more"},
+			expected: "value='it'\\''s $HOME\nand more'\n",
+		},
+		"invalid-key": {
+			query:       map[string]string{"not-valid": "x"},
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual, err := formatQueryEnvFile(testCase.query)
+
+			if testCase.expectError {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if string(actual) != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWriteQueryEnvFile(t *testing.T) {
+	name, err := writeQueryEnvFile([]byte("a='1'\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.Remove(name)
+
+	content, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if string(content) != "a='1'\n" {
+		t.Errorf("expected file content %q, got %q", "a='1'\n", content)
+	}
+}
@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// marshalRecords encodes the query_records as newline-delimited JSON, one
+// object per line.
+func marshalRecords(records []map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+// recordsToArray converts newline-delimited JSON output to a JSON array of
+// its values, returning the number of values. Blank lines are ignored.
+func recordsToArray(output []byte) ([]byte, int, error) {
+	values := []json.RawMessage{}
+
+	dec := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var value json.RawMessage
+		if err := dec.Decode(&value); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, 0, err
+		}
+
+		values = append(values, value)
+	}
+
+	array, err := json.Marshal(values)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return array, len(values), nil
+}
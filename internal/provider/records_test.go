@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestMarshalRecords(t *testing.T) {
+	actual, err := marshalRecords([]map[string]string{
+		{"name": "a", "size": "1"},
+		{"name": "b"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := "{\"name\":\"a\",\"size\":\"1\"}\n{\"name\":\"b\"}\n"
+	if string(actual) != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestRecordsToArray(t *testing.T) {
+	testCases := map[string]struct {
+		output        string
+		expected      string
+		expectedCount int
+		expectError   bool
+	}{
+		"records": {
+			output:        "{\"id\":\"1\"}\n{\"id\":\"2\"}\n",
+			expected:      `[{"id":"1"},{"id":"2"}]`,
+			expectedCount: 2,
+		},
+		"blank-lines": {
+			output:        "\n{\"id\":\"1\"}\n\n{\"id\":\"2\"}",
+			expected:      `[{"id":"1"},{"id":"2"}]`,
+			expectedCount: 2,
+		},
+		"empty": {
+			output:   "",
+			expected: `[]`,
+		},
+		"invalid": {
+			output:      "{\"id\":\"1\"}\nnot json\n",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual, count, err := recordsToArray([]byte(testCase.output))
+
+			if testCase.expectError {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if string(actual) != testCase.expected || count != testCase.expectedCount {
+				t.Errorf("expected %s with %d records, got %s with %d", testCase.expected, testCase.expectedCount, actual, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,812 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+const (
+	resultTypeString = "string"
+	resultTypeNumber = "number"
+	resultTypeBool   = "bool"
+)
+
+// validateResultTypes checks that each result value named in resultTypes can
+// be interpreted as the declared type. Values are checked in their string
+// form, as that is how they are stored in the result, so a number may be
+// returned either as a JSON number or as a string such as "42". Keys that are
+// absent from the result are not checked.
+func validateResultTypes(result map[string]interface{}, resultTypes map[string]string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	keys := make([]string, 0, len(resultTypes))
+	for key := range resultTypes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		typ := resultTypes[key]
+
+		switch typ {
+		case resultTypeString, resultTypeNumber, resultTypeBool:
+		default:
+			diags.AddError("Invalid Result Type",
+				fmt.Sprintf("The result_types entry for %q must be one of %q, %q or %q, got: %q",
+					key, resultTypeString, resultTypeNumber, resultTypeBool, typ))
+			continue
+		}
+
+		val, ok := result[key]
+		if !ok {
+			continue
+		}
+
+		if !resultValueHasType(val, typ) {
+			diags.AddError("Unexpected External Program Result Type",
+				"The data source received a result value that does not match its declared type in result_types."+
+					fmt.Sprintf("\n\nKey: %s", key)+
+					fmt.Sprintf("\nExpected Type: %s", typ)+
+					fmt.Sprintf("\nValue: %v", val))
+		}
+	}
+
+	return diags
+}
+
+// unexpectedResultKeys returns the keys of the result that are not in
+// allowed, in sorted order.
+func unexpectedResultKeys(result map[string]interface{}, allowed []string) []string {
+	allowedKeys := make(map[string]bool, len(allowed))
+	for _, key := range allowed {
+		allowedKeys[key] = true
+	}
+
+	var unexpected []string
+	for key := range result {
+		if !allowedKeys[key] {
+			unexpected = append(unexpected, key)
+		}
+	}
+	sort.Strings(unexpected)
+
+	return unexpected
+}
+
+func resultValueHasType(val interface{}, typ string) bool {
+	switch v := val.(type) {
+	case string:
+		switch typ {
+		case resultTypeNumber:
+			_, err := strconv.ParseFloat(v, 64)
+			return err == nil
+		case resultTypeBool:
+			_, err := strconv.ParseBool(v)
+			return err == nil
+		}
+		return true
+	case float64:
+		return typ == resultTypeNumber
+	case bool:
+		return typ == resultTypeBool
+	}
+
+	return false
+}
+
+// programReturnedError looks for an error reported by the program in its
+// JSON output under errorKey, along with optional details under detailKey.
+// Non-string values are formatted as JSON.
+func programReturnedError(output []byte, errorKey, detailKey string) (message, detail string, ok bool) {
+	if errorKey == "" {
+		return "", "", false
+	}
+
+	result := map[string]interface{}{}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", "", false
+	}
+
+	message = resultValueString(result[errorKey])
+	if message == "" {
+		return "", "", false
+	}
+
+	if detailKey != "" {
+		detail = resultValueString(result[detailKey])
+	}
+
+	return message, detail, true
+}
+
+// resultValueString returns the string form of a parsed JSON value, or the
+// empty string for null.
+func resultValueString(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+
+	b, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprintf("%v", val)
+	}
+
+	return string(b)
+}
+
+// resultStrings returns the values of a parsed JSON object in their string
+// form, as they are stored in result, while result_dynamic keeps their types.
+func resultStrings(result map[string]interface{}) map[string]string {
+	values := make(map[string]string, len(result))
+	for key, val := range result {
+		values[key] = resultValueString(val)
+	}
+
+	return values
+}
+
+// flattenResultArrays replaces each array in the result with a key for each
+// element, named by the key of the array and the index of the element as in
+// items.0, and an items.count key holding the number of elements. Objects and
+// arrays within an array are expanded the same way, as in items.0.name and
+// items.0.tags.0, and other elements are converted to strings. Values other
+// than arrays are left as they are.
+func flattenResultArrays(result map[string]interface{}) map[string]interface{} {
+	flattened := make(map[string]interface{}, len(result))
+
+	for key, val := range result {
+		if elems, ok := val.([]interface{}); ok {
+			flattenArray(flattened, key, elems)
+			continue
+		}
+
+		flattened[key] = val
+	}
+
+	return flattened
+}
+
+// flattenArray adds the flattened elements of the array under the prefix.
+func flattenArray(flattened map[string]interface{}, prefix string, elems []interface{}) {
+	flattened[prefix+".count"] = strconv.Itoa(len(elems))
+
+	for idx, elem := range elems {
+		flattenValue(flattened, prefix+"."+strconv.Itoa(idx), elem)
+	}
+}
+
+// flattenValue adds the value under the key, expanding objects and arrays.
+func flattenValue(flattened map[string]interface{}, key string, val interface{}) {
+	switch v := val.(type) {
+	case []interface{}:
+		flattenArray(flattened, key, v)
+	case map[string]interface{}:
+		for field, fieldVal := range v {
+			flattenValue(flattened, key+"."+field, fieldVal)
+		}
+	default:
+		flattened[key] = resultValueString(v)
+	}
+}
+
+// extractResultSections removes the named top-level objects from the result
+// and returns them as maps of strings. Each section must be present and be
+// an object of string values.
+func extractResultSections(result map[string]interface{}, names []string) (map[string]map[string]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	sections := make(map[string]map[string]string, len(names))
+
+	for _, name := range names {
+		val, ok := result[name]
+		if !ok {
+			diags.AddAttributeError(path.Root("result_sections"), "Missing Result Section",
+				"The program output does not contain a top-level key for this result section."+
+					fmt.Sprintf("\n\nSection: %s", name))
+			continue
+		}
+
+		object, ok := val.(map[string]interface{})
+		if !ok {
+			diags.AddAttributeError(path.Root("result_sections"), "Invalid Result Section",
+				"The value of each result section in the program output must be a JSON encoded map of string keys and string values."+
+					fmt.Sprintf("\n\nSection: %s", name)+
+					fmt.Sprintf("\nSection Type: %T", val))
+			continue
+		}
+
+		section := make(map[string]string, len(object))
+
+		for key, elem := range object {
+			str, ok := elem.(string)
+			if !ok {
+				diags.AddAttributeError(path.Root("result_sections"), "Invalid Result Section",
+					"The value of each result section in the program output must be a JSON encoded map of string keys and string values."+
+						fmt.Sprintf("\n\nSection: %s", name)+
+						fmt.Sprintf("\nKey: %s", key)+
+						fmt.Sprintf("\nValue Type: %T", elem))
+				continue
+			}
+
+			section[key] = str
+		}
+
+		sections[name] = section
+		delete(result, name)
+	}
+
+	return sections, diags
+}
+
+// compactResultJSON returns the program output as compact JSON, keeping the
+// structure and values of the output exactly, including numbers that would
+// lose precision when parsed as float64.
+func compactResultJSON(output []byte) (string, error) {
+	var b bytes.Buffer
+
+	if err := json.Compact(&b, output); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// canonicalJSON returns the canonical encoding of the JSON output: compact,
+// with object keys sorted and numbers written as given. It reports whether the
+// output, without surrounding whitespace, already is canonical.
+func canonicalJSON(output []byte) ([]byte, bool, error) {
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	decoder.UseNumber()
+
+	var val interface{}
+	if err := decoder.Decode(&val); err != nil {
+		return nil, false, err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, false, errors.New("invalid character after top-level value")
+	}
+
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(val); err != nil {
+		return nil, false, err
+	}
+
+	// Encode terminates the value with a newline.
+	canonical := bytes.TrimSuffix(b.Bytes(), []byte("\n"))
+
+	return canonical, bytes.Equal(canonical, bytes.TrimSpace(output)), nil
+}
+
+// duplicateKeys returns the keys that appear more than once in the JSON
+// object, or in any object element of the JSON array, of the output, in the
+// order they are repeated. Nested objects are not checked. Output that is
+// neither an object nor an array has no keys.
+func duplicateKeys(output []byte) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(output))
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		return objectDuplicateKeys(decoder)
+	case json.Delim('['):
+		var duplicates []string
+
+		for decoder.More() {
+			var elem json.RawMessage
+			if err := decoder.Decode(&elem); err != nil {
+				return nil, err
+			}
+
+			elemDecoder := json.NewDecoder(bytes.NewReader(elem))
+			if token, err := elemDecoder.Token(); err != nil || token != json.Delim('{') {
+				continue
+			}
+
+			elemDuplicates, err := objectDuplicateKeys(elemDecoder)
+			if err != nil {
+				return nil, err
+			}
+
+			duplicates = append(duplicates, elemDuplicates...)
+		}
+
+		return duplicates, nil
+	}
+
+	return nil, nil
+}
+
+// objectDuplicateKeys returns the repeated keys of the object being decoded,
+// whose opening brace has been read.
+func objectDuplicateKeys(decoder *json.Decoder) ([]string, error) {
+	seen := make(map[string]bool)
+	var duplicates []string
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object key: %v", token)
+		}
+
+		if seen[key] {
+			duplicates = append(duplicates, key)
+		}
+		seen[key] = true
+
+		var val json.RawMessage
+		if err := decoder.Decode(&val); err != nil {
+			return nil, err
+		}
+	}
+
+	return duplicates, nil
+}
+
+// lastJSONObject returns the last complete JSON object in the output, which
+// must be followed only by whitespace, ignoring any text before it. Objects
+// that are followed by more output are skipped as a whole, so an object
+// nested in the last one is never returned in its place.
+func lastJSONObject(output []byte) ([]byte, bool) {
+	trimmed := bytes.TrimRightFunc(output, unicode.IsSpace)
+
+	for start := 0; start < len(trimmed); {
+		offset := bytes.IndexByte(trimmed[start:], '{')
+		if offset < 0 {
+			break
+		}
+		start += offset
+
+		var object map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(trimmed[start:]))
+		if err := decoder.Decode(&object); err != nil {
+			start++
+			continue
+		}
+
+		end := start + int(decoder.InputOffset())
+		if end == len(trimmed) {
+			return trimmed[start:], true
+		}
+
+		start = end
+	}
+
+	return nil, false
+}
+
+// partialOutput returns the complete part of the output of a program that
+// was stopped before it finished, reporting false when there is none. Only
+// complete lines are kept, and of those, with output_format "json", the last
+// JSON object. With "json_array", the complete elements of the array are
+// kept, wherever the output was cut off.
+func partialOutput(output []byte, outputFormat string) ([]byte, bool) {
+	if outputFormat == outputFormatJSONArray {
+		decoder := json.NewDecoder(bytes.NewReader(output))
+		if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+			return nil, false
+		}
+
+		elements := []json.RawMessage{}
+		for decoder.More() {
+			var element json.RawMessage
+			if err := decoder.Decode(&element); err != nil {
+				break
+			}
+			elements = append(elements, element)
+		}
+
+		array, err := json.Marshal(elements)
+		if err != nil {
+			return nil, false
+		}
+
+		return array, true
+	}
+
+	end := bytes.LastIndexByte(output, '\n')
+	if end < 0 {
+		return nil, false
+	}
+	complete := output[:end+1]
+
+	if outputFormat == outputFormatHCL {
+		return complete, true
+	}
+
+	return lastJSONObject(complete)
+}
+
+// numericResultJSON converts program output consisting of a single number,
+// surrounded by optional whitespace, to a JSON object storing the number as a
+// string under key.
+func numericResultJSON(output []byte, key string) ([]byte, error) {
+	number := strings.TrimSpace(string(output))
+
+	if !resultValueHasType(number, resultTypeNumber) {
+		return nil, fmt.Errorf("output is not a number: %q", number)
+	}
+
+	return json.Marshal(map[string]string{key: number})
+}
+
+// mergeResultKeys returns the prior result with the listed keys taken from
+// the current result. A listed key absent from the current result is removed,
+// and keys that are not listed keep their prior values.
+func mergeResultKeys(prior map[string]string, current map[string]interface{}, keys []string) map[string]interface{} {
+	merged := make(map[string]interface{}, len(prior)+len(keys))
+	for key, val := range prior {
+		merged[key] = val
+	}
+
+	for _, key := range keys {
+		if val, ok := current[key]; ok {
+			merged[key] = val
+		} else {
+			delete(merged, key)
+		}
+	}
+
+	return merged
+}
+
+// dedupeResults returns the results without elements equal to an earlier
+// element, compared by their JSON encoding, keeping the first occurrence of
+// each in the original order.
+func dedupeResults(results []interface{}) ([]interface{}, error) {
+	seen := make(map[string]bool, len(results))
+	deduped := make([]interface{}, 0, len(results))
+
+	for _, elem := range results {
+		// Map keys are encoded sorted, so equal elements encode equally.
+		encoded, err := json.Marshal(elem)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[string(encoded)] {
+			continue
+		}
+
+		seen[string(encoded)] = true
+		deduped = append(deduped, elem)
+	}
+
+	return deduped, nil
+}
+
+// resultSnapshot returns the values of the listed keys of the result. Keys
+// absent from the result are absent from the snapshot.
+func resultSnapshot(result map[string]string, keys []string) map[string]string {
+	snapshot := make(map[string]string, len(keys))
+
+	for _, key := range keys {
+		if val, ok := result[key]; ok {
+			snapshot[key] = val
+		}
+	}
+
+	return snapshot
+}
+
+// changedResultKeys returns the listed keys whose value in the result differs
+// from the snapshot, including keys present in only one of them.
+func changedResultKeys(result, snapshot map[string]string, keys []string) []string {
+	var changed []string
+
+	for _, key := range keys {
+		val, ok := result[key]
+		snapshotVal, snapshotOk := snapshot[key]
+
+		if ok != snapshotOk || val != snapshotVal {
+			changed = append(changed, key)
+		}
+	}
+
+	return changed
+}
+
+// resultFingerprint returns a hash of the result and results attributes,
+// which is stable for equal values regardless of key order.
+func resultFingerprint(ctx context.Context, result types.Map, results types.List) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	var fingerprint struct {
+		Result  map[string]string   `json:"result"`
+		Results []map[string]string `json:"results"`
+	}
+
+	if !result.IsNull() {
+		diags.Append(result.ElementsAs(ctx, &fingerprint.Result, false)...)
+	}
+
+	if !results.IsNull() {
+		diags.Append(results.ElementsAs(ctx, &fingerprint.Results, false)...)
+	}
+
+	if diags.HasError() {
+		return "", diags
+	}
+
+	// encoding/json writes map keys in sorted order.
+	b, err := json.Marshal(fingerprint)
+	if err != nil {
+		diags.AddError("Result Fingerprint Failed",
+			"The data source received an unexpected error while attempting to compute the result fingerprint. "+
+				"This is always a bug in the external provider code and should be reported to the provider developers."+
+				fmt.Sprintf("\n\nError: %s", err))
+		return "", diags
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), diags
+}
+
+const (
+	nonObjectOutputError = "error"
+	nonObjectOutputWrap  = "wrap"
+	nonObjectOutputRaw   = "raw"
+
+	defaultNonObjectKey = "value"
+)
+
+// nonObjectOutput applies the non_object_output mode to program output that
+// is valid JSON but not an object, returning output that is a JSON object.
+// Any other output is returned unchanged, to be reported when it is parsed.
+func nonObjectOutput(output []byte, mode, key string) ([]byte, error) {
+	switch mode {
+	case "", nonObjectOutputError:
+		return output, nil
+	case nonObjectOutputWrap, nonObjectOutputRaw:
+	default:
+		return nil, fmt.Errorf("non_object_output must be one of %q, %q or %q, got: %q",
+			nonObjectOutputError, nonObjectOutputWrap, nonObjectOutputRaw, mode)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(output, &value); err != nil {
+		return output, nil
+	}
+
+	if _, ok := value.(map[string]interface{}); ok {
+		return output, nil
+	}
+
+	if mode == nonObjectOutputRaw {
+		return []byte("{}"), nil
+	}
+
+	if key == "" {
+		key = defaultNonObjectKey
+	}
+
+	return json.Marshal(map[string]string{key: resultValueString(value)})
+}
+
+// validateEcho checks that the result contains the query value of key
+// unchanged.
+func validateEcho(result map[string]interface{}, query map[string]string, key string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	expected, ok := query[key]
+	if !ok {
+		diags.AddAttributeError(path.Root("echo_key"), "Invalid Echo Key",
+			"The echo_key attribute must name a key of the query passed to the program."+
+				fmt.Sprintf("\n\nKey: %s", key))
+		return diags
+	}
+
+	val, ok := result[key]
+	if !ok {
+		diags.AddAttributeError(path.Root("echo_key"), "Program Echo Mismatch",
+			"The program result does not contain the echo_key, so it may not have received its input correctly."+
+				fmt.Sprintf("\n\nKey: %s", key))
+		return diags
+	}
+
+	if got := resultValueString(val); got != expected {
+		diags.AddAttributeError(path.Root("echo_key"), "Program Echo Mismatch",
+			"The program result does not contain the query value of the echo_key unchanged, so it may not have received its input correctly."+
+				fmt.Sprintf("\n\nKey: %s", key)+
+				fmt.Sprintf("\nExpected: %q", expected)+
+				fmt.Sprintf("\nGot: %q", got))
+	}
+
+	return diags
+}
+
+// protocolVersionKey is the query and result key the protocol_version is
+// passed and echoed under.
+const protocolVersionKey = "__protocol_version"
+
+// protocolMajorVersion returns the major component of a version such as
+// "2" or "2.1".
+func protocolMajorVersion(version string) string {
+	major, _, _ := strings.Cut(strings.TrimSpace(version), ".")
+	return major
+}
+
+// validateProtocolVersion checks that the result echoes a protocol version
+// compatible with the expected one, which is the case when their major
+// versions are equal.
+func validateProtocolVersion(result map[string]interface{}, expected string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	val, ok := result[protocolVersionKey]
+	if !ok {
+		diags.AddAttributeError(path.Root("require_protocol_version"), "Program Protocol Version Missing",
+			"The program result does not contain a protocol version, so the program may not support the protocol "+
+				"version the configuration expects."+
+				fmt.Sprintf("\n\nKey: %s", protocolVersionKey)+
+				fmt.Sprintf("\nExpected: %s", expected))
+		return diags
+	}
+
+	got := resultValueString(val)
+	if protocolMajorVersion(got) != protocolMajorVersion(expected) {
+		diags.AddAttributeError(path.Root("require_protocol_version"), "Program Protocol Version Mismatch",
+			"The program result reports a protocol version that is not compatible with the one the configuration "+
+				"expects. Versions are compatible when their major versions are equal."+
+				fmt.Sprintf("\n\nExpected: %s", expected)+
+				fmt.Sprintf("\nGot: %s", got))
+	}
+
+	return diags
+}
+
+// resultObject decodes the result values declared in attributes, a map of
+// keys to their types, into a result_object value: an object with an
+// attribute of the declared type for each key. Every declared key must be
+// present in the result with a value of its type. As with result_types,
+// numbers and bools may be returned as JSON values or as strings, while
+// strings must be JSON strings.
+func resultObject(result map[string]interface{}, attributes map[string]string) (dynamicValue, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	attrTypes := make(map[string]tftypes.Type, len(attributes))
+	attrs := make(map[string]tftypes.Value, len(attributes))
+
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		typ := attributes[key]
+		attrPath := path.Root("result_attributes").AtMapKey(key)
+
+		switch typ {
+		case resultTypeString, resultTypeNumber, resultTypeBool:
+		default:
+			diags.AddAttributeError(attrPath, "Invalid Result Attribute Type",
+				fmt.Sprintf("The result_attributes entry for %q must be one of %q, %q or %q, got: %q",
+					key, resultTypeString, resultTypeNumber, resultTypeBool, typ))
+			continue
+		}
+
+		val, ok := result[key]
+		if !ok || val == nil {
+			diags.AddAttributeError(attrPath, "Missing External Program Result Attribute",
+				"The data source received results that do not contain a value for this declared result attribute."+
+					fmt.Sprintf("\n\nKey: %s", key))
+			continue
+		}
+
+		if !resultValueHasType(val, typ) {
+			diags.AddAttributeError(attrPath, "Unexpected External Program Result Type",
+				"The data source received a result value that does not match its declared type in result_attributes."+
+					fmt.Sprintf("\n\nKey: %s", key)+
+					fmt.Sprintf("\nExpected Type: %s", typ)+
+					fmt.Sprintf("\nValue: %v", val))
+			continue
+		}
+
+		switch typ {
+		case resultTypeString:
+			attrTypes[key] = tftypes.String
+			attrs[key] = tftypes.NewValue(tftypes.String, val.(string))
+		case resultTypeNumber:
+			number, _, err := big.ParseFloat(resultValueString(val), 10, 512, big.ToNearestEven)
+			if err != nil {
+				diags.AddAttributeError(attrPath, "Unexpected External Program Result Type",
+					"The data source received an unexpected error while attempting to decode the result value as a number."+
+						fmt.Sprintf("\n\nKey: %s", key)+
+						fmt.Sprintf("\nError: %s", err))
+				continue
+			}
+			attrTypes[key] = tftypes.Number
+			attrs[key] = tftypes.NewValue(tftypes.Number, number)
+		case resultTypeBool:
+			b, _ := strconv.ParseBool(resultValueString(val))
+			attrTypes[key] = tftypes.Bool
+			attrs[key] = tftypes.NewValue(tftypes.Bool, b)
+		}
+	}
+
+	if diags.HasError() {
+		return dynamicNull(), diags
+	}
+
+	return dynamicValue{value: tftypes.NewValue(tftypes.Object{AttributeTypes: attrTypes}, attrs)}, diags
+}
+
+// programDiagnosticsKey is the result key structured diagnostics are
+// returned under when parse_diagnostics is set.
+const programDiagnosticsKey = "diagnostics"
+
+// programDiagnostics removes the diagnostics array from the result and
+// returns its entries as Terraform diagnostics. Each entry is an object with
+// a severity of "error" or "warning", a summary and an optional detail.
+func programDiagnostics(result map[string]interface{}) (diag.Diagnostics, error) {
+	var diags diag.Diagnostics
+
+	raw, ok := result[programDiagnosticsKey]
+	if !ok {
+		return diags, nil
+	}
+	delete(result, programDiagnosticsKey)
+
+	entries, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be an array, got %T", programDiagnosticsKey, raw)
+	}
+
+	for idx, entryRaw := range entries {
+		entry, ok := entryRaw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s element %d must be an object, got %T", programDiagnosticsKey, idx, entryRaw)
+		}
+
+		summary, _ := entry["summary"].(string)
+		if summary == "" {
+			return nil, fmt.Errorf("%s element %d must have a summary", programDiagnosticsKey, idx)
+		}
+
+		detail, _ := entry["detail"].(string)
+
+		switch severity, _ := entry["severity"].(string); severity {
+		case "error":
+			diags.AddError(summary, detail)
+		case "warning":
+			diags.AddWarning(summary, detail)
+		default:
+			return nil, fmt.Errorf("%s element %d must have a severity of %q or %q, got: %q",
+				programDiagnosticsKey, idx, "error", "warning", severity)
+		}
+	}
+
+	return diags, nil
+}
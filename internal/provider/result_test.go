@@ -0,0 +1,1062 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestValidateResultTypes(t *testing.T) {
+	testCases := map[string]struct {
+		result      map[string]interface{}
+		resultTypes map[string]string
+		expectError bool
+	}{
+		"string": {
+			result:      map[string]interface{}{"key": "value"},
+			resultTypes: map[string]string{"key": "string"},
+		},
+		"number-string": {
+			result:      map[string]interface{}{"key": "-1.5"},
+			resultTypes: map[string]string{"key": "number"},
+		},
+		"number-json": {
+			result:      map[string]interface{}{"key": float64(42)},
+			resultTypes: map[string]string{"key": "number"},
+		},
+		"number-invalid": {
+			result:      map[string]interface{}{"key": "forty-two"},
+			resultTypes: map[string]string{"key": "number"},
+			expectError: true,
+		},
+		"bool-string": {
+			result:      map[string]interface{}{"key": "true"},
+			resultTypes: map[string]string{"key": "bool"},
+		},
+		"bool-invalid": {
+			result:      map[string]interface{}{"key": "yes"},
+			resultTypes: map[string]string{"key": "bool"},
+			expectError: true,
+		},
+		"missing-key": {
+			result:      map[string]interface{}{},
+			resultTypes: map[string]string{"key": "number"},
+		},
+		"unknown-type": {
+			result:      map[string]interface{}{"key": "value"},
+			resultTypes: map[string]string{"key": "list"},
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			diags := validateResultTypes(testCase.result, testCase.resultTypes)
+
+			if diags.HasError() != testCase.expectError {
+				t.Errorf("expected error %t, got diagnostics: %v", testCase.expectError, diags)
+			}
+		})
+	}
+}
+
+func TestProgramReturnedError(t *testing.T) {
+	testCases := map[string]struct {
+		output        string
+		expectOk      bool
+		expectMessage string
+		expectDetail  string
+	}{
+		"no-error": {
+			output: `{"value": "ok"}`,
+		},
+		"empty-error": {
+			output: `{"error": ""}`,
+		},
+		"null-error": {
+			output: `{"error": null}`,
+		},
+		"error": {
+			output:        `{"error": "bad input"}`,
+			expectOk:      true,
+			expectMessage: "bad input",
+		},
+		"error-with-detail": {
+			output:        `{"error": "bad input", "detail": "line 1\nline 2"}`,
+			expectOk:      true,
+			expectMessage: "bad input",
+			expectDetail:  "line 1\nline 2",
+		},
+		"non-string-error": {
+			output:        `{"error": {"code": 3}}`,
+			expectOk:      true,
+			expectMessage: `{"code":3}`,
+		},
+		"invalid-json": {
+			output: `not json`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			message, detail, ok := programReturnedError([]byte(testCase.output), "error", "detail")
+
+			if ok != testCase.expectOk || message != testCase.expectMessage || detail != testCase.expectDetail {
+				t.Errorf("unexpected result: %q, %q, %t", message, detail, ok)
+			}
+		})
+	}
+}
+
+func TestExtractResultSections(t *testing.T) {
+	result := map[string]interface{}{
+		"key": "value",
+		"network": map[string]interface{}{
+			"vpc_id": "vpc-123",
+		},
+	}
+
+	sections, diags := extractResultSections(result, []string{"network"})
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if sections["network"]["vpc_id"] != "vpc-123" {
+		t.Errorf("unexpected sections: %v", sections)
+	}
+
+	if _, ok := result["network"]; ok {
+		t.Error("expected section to be removed from result")
+	}
+
+	if result["key"] != "value" {
+		t.Errorf("unexpected result: %v", result)
+	}
+
+	testCases := map[string]map[string]interface{}{
+		"missing":    {"key": "value"},
+		"not-object": {"network": "value"},
+		"non-string": {"network": map[string]interface{}{"port": float64(80)}},
+	}
+
+	for name, result := range testCases {
+		name, result := name, result
+
+		t.Run(name, func(t *testing.T) {
+			_, diags := extractResultSections(result, []string{"network"})
+			if !diags.HasError() {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestCompactResultJSON(t *testing.T) {
+	testCases := map[string]struct {
+		output      string
+		expected    string
+		expectError bool
+	}{
+		"nested-object": {
+			output:   "{\n  \"foo\": {\n    \"bar\": \"baz\"\n  }\n}\n",
+			expected: `{"foo":{"bar":"baz"}}`,
+		},
+		"array": {
+			output:   `[ {"a": "1"}, {"b": "2"} ]`,
+			expected: `[{"a":"1"},{"b":"2"}]`,
+		},
+		"mixed-types": {
+			output:   `{"string": "s", "number": 12345678901234567890, "bool": true, "null": null, "list": [1, "two", false]}`,
+			expected: `{"string":"s","number":12345678901234567890,"bool":true,"null":null,"list":[1,"two",false]}`,
+		},
+		"invalid": {
+			output:      `{"foo":`,
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			got, err := compactResultJSON([]byte(testCase.output))
+
+			if testCase.expectError {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func TestNumericResultJSON(t *testing.T) {
+	testCases := map[string]struct {
+		output      string
+		expected    string
+		expectError bool
+	}{
+		"integer": {
+			output:   "  42\n",
+			expected: `{"count":"42"}`,
+		},
+		"decimal": {
+			output:   "-1.5",
+			expected: `{"count":"-1.5"}`,
+		},
+		"text": {
+			output:      "42 files\n",
+			expectError: true,
+		},
+		"empty": {
+			output:      "\n",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			got, err := numericResultJSON([]byte(testCase.output), "count")
+
+			if testCase.expectError {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if string(got) != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func TestResultFingerprint(t *testing.T) {
+	ctx := context.Background()
+
+	a := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"a": types.StringValue("1"),
+		"b": types.StringValue("2"),
+	})
+	b := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"b": types.StringValue("2"),
+		"a": types.StringValue("1"),
+	})
+	c := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"a": types.StringValue("1"),
+		"b": types.StringValue("3"),
+	})
+	results := types.ListNull(types.MapType{ElemType: types.StringType})
+
+	fingerprintA, diags := resultFingerprint(ctx, a, results)
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	fingerprintB, _ := resultFingerprint(ctx, b, results)
+	fingerprintC, _ := resultFingerprint(ctx, c, results)
+
+	if fingerprintA != fingerprintB {
+		t.Error("expected equal results to have equal fingerprints")
+	}
+
+	if fingerprintA == fingerprintC {
+		t.Error("expected different results to have different fingerprints")
+	}
+}
+
+func TestNonObjectOutput(t *testing.T) {
+	testCases := map[string]struct {
+		output      string
+		mode        string
+		key         string
+		expected    string
+		expectError bool
+	}{
+		"object-unchanged": {
+			output:   `{"key":"value"}`,
+			mode:     nonObjectOutputWrap,
+			expected: `{"key":"value"}`,
+		},
+		"error-unchanged": {
+			output:   `["a","b"]`,
+			mode:     nonObjectOutputError,
+			expected: `["a","b"]`,
+		},
+		"wrap-array": {
+			output:   `["a","b"]`,
+			mode:     nonObjectOutputWrap,
+			expected: `{"value":"[\"a\",\"b\"]"}`,
+		},
+		"wrap-string-key": {
+			output:   `"hello"`,
+			mode:     nonObjectOutputWrap,
+			key:      "greeting",
+			expected: `{"greeting":"hello"}`,
+		},
+		"raw": {
+			output:   `42`,
+			mode:     nonObjectOutputRaw,
+			expected: `{}`,
+		},
+		"invalid-json-unchanged": {
+			output:   `not json`,
+			mode:     nonObjectOutputWrap,
+			expected: `not json`,
+		},
+		"invalid-mode": {
+			output:      `[]`,
+			mode:        "drop",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			got, err := nonObjectOutput([]byte(testCase.output), testCase.mode, testCase.key)
+
+			if testCase.expectError {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if string(got) != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidateEcho(t *testing.T) {
+	query := map[string]string{"nonce": "ünïcode"}
+
+	testCases := map[string]struct {
+		result      map[string]interface{}
+		key         string
+		expectError bool
+	}{
+		"match": {
+			result: map[string]interface{}{"nonce": "ünïcode"},
+			key:    "nonce",
+		},
+		"mismatch": {
+			result:      map[string]interface{}{"nonce": "unicode"},
+			key:         "nonce",
+			expectError: true,
+		},
+		"missing-result": {
+			result:      map[string]interface{}{},
+			key:         "nonce",
+			expectError: true,
+		},
+		"missing-query": {
+			result:      map[string]interface{}{"other": "value"},
+			key:         "other",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			diags := validateEcho(testCase.result, query, testCase.key)
+
+			if diags.HasError() != testCase.expectError {
+				t.Errorf("expected error: %t, got: %v", testCase.expectError, diags)
+			}
+		})
+	}
+}
+
+func TestResultObject(t *testing.T) {
+	result := map[string]interface{}{
+		"name":    "example",
+		"count":   float64(3),
+		"size":    "1.5",
+		"enabled": "true",
+		"ready":   false,
+	}
+
+	testCases := map[string]struct {
+		attributes  map[string]string
+		expected    tftypes.Value
+		expectError bool
+	}{
+		"decoded": {
+			attributes: map[string]string{
+				"name":    "string",
+				"count":   "number",
+				"size":    "number",
+				"enabled": "bool",
+				"ready":   "bool",
+			},
+			expected: tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+				"name":    tftypes.String,
+				"count":   tftypes.Number,
+				"size":    tftypes.Number,
+				"enabled": tftypes.Bool,
+				"ready":   tftypes.Bool,
+			}}, map[string]tftypes.Value{
+				"name":    tftypes.NewValue(tftypes.String, "example"),
+				"count":   tftypes.NewValue(tftypes.Number, testBigFloat(t, "3")),
+				"size":    tftypes.NewValue(tftypes.Number, testBigFloat(t, "1.5")),
+				"enabled": tftypes.NewValue(tftypes.Bool, true),
+				"ready":   tftypes.NewValue(tftypes.Bool, false),
+			}),
+		},
+		"missing": {
+			attributes:  map[string]string{"other": "string"},
+			expectError: true,
+		},
+		"mismatch": {
+			attributes:  map[string]string{"name": "number"},
+			expectError: true,
+		},
+		"string-from-number": {
+			attributes:  map[string]string{"count": "string"},
+			expectError: true,
+		},
+		"invalid-type": {
+			attributes:  map[string]string{"name": "list"},
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			object, diags := resultObject(result, testCase.attributes)
+
+			if testCase.expectError {
+				if !diags.HasError() {
+					t.Fatal("expected error, got none")
+				}
+				if !object.IsNull() {
+					t.Errorf("expected null object, got %s", object)
+				}
+				return
+			}
+
+			if diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+
+			if !object.value.Equal(testCase.expected) {
+				t.Errorf("expected %s, got %s", testCase.expected, object)
+			}
+		})
+	}
+}
+
+func TestLastJSONObject(t *testing.T) {
+	testCases := map[string]struct {
+		output        string
+		expected      string
+		expectMissing bool
+	}{
+		"strict": {
+			output:   `{"a":"b"}`,
+			expected: `{"a":"b"}`,
+		},
+		"banner": {
+			output:   "Warning: this command is deprecated\n{\"a\":\"b\"}\n",
+			expected: `{"a":"b"}`,
+		},
+		"partial-object-before": {
+			output:   "{\"progress\": \n{\"a\":\"b\"}",
+			expected: `{"a":"b"}`,
+		},
+		"earlier-object": {
+			output:   "{\"log\":\"start\"}\n{\"a\":{\"nested\":\"c\"}}",
+			expected: `{"a":{"nested":"c"}}`,
+		},
+		"trailing-noise": {
+			output:        "{\"a\":\"b\"}\ndone",
+			expectMissing: true,
+		},
+		"no-object": {
+			output:        "nothing here",
+			expectMissing: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual, ok := lastJSONObject([]byte(testCase.output))
+
+			if testCase.expectMissing {
+				if ok {
+					t.Fatalf("expected no object, got %q", actual)
+				}
+				return
+			}
+
+			if !ok {
+				t.Fatal("expected object, got none")
+			}
+
+			if string(actual) != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestProgramDiagnostics(t *testing.T) {
+	testCases := map[string]struct {
+		result         map[string]interface{}
+		expectErrors   int
+		expectWarnings int
+		expectError    bool
+	}{
+		"absent": {
+			result: map[string]interface{}{"key": "value"},
+		},
+		"mixed": {
+			result: map[string]interface{}{
+				"key": "value",
+				"diagnostics": []interface{}{
+					map[string]interface{}{"severity": "warning", "summary": "Deprecated", "detail": "Use v2."},
+					map[string]interface{}{"severity": "error", "summary": "Quota Exceeded"},
+				},
+			},
+			expectErrors:   1,
+			expectWarnings: 1,
+		},
+		"not-array": {
+			result:      map[string]interface{}{"diagnostics": "oops"},
+			expectError: true,
+		},
+		"missing-summary": {
+			result: map[string]interface{}{
+				"diagnostics": []interface{}{map[string]interface{}{"severity": "warning"}},
+			},
+			expectError: true,
+		},
+		"invalid-severity": {
+			result: map[string]interface{}{
+				"diagnostics": []interface{}{map[string]interface{}{"severity": "info", "summary": "Note"}},
+			},
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			diags, err := programDiagnostics(testCase.result)
+
+			if testCase.expectError {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if _, ok := testCase.result["diagnostics"]; ok {
+				t.Error("expected diagnostics to be removed from the result")
+			}
+
+			if diags.ErrorsCount() != testCase.expectErrors || diags.WarningsCount() != testCase.expectWarnings {
+				t.Errorf("expected %d errors and %d warnings, got: %v", testCase.expectErrors, testCase.expectWarnings, diags)
+			}
+		})
+	}
+}
+
+func TestValidateProtocolVersion(t *testing.T) {
+	testCases := map[string]struct {
+		result      map[string]interface{}
+		expectError bool
+	}{
+		"equal": {
+			result: map[string]interface{}{"__protocol_version": "2"},
+		},
+		"same-major": {
+			result: map[string]interface{}{"__protocol_version": "2.3"},
+		},
+		"number": {
+			result: map[string]interface{}{"__protocol_version": float64(2)},
+		},
+		"different-major": {
+			result:      map[string]interface{}{"__protocol_version": "3.0"},
+			expectError: true,
+		},
+		"missing": {
+			result:      map[string]interface{}{},
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			diags := validateProtocolVersion(testCase.result, "2.1")
+
+			if diags.HasError() != testCase.expectError {
+				t.Errorf("expected error %t, got: %v", testCase.expectError, diags)
+			}
+		})
+	}
+}
+
+func TestMergeResultKeys(t *testing.T) {
+	prior := map[string]string{
+		"kept":      "old",
+		"refreshed": "old",
+		"removed":   "old",
+	}
+	current := map[string]interface{}{
+		"refreshed": "new",
+		"ignored":   "new",
+	}
+
+	actual := mergeResultKeys(prior, current, []string{"refreshed", "removed", "absent"})
+
+	expected := map[string]interface{}{
+		"kept":      "old",
+		"refreshed": "new",
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestDedupeResults(t *testing.T) {
+	testCases := map[string]struct {
+		results  string
+		expected string
+	}{
+		"empty": {
+			results:  `[]`,
+			expected: `[]`,
+		},
+		"no-duplicates": {
+			results:  `[{"name":"b"},{"name":"a"}]`,
+			expected: `[{"name":"b"},{"name":"a"}]`,
+		},
+		"duplicates": {
+			results:  `[{"name":"b"},{"name":"a"},{"name":"b"},{"name":"c"},{"name":"a"}]`,
+			expected: `[{"name":"b"},{"name":"a"},{"name":"c"}]`,
+		},
+		"all-duplicates": {
+			results:  `[{"name":"a","id":"1"},{"id":"1","name":"a"},{"name":"a","id":"1"}]`,
+			expected: `[{"id":"1","name":"a"}]`,
+		},
+		"partial-match": {
+			results:  `[{"name":"a","id":"1"},{"name":"a"}]`,
+			expected: `[{"id":"1","name":"a"},{"name":"a"}]`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			var results []interface{}
+			if err := json.Unmarshal([]byte(testCase.results), &results); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			deduped, err := dedupeResults(results)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			actual, err := json.Marshal(deduped)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if string(actual) != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	testCases := map[string]struct {
+		output      string
+		expected    string
+		canonical   bool
+		expectError bool
+	}{
+		"canonical": {
+			output:    `{"a":"1","b":{"c":[1,2.50,"<&>"]}}` + "\n",
+			expected:  `{"a":"1","b":{"c":[1,2.50,"<&>"]}}`,
+			canonical: true,
+		},
+		"unsorted": {
+			output:   `{"b":"2","a":"1"}`,
+			expected: `{"a":"1","b":"2"}`,
+		},
+		"whitespace": {
+			output:   "{\n  \"a\": \"1\"\n}",
+			expected: `{"a":"1"}`,
+		},
+		"escaped": {
+			output:   `{"a":"\u0041"}`,
+			expected: `{"a":"A"}`,
+		},
+		"array": {
+			output:    `[{"a":"1"},{"a":"1","b":"2"}]`,
+			expected:  `[{"a":"1"},{"a":"1","b":"2"}]`,
+			canonical: true,
+		},
+		"invalid": {
+			output:      `{"a":`,
+			expectError: true,
+		},
+		"trailing-value": {
+			output:      `{"a":"1"} {"b":"2"}`,
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			got, canonical, err := canonicalJSON([]byte(testCase.output))
+
+			if testCase.expectError {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if string(got) != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, got)
+			}
+
+			if canonical != testCase.canonical {
+				t.Errorf("expected canonical %t, got %t", testCase.canonical, canonical)
+			}
+		})
+	}
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	testCases := map[string]struct {
+		output      string
+		expected    []string
+		expectError bool
+	}{
+		"no-duplicates": {
+			output: `{"a":"1","b":"2"}`,
+		},
+		"duplicate": {
+			output:   `{"a":"1","b":"2","a":"3"}`,
+			expected: []string{"a"},
+		},
+		"repeated-duplicates": {
+			output:   `{"a":"1","b":"2","a":"3","b":"4","a":"5"}`,
+			expected: []string{"a", "b", "a"},
+		},
+		"nested-duplicate": {
+			output: `{"a":{"b":"1","b":"2"},"c":["d","d"]}`,
+		},
+		"array": {
+			output:   `[{"a":"1"},{"a":"1","a":"2"},{"b":"1","b":"2"}]`,
+			expected: []string{"a", "b"},
+		},
+		"array-across-elements": {
+			output: `[{"a":"1"},{"a":"2"}]`,
+		},
+		"scalar": {
+			output: `"a"`,
+		},
+		"invalid": {
+			output:      `{"a":"1","a"`,
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			got, err := duplicateKeys([]byte(testCase.output))
+
+			if testCase.expectError {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func TestFlattenResultArrays(t *testing.T) {
+	testCases := map[string]struct {
+		output   string
+		expected map[string]interface{}
+	}{
+		"no-arrays": {
+			output:   `{"name":"example"}`,
+			expected: map[string]interface{}{"name": "example"},
+		},
+		"strings": {
+			output: `{"name":"example","items":["a","b"]}`,
+			expected: map[string]interface{}{
+				"name":        "example",
+				"items.0":     "a",
+				"items.1":     "b",
+				"items.count": "2",
+			},
+		},
+		"empty": {
+			output:   `{"items":[]}`,
+			expected: map[string]interface{}{"items.count": "0"},
+		},
+		"scalars": {
+			output: `{"items":[1,true,null,"s"]}`,
+			expected: map[string]interface{}{
+				"items.0":     "1",
+				"items.1":     "true",
+				"items.2":     "",
+				"items.3":     "s",
+				"items.count": "4",
+			},
+		},
+		"nested": {
+			output: `{"items":[{"name":"a","tags":["x","y"],"meta":{"size":2}},[["deep"]]]}`,
+			expected: map[string]interface{}{
+				"items.0.name":       "a",
+				"items.0.tags.0":     "x",
+				"items.0.tags.1":     "y",
+				"items.0.tags.count": "2",
+				"items.0.meta.size":  "2",
+				"items.1.0.0":        "deep",
+				"items.1.0.count":    "1",
+				"items.1.count":      "1",
+				"items.count":        "2",
+			},
+		},
+		"object-not-flattened": {
+			output: `{"meta":{"items":["a"]}}`,
+			expected: map[string]interface{}{
+				"meta": map[string]interface{}{"items": []interface{}{"a"}},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			var result map[string]interface{}
+			if err := json.Unmarshal([]byte(testCase.output), &result); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			actual := flattenResultArrays(result)
+
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestChangedResultKeys(t *testing.T) {
+	snapshot := resultSnapshot(map[string]string{
+		"version":  "1",
+		"checksum": "abc",
+		"removed":  "x",
+		"ignored":  "old",
+	}, []string{"version", "checksum", "removed", "added"})
+
+	expectedSnapshot := map[string]string{"version": "1", "checksum": "abc", "removed": "x"}
+	if !reflect.DeepEqual(snapshot, expectedSnapshot) {
+		t.Fatalf("expected snapshot %v, got %v", expectedSnapshot, snapshot)
+	}
+
+	testCases := map[string]struct {
+		result   map[string]string
+		expected []string
+	}{
+		"unchanged": {
+			result: map[string]string{"version": "1", "checksum": "abc", "removed": "x", "ignored": "new"},
+		},
+		"changed": {
+			result:   map[string]string{"version": "2", "checksum": "abc", "removed": "x"},
+			expected: []string{"version"},
+		},
+		"removed-and-added": {
+			result:   map[string]string{"version": "1", "checksum": "abc", "added": "y"},
+			expected: []string{"removed", "added"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual := changedResultKeys(testCase.result, snapshot, []string{"version", "checksum", "removed", "added"})
+
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUnexpectedResultKeys(t *testing.T) {
+	testCases := map[string]struct {
+		result   map[string]interface{}
+		allowed  []string
+		expected []string
+	}{
+		"allowed": {
+			result:  map[string]interface{}{"id": "1", "name": "web"},
+			allowed: []string{"id", "name", "tags"},
+		},
+		"unexpected": {
+			result:   map[string]interface{}{"id": "1", "debug": "x", "_trace": "y"},
+			allowed:  []string{"id"},
+			expected: []string{"_trace", "debug"},
+		},
+		"empty-allowlist": {
+			result:   map[string]interface{}{"id": "1"},
+			allowed:  []string{},
+			expected: []string{"id"},
+		},
+		"empty-result": {
+			result:  map[string]interface{}{},
+			allowed: []string{"id"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual := unexpectedResultKeys(testCase.result, testCase.allowed)
+
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPartialOutput(t *testing.T) {
+	testCases := map[string]struct {
+		output        string
+		outputFormat  string
+		expected      string
+		expectMissing bool
+	}{
+		"json-stream": {
+			output:       "{\"count\":\"1\"}\n{\"count\":\"2\"}\n{\"count\":",
+			outputFormat: outputFormatJSON,
+			expected:     `{"count":"2"}`,
+		},
+		"json-complete": {
+			output:       "{\n  \"a\": \"b\"\n}\n",
+			outputFormat: outputFormatJSON,
+			expected:     "{\n  \"a\": \"b\"\n}",
+		},
+		"json-incomplete": {
+			output:        "{\"a\":",
+			outputFormat:  outputFormatJSON,
+			expectMissing: true,
+		},
+		"json-array": {
+			output:       `[{"a":"1"},{"a":"2"},{"a":`,
+			outputFormat: outputFormatJSONArray,
+			expected:     `[{"a":"1"},{"a":"2"}]`,
+		},
+		"json-array-empty": {
+			output:       `[`,
+			outputFormat: outputFormatJSONArray,
+			expected:     `[]`,
+		},
+		"json-array-missing": {
+			output:        `{"a":"1"}`,
+			outputFormat:  outputFormatJSONArray,
+			expectMissing: true,
+		},
+		"hcl": {
+			output:       "a = \"1\"\nb = \"2",
+			outputFormat: outputFormatHCL,
+			expected:     "a = \"1\"\n",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual, ok := partialOutput([]byte(testCase.output), testCase.outputFormat)
+
+			if ok == testCase.expectMissing {
+				t.Fatalf("expected ok %t, got %t", !testCase.expectMissing, ok)
+			}
+
+			if string(actual) != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	defaultRetryInterval = time.Second
+	defaultRetryBackoff  = 1.0
+
+	// maxRetryDelay caps the interval grown by the backoff, which would
+	// otherwise overflow a time.Duration after enough retries.
+	maxRetryDelay = 5 * time.Minute
+)
+
+// retryConfig is the effective retry policy for a single resource, after
+// falling back to the provider-level defaults for any unset attribute.
+type retryConfig struct {
+	retries  int64
+	interval time.Duration
+	backoff  float64
+}
+
+// newRetryConfig returns the retry policy of the plan, falling back to the
+// provider defaults for unset attributes. Invalid values were reported by
+// validateStatic and the provider Configure, so they are not checked again.
+func newRetryConfig(plan execModelV0, data *providerData) retryConfig {
+	retries := plan.Retries
+	interval := plan.RetryInterval
+	backoff := plan.RetryBackoff
+
+	if data != nil {
+		if retries.IsNull() {
+			retries = data.defaultRetries
+		}
+		if interval.IsNull() {
+			interval = data.defaultRetryInterval
+		}
+		if backoff.IsNull() {
+			backoff = data.defaultRetryBackoff
+		}
+	}
+
+	config := retryConfig{
+		interval: defaultRetryInterval,
+		backoff:  defaultRetryBackoff,
+	}
+
+	if !retries.IsNull() {
+		config.retries = retries.ValueInt64()
+	}
+
+	if d, ok := parseDurationAttribute(interval); ok && d >= 0 {
+		config.interval = d
+	}
+
+	if !backoff.IsNull() && backoff.ValueFloat64() >= 1 {
+		config.backoff = backoff.ValueFloat64()
+	}
+
+	return config
+}
+
+// validateRetry checks the retry attributes, whose names are prefixed with
+// "default_" for the provider defaults. Unknown values are not checked.
+func validateRetry(prefix string, retries types.Int64, interval types.String, backoff types.Float64) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if !retries.IsUnknown() && retries.ValueInt64() < 0 {
+		diags.AddAttributeError(path.Root(prefix+"retries"), "Invalid Retries",
+			fmt.Sprintf("The %sretries must not be negative, got: %d", prefix, retries.ValueInt64()))
+	}
+
+	if d, ok := parseDurationAttribute(interval); ok && d < 0 {
+		diags.AddAttributeError(path.Root(prefix+"retry_interval"), "Invalid Retry Interval",
+			fmt.Sprintf("The %sretry_interval must be a non-negative duration string, such as \"5s\".", prefix)+
+				fmt.Sprintf("\n\nValue: %s", interval.ValueString()))
+	}
+
+	if !backoff.IsNull() && !backoff.IsUnknown() && backoff.ValueFloat64() < 1 {
+		diags.AddAttributeError(path.Root(prefix+"retry_backoff"), "Invalid Retry Backoff",
+			fmt.Sprintf("The %sretry_backoff must be at least 1, got: %g", prefix, backoff.ValueFloat64()))
+	}
+
+	return diags
+}
+
+// delay returns the interval preceding the given retry attempt (starting at
+// 1), grown by the backoff for each earlier retry up to maxRetryDelay.
+func (c retryConfig) delay(attempt int64) time.Duration {
+	delay := float64(c.interval)
+	for i := int64(1); i < attempt && delay < float64(maxRetryDelay); i++ {
+		delay *= c.backoff
+	}
+
+	if delay > float64(maxRetryDelay) {
+		return maxRetryDelay
+	}
+
+	return time.Duration(delay)
+}
+
+// wait blocks for the delay preceding the given retry attempt, returning
+// early with the context error if it is cancelled.
+func (c retryConfig) wait(ctx context.Context, attempt int64) error {
+	return sleep(ctx, c.delay(attempt))
+}
+
+// sleep waits for the duration d, returning early with the error of the
+// context if it is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// lookPath finds the program like exec.LookPath, retrying a failed lookup up
+// to retries times at the interval, for programs that are still being built
+// or installed when the resource is created. It returns the error of the last
+// lookup when the retries are exhausted or the context is cancelled.
+func lookPath(ctx context.Context, file string, retries int64, interval time.Duration) (string, error) {
+	for attempt := int64(0); ; attempt++ {
+		path, err := exec.LookPath(file)
+		if err == nil || attempt >= retries {
+			return path, err
+		}
+
+		tflog.Debug(ctx, "Retrying external program lookup", map[string]interface{}{"program": file, "attempt": attempt + 1, "error": err.Error()})
+
+		if sleep(ctx, interval) != nil {
+			return path, err
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestLookPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires the executable bit")
+	}
+
+	name := filepath.Join(t.TempDir(), "program")
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = os.WriteFile(name, []byte("#!/bin/sh\n"), 0o700)
+	}()
+
+	path, err := lookPath(context.Background(), name, 50, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if path != name {
+		t.Errorf("expected %q, got %q", name, path)
+	}
+}
+
+func TestLookPath_Exhausted(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "missing")
+
+	if _, err := lookPath(context.Background(), name, 2, time.Millisecond); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestLookPath_Cancelled(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "missing")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+
+	if _, err := lookPath(ctx, name, 10, time.Minute); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the lookup to stop when cancelled, took %s", elapsed)
+	}
+}
+
+func TestNewRetryConfig(t *testing.T) {
+	defaults := &providerData{
+		defaultRetries:       types.Int64Value(3),
+		defaultRetryInterval: types.StringValue("5s"),
+		defaultRetryBackoff:  types.Float64Value(2),
+	}
+
+	testCases := map[string]struct {
+		attributes map[string]interface{}
+		data       *providerData
+		expected   retryConfig
+	}{
+		"unset": {
+			attributes: map[string]interface{}{},
+			expected:   retryConfig{interval: defaultRetryInterval, backoff: defaultRetryBackoff},
+		},
+		"provider-unconfigured": {
+			attributes: map[string]interface{}{},
+			data:       &providerData{},
+			expected:   retryConfig{interval: defaultRetryInterval, backoff: defaultRetryBackoff},
+		},
+		"provider-defaults": {
+			attributes: map[string]interface{}{},
+			data:       defaults,
+			expected:   retryConfig{retries: 3, interval: 5 * time.Second, backoff: 2},
+		},
+		"overrides": {
+			attributes: map[string]interface{}{"retries": 1, "retry_interval": "250ms", "retry_backoff": 1.5},
+			data:       defaults,
+			expected:   retryConfig{retries: 1, interval: 250 * time.Millisecond, backoff: 1.5},
+		},
+		"partial-override": {
+			attributes: map[string]interface{}{"retry_interval": "0s"},
+			data:       defaults,
+			expected:   retryConfig{retries: 3, interval: 0, backoff: 2},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			got := newRetryConfig(testModel(t, testCase.attributes), testCase.data)
+
+			if got != testCase.expected {
+				t.Errorf("expected %+v, got %+v", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidateRetry(t *testing.T) {
+	testCases := map[string]struct {
+		prefix       string
+		retries      types.Int64
+		interval     types.String
+		backoff      types.Float64
+		expected     string
+		expectedPath string
+	}{
+		"valid": {
+			retries:  types.Int64Value(2),
+			interval: types.StringValue("0s"),
+			backoff:  types.Float64Value(1),
+		},
+		"unset": {
+			retries:  types.Int64Null(),
+			interval: types.StringNull(),
+			backoff:  types.Float64Null(),
+		},
+		"unknown": {
+			retries:  types.Int64Unknown(),
+			interval: types.StringUnknown(),
+			backoff:  types.Float64Unknown(),
+		},
+		"negative-retries": {
+			retries:      types.Int64Value(-1),
+			interval:     types.StringNull(),
+			backoff:      types.Float64Null(),
+			expected:     "Invalid Retries",
+			expectedPath: "retries",
+		},
+		"negative-interval": {
+			retries:      types.Int64Null(),
+			interval:     types.StringValue("-1s"),
+			backoff:      types.Float64Null(),
+			expected:     "Invalid Retry Interval",
+			expectedPath: "retry_interval",
+		},
+		"invalid-interval": {
+			retries:      types.Int64Null(),
+			interval:     types.StringValue("soon"),
+			backoff:      types.Float64Null(),
+			expected:     "Invalid Retry Interval",
+			expectedPath: "retry_interval",
+		},
+		"backoff-below-one": {
+			retries:      types.Int64Null(),
+			interval:     types.StringNull(),
+			backoff:      types.Float64Value(0.5),
+			expected:     "Invalid Retry Backoff",
+			expectedPath: "retry_backoff",
+		},
+		"provider-default": {
+			prefix:       "default_",
+			retries:      types.Int64Null(),
+			interval:     types.StringValue("-5s"),
+			backoff:      types.Float64Null(),
+			expected:     "Invalid Retry Interval",
+			expectedPath: "default_retry_interval",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			diags := validateRetry(testCase.prefix, testCase.retries, testCase.interval, testCase.backoff)
+
+			if testCase.expected == "" {
+				if diags.HasError() {
+					t.Fatalf("unexpected diagnostics: %v", diags)
+				}
+				return
+			}
+
+			d := testDiagnostic(diags, testCase.expected)
+			if d == nil {
+				t.Fatalf("expected %q diagnostic, got: %v", testCase.expected, diags)
+			}
+
+			if got := d.(diag.DiagnosticWithPath).Path().String(); got != testCase.expectedPath {
+				t.Errorf("expected diagnostic on %s, got %s", testCase.expectedPath, got)
+			}
+		})
+	}
+}
+
+func TestRetryConfig_Delay(t *testing.T) {
+	testCases := map[string]struct {
+		config   retryConfig
+		expected []time.Duration
+	}{
+		"constant": {
+			config:   retryConfig{interval: time.Second, backoff: 1},
+			expected: []time.Duration{time.Second, time.Second, time.Second},
+		},
+		"backoff": {
+			config:   retryConfig{interval: time.Second, backoff: 2},
+			expected: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		"capped": {
+			config:   retryConfig{interval: time.Minute, backoff: 3},
+			expected: []time.Duration{time.Minute, 3 * time.Minute, maxRetryDelay, maxRetryDelay},
+		},
+		"interval-above-cap": {
+			config:   retryConfig{interval: time.Hour, backoff: 1},
+			expected: []time.Duration{maxRetryDelay},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			for i, expected := range testCase.expected {
+				if got := testCase.config.delay(int64(i + 1)); got != expected {
+					t.Errorf("expected delay %s before retry %d, got %s", expected, i+1, got)
+				}
+			}
+		})
+	}
+
+	// The delay stays capped, rather than overflowing, after many retries.
+	config := retryConfig{interval: time.Second, backoff: 10}
+	if got := config.delay(1000); got != maxRetryDelay {
+		t.Errorf("expected delay %s after many retries, got %s", maxRetryDelay, got)
+	}
+}
+
+func TestRun_Retries(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	// The script fails until it has been run three times in the working
+	// directory.
+	script := "n=$(cat count 2>/dev/null || echo 0)\nn=$((n+1))\necho $n > count\n" +
+		"[ $n -ge 3 ] || exit 1\nprintf '{\"runs\":\"%s\"}' $n\n"
+
+	testCases := map[string]struct {
+		attributes  map[string]interface{}
+		data        *providerData
+		expectError bool
+	}{
+		"no-retries": {
+			attributes:  map[string]interface{}{},
+			expectError: true,
+		},
+		"exhausted": {
+			attributes:  map[string]interface{}{"retries": 1, "retry_interval": "10ms"},
+			expectError: true,
+		},
+		"retried": {
+			attributes: map[string]interface{}{"retries": 2, "retry_interval": "10ms", "retry_backoff": 2},
+		},
+		"provider-defaults": {
+			attributes: map[string]interface{}{},
+			data: &providerData{
+				defaultRetries:       types.Int64Value(5),
+				defaultRetryInterval: types.StringValue("10ms"),
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			testCase.attributes["script"] = script
+			testCase.attributes["working_dir"] = t.TempDir()
+
+			r := &programResource{data: testCase.data}
+
+			state, diags := r.run(context.Background(), testModel(t, testCase.attributes), nil, phaseCreate)
+
+			if testCase.expectError {
+				if !diags.HasError() {
+					t.Fatal("expected error")
+				}
+				return
+			}
+
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			if got := state.Result.Elements()["runs"]; got != types.StringValue("3") {
+				t.Errorf("expected the third run to succeed, got %s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package provider
+
+// resourceLimits are limits applied to each process of the program. Zero
+// values are not applied.
+type resourceLimits struct {
+	// memoryBytes limits the virtual memory of each process.
+	memoryBytes int64
+
+	// cpuSeconds limits the CPU time of each process.
+	cpuSeconds int64
+}
+
+func (l resourceLimits) isZero() bool {
+	return l.memoryBytes == 0 && l.cpuSeconds == 0
+}
@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// applyResourceLimits sets the resource limits of the started command. The
+// limits are applied just after the process starts, as Go cannot set them
+// between fork and exec, so they do not cover its first instructions.
+func applyResourceLimits(cmd *exec.Cmd, limits resourceLimits) error {
+	pid := cmd.Process.Pid
+
+	if limits.memoryBytes > 0 {
+		rlimit := unix.Rlimit{Cur: uint64(limits.memoryBytes), Max: uint64(limits.memoryBytes)}
+		if err := unix.Prlimit(pid, unix.RLIMIT_AS, &rlimit, nil); err != nil {
+			return err
+		}
+	}
+
+	if limits.cpuSeconds > 0 {
+		// The soft limit sends SIGXCPU, which terminates the process unless
+		// it is handled, and the hard limit a second later sends SIGKILL.
+		rlimit := unix.Rlimit{Cur: uint64(limits.cpuSeconds), Max: uint64(limits.cpuSeconds) + 1}
+		if err := unix.Prlimit(pid, unix.RLIMIT_CPU, &rlimit, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resourceLimitExceeded reports whether err shows that the program was
+// terminated for exceeding a resource limit, and which limit it was. A
+// process exceeding its memory limit fails to allocate memory rather than
+// being signalled, so it is only suspected when the process was terminated
+// by a signal commonly caused by failed allocations.
+func resourceLimitExceeded(err error, limits resourceLimits) (string, bool) {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return "", false
+	}
+
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return "", false
+	}
+
+	if limits.cpuSeconds > 0 {
+		cpu := exitErr.UserTime() + exitErr.SystemTime()
+
+		if status.Signal() == syscall.SIGXCPU || (status.Signal() == syscall.SIGKILL && cpu.Seconds() >= float64(limits.cpuSeconds)) {
+			return "cpu_limit", true
+		}
+	}
+
+	if limits.memoryBytes > 0 {
+		switch status.Signal() {
+		case syscall.SIGSEGV, syscall.SIGABRT, syscall.SIGBUS, syscall.SIGKILL:
+			return "memory_limit", true
+		}
+	}
+
+	return "", false
+}
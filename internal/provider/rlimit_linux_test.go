@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+func TestApplyResourceLimits_CPU(t *testing.T) {
+	limits := resourceLimits{cpuSeconds: 1}
+
+	cmds := []*exec.Cmd{
+		exec.Command("sh", "-c", "while :; do :; done"),
+	}
+
+	_, err := runPipeline(cmds, pipelineOptions{
+		started: func(cmd *exec.Cmd) error {
+			return applyResourceLimits(cmd, limits)
+		},
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if limit, ok := resourceLimitExceeded(err, limits); !ok || limit != "cpu_limit" {
+		t.Errorf("expected cpu_limit to be exceeded, got: %q, %t (%s)", limit, ok, err)
+	}
+}
+
+func TestRun_ResourceLimits(t *testing.T) {
+	testCases := map[string]struct {
+		attributes map[string]interface{}
+		expected   string
+	}{
+		"cpu_limit": {
+			attributes: map[string]interface{}{
+				"script":    "while :; do :; done\n",
+				"cpu_limit": 1,
+				"timeout":   "10s",
+			},
+			expected: "cpu_limit",
+		},
+		"memory_limit": {
+			attributes: map[string]interface{}{
+				"script":       "x=$(head -c 200000000 /dev/zero | tr '\\000' a)\nprintf '{}'\n",
+				"memory_limit": 64 * 1024 * 1024,
+			},
+			expected: "memory_limit",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			_, diags := testRun(t, testCase.attributes, nil, phaseCreate)
+
+			d := testDiagnostic(diags, "Program Exceeded Resource Limit")
+			if d == nil {
+				t.Fatalf("expected Program Exceeded Resource Limit diagnostic, got: %v", diags)
+			}
+
+			if got := d.(diag.DiagnosticWithPath).Path().String(); got != testCase.expected {
+				t.Errorf("expected diagnostic for %s, got %s", testCase.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package provider
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// applyResourceLimits is not supported on platforms other than Linux.
+func applyResourceLimits(_ *exec.Cmd, _ resourceLimits) error {
+	return errors.New("resource limits are not supported on this platform")
+}
+
+// resourceLimitExceeded always reports false on platforms other than Linux.
+func resourceLimitExceeded(_ error, _ resourceLimits) (string, bool) {
+	return "", false
+}
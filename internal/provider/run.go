@@ -0,0 +1,2087 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// run executes the program for the planned resource in the lifecycle phase
+// and returns the resulting state. The prior state is given when the program
+// is re-run by an update or a refresh.
+//
+// A run has three phases: buildInput prepares the program and its input from
+// the plan, execute runs it and converts its output to JSON, unless it is a
+// dry run, and decodeResult parses that JSON into the state.
+func (r *programResource) run(ctx context.Context, plan execModelV0, prior *execModelV0, phase string) (state execModelV0, diags diag.Diagnostics) {
+	// Values unknown when the configuration was validated are only known now.
+	diags.Append(validateStatic(plan)...)
+	if diags.HasError() {
+		return
+	}
+
+	if jitter, _ := parseDurationAttribute(plan.StartupJitter); phase == phaseCreate && jitter > 0 {
+		if err := sleep(ctx, time.Duration(mathrand.Int63n(int64(jitter)+1))); err != nil {
+			diags.AddError("Startup Jitter Interrupted",
+				"The data source was cancelled while waiting to start the program, so the program was not run."+
+					fmt.Sprintf("\n\nError: %s", err))
+			return
+		}
+	}
+
+	in, d := r.buildInput(ctx, &plan, prior, phase)
+	defer in.close()
+
+	diags.Append(d...)
+	if diags.HasError() {
+		return
+	}
+
+	var out *runOutput
+
+	if plan.DryRun.ValueBool() {
+		out, d = in.dryRun(ctx, plan)
+	} else {
+		out, d = r.execute(ctx, plan, in, phase)
+	}
+
+	diags.Append(d...)
+	if diags.HasError() {
+		return
+	}
+
+	state, d = decodeResult(ctx, plan, prior, in, out)
+	diags.Append(d...)
+
+	return state, diags
+}
+
+// runInput is the program and its input, as prepared from the plan.
+type runInput struct {
+	e       *execution
+	program []string
+	pipe    [][]string
+	id      string
+
+	// transformProgram is the last stage of pipe, if set.
+	transformProgram []string
+
+	workingDir     string
+	chrootDir      string
+	outputFormat   string
+	outputEncoding string
+
+	// query is the query passed to the program, including the keys added by
+	// the provider, and queryJson the stdin object it is part of.
+	query     map[string]string
+	queryJson []byte
+	records   []map[string]string
+
+	// deadline is when the program is cancelled, or zero without a timeout.
+	deadline         time.Time
+	timeoutValue     types.String
+	timeoutAttribute string
+
+	onCancel           []string
+	exitCodeSeverity   map[int]string
+	successRegexp      *regexp.Regexp
+	errorPattern       *regexp.Regexp
+	resultFromExitCode bool
+	maxTotalBytes      int64
+	limits             resourceLimits
+
+	// cleanup removes the files created for the run, such as the script and
+	// the temporary directory.
+	cleanup []func()
+}
+
+// close removes the files created for the run.
+func (in *runInput) close() {
+	for _, cleanup := range in.cleanup {
+		cleanup()
+	}
+}
+
+// runOutput is the outcome of running the program, or of a dry run.
+type runOutput struct {
+	cmd *exec.Cmd
+
+	// output is the output of the program, as it is cached, and resultJSON
+	// the JSON the result is parsed from.
+	output     []byte
+	resultJSON []byte
+
+	exitCode int
+
+	// timedOut is set when the result is the partial output of a program
+	// stopped by its timeout.
+	timedOut bool
+
+	// unchanged is set when the prior result is kept, as the program was
+	// skipped by its guards or its output matched no_change_output.
+	unchanged bool
+	skipped   bool
+
+	fileResults map[string]string
+	globFiles   map[string]string
+
+	cacheDir, cacheKey string
+	cached             bool
+}
+
+// buildInput prepares the program and its input from the plan, setting the
+// computed attributes that are known before the program runs.
+func (r *programResource) buildInput(ctx context.Context, plan *execModelV0, prior *execModelV0, phase string) (in *runInput, diags diag.Diagnostics) {
+	in = &runInput{}
+
+	program := make([]string, 0, len(plan.Program.Elements()))
+
+	for _, programArgRaw := range plan.Program.Elements() {
+		programArg := strings.Replace(programArgRaw.String(), "\"", "", -1)
+		if programArg == "" {
+			continue
+		}
+		program = append(program, programArg)
+	}
+
+	if name := plan.ProgramEnv.ValueString(); name != "" {
+		if value := os.Getenv(name); value != "" {
+			if len(program) == 0 {
+				program = append(program, value)
+			} else {
+				program[0] = value
+			}
+		} else if len(program) == 0 {
+			diags.AddAttributeError(path.Root("program_env"), "Program Environment Variable Unset",
+				"The program_env attribute names an environment variable that is not set, or is empty, and the "+
+					"program attribute is not set to fall back to. Set the variable in the environment of Terraform, "+
+					"or set the program attribute."+
+					fmt.Sprintf("\n\nVariable: %s", name))
+			return
+		}
+	}
+
+	if !plan.Script.IsNull() {
+		interpreter := defaultInterpreter()
+		if !plan.Interpreter.IsNull() {
+			interpreter = nil
+			diags.Append(plan.Interpreter.ElementsAs(ctx, &interpreter, false)...)
+			if diags.HasError() {
+				return
+			}
+		}
+
+		if len(interpreter) == 0 || interpreter[0] == "" {
+			diags.AddAttributeError(path.Root("interpreter"), "Interpreter Missing",
+				"The script attribute is set, but there is no interpreter to run it. "+
+					"The interpreter attribute must be set on this platform."+
+					fmt.Sprintf("\n\nPlatform: %s", runtime.GOOS))
+			return
+		}
+
+		name, err := writeScript(plan.Script.ValueString())
+		if err != nil {
+			diags.AddAttributeError(path.Root("script"), "Script Creation Failed",
+				"The data source received an unexpected error while attempting to write the script to a temporary file."+
+					fmt.Sprintf("\n\nError: %s", err))
+			return
+		}
+		in.cleanup = append(in.cleanup, func() { os.Remove(name) })
+
+		program = append(append(program[:0], interpreter...), name)
+	}
+
+	if len(program) == 0 {
+		diags.AddError("External Program Missing", "The data source was configured without a program to execute. Verify the configuration contains at least one non-empty value.")
+		return
+	}
+
+	if !plan.JsonArgs.IsNull() {
+		jsonArgs := make(map[string][]string, len(plan.JsonArgs.Elements()))
+		diags.Append(plan.JsonArgs.ElementsAs(ctx, &jsonArgs, false)...)
+		if diags.HasError() {
+			return
+		}
+
+		rendered, err := renderJSONArgs(program, jsonArgs)
+		if err != nil {
+			diags.AddAttributeError(path.Root("json_args"), "JSON Argument Handling Failed",
+				"The data source received an unexpected error while attempting to encode the json_args. "+
+					"This is always a bug in the external provider code and should be reported to the provider developers."+
+					fmt.Sprintf("\n\nError: %s", err))
+			return
+		}
+
+		program = rendered
+	}
+
+	// The flag style applies to both flags and the query with query_as_args.
+	flagPrefix := defaultFlagPrefix
+	if !plan.FlagPrefix.IsNull() {
+		flagPrefix = plan.FlagPrefix.ValueString()
+	}
+
+	flagSeparator := flagSeparatorArgument
+	if !plan.FlagSeparator.IsNull() {
+		flagSeparator = plan.FlagSeparator.ValueString()
+	}
+
+	if !plan.Flags.IsNull() {
+		flags := make(map[string]string, len(plan.Flags.Elements()))
+		diags.Append(plan.Flags.ElementsAs(ctx, &flags, false)...)
+		if diags.HasError() {
+			return
+		}
+
+		program = append(program, renderFlags(flags, flagPrefix, flagSeparator)...)
+	}
+
+	queryAsArgs := queryAsArgsNone
+	if !plan.QueryAsArgs.IsNull() {
+		queryAsArgs = plan.QueryAsArgs.ValueString()
+	}
+
+	var pipe [][]string
+
+	for idx, stageRaw := range plan.Pipe.Elements() {
+		stageList, ok := stageRaw.(types.List)
+		if !ok {
+			continue
+		}
+
+		stage := make([]string, 0, len(stageList.Elements()))
+		for _, stageArgRaw := range stageList.Elements() {
+			stageArg := strings.Replace(stageArgRaw.String(), "\"", "", -1)
+			if stageArg == "" {
+				continue
+			}
+			stage = append(stage, stageArg)
+		}
+
+		if len(stage) == 0 {
+			diags.AddError("External Program Missing",
+				fmt.Sprintf("The data source was configured with an empty program at pipe stage %d. ", idx+2)+
+					"Verify the configuration contains at least one non-empty value for each stage.")
+			return
+		}
+
+		pipe = append(pipe, stage)
+	}
+
+	// The transform program is the last stage of the pipeline, so its output
+	// is the result.
+	var transformProgram []string
+	diags.Append(plan.TransformProgram.ElementsAs(ctx, &transformProgram, false)...)
+	if diags.HasError() {
+		return
+	}
+
+	if !plan.TransformProgram.IsNull() {
+		pipe = append(pipe, transformProgram)
+	}
+
+	outputFormat := plan.OutputFormat.ValueString()
+	if outputFormat == "" {
+		outputFormat = outputFormatJSON
+	}
+
+	query := make(map[string]string)
+
+	for key, val := range plan.Query.Elements() {
+		valArg := strings.Replace(val.String(), "\"", "", -1)
+
+		if plan.StrictQuery.ValueBool() {
+			if strVal, ok := val.(types.String); !ok || valArg == "" || valArg != strVal.ValueString() {
+				diags.AddAttributeError(path.Root("query").AtMapKey(key), "Query Value Not Preserved",
+					"The strict_query attribute is set, but this query value would not be passed to the program unchanged. "+
+						"Empty values are omitted from the query and double quotes are removed from values. "+
+						"Verify the value is not empty and contains no double quotes."+
+						fmt.Sprintf("\n\nKey: %s", key))
+				continue
+			}
+		}
+
+		if valArg == "" {
+			continue
+		}
+		query[key] = valArg
+	}
+
+	if diags.HasError() {
+		return
+	}
+
+	workingDir := plan.WorkingDir.ValueString()
+
+	if plan.TemplateWorkingDir.ValueBool() {
+		rendered, err := renderWorkingDirTemplate(workingDir, query)
+		if err != nil {
+			diags.AddAttributeError(path.Root("working_dir"), "Invalid Working Directory Template",
+				"The data source received an unexpected error while attempting to render the working_dir template."+
+					fmt.Sprintf("\n\nValue: %s", workingDir)+
+					fmt.Sprintf("\nError: %s", err))
+			return
+		}
+
+		workingDir = rendered
+	}
+
+	var passthroughNames []string
+	diags.Append(plan.PassthroughEnv.ElementsAs(ctx, &passthroughNames, false)...)
+	if diags.HasError() {
+		return
+	}
+
+	// baseEnv is the environment the program starts from, before the
+	// variables set by the resource: that of Terraform, or only the
+	// passthrough_env variables of it with clean_environment.
+	baseEnv := os.Environ()
+
+	if plan.CleanEnvironment.ValueBool() {
+		baseEnv = passthroughEnv(baseEnv, passthroughNames)
+	} else if len(passthroughNames) > 0 {
+		diags.AddAttributeWarning(path.Root("passthrough_env"), "Passthrough Environment Ignored",
+			"The passthrough_env attribute is set, but clean_environment is not, so the program inherits the "+
+				"whole environment of Terraform.")
+	}
+
+	if plan.ExpandEnvArgs.ValueBool() {
+		// The environment files are found relative to the working directory,
+		// so only the environment attribute is available to expand it.
+		environment := make(map[string]string, len(plan.Environment.Elements()))
+		diags.Append(plan.Environment.ElementsAs(ctx, &environment, false)...)
+		if diags.HasError() {
+			return
+		}
+
+		expandEnvironment := baseEnv
+		for key, value := range environment {
+			expandEnvironment = setEnv(expandEnvironment, key, value)
+		}
+
+		for idx := range program {
+			program[idx] = expandEnv(program[idx], expandEnvironment)
+		}
+
+		workingDir = expandEnv(workingDir, expandEnvironment)
+	}
+
+	if plan.RootRelative.ValueBool() {
+		var rootDir string
+		if r.data != nil {
+			rootDir = r.data.rootDir
+		}
+
+		if rootDir == "" {
+			diags.AddAttributeError(path.Root("root_relative"), "Root Module Directory Unknown",
+				"The root_relative attribute is set, but the root module directory could not be determined "+
+					"from the working directory of the provider.")
+			return
+		}
+
+		// Programs without a directory are found using PATH rather than
+		// relative to the working directory, so they are left unchanged.
+		if !filepath.IsAbs(program[0]) && strings.ContainsRune(filepath.ToSlash(program[0]), '/') {
+			program[0] = filepath.Join(rootDir, program[0])
+		}
+
+		if !filepath.IsAbs(workingDir) {
+			workingDir = filepath.Join(rootDir, workingDir)
+		}
+	}
+
+	// Like the id, the arguments are rendered from the configured query only.
+	switch queryAsArgs {
+	case queryAsArgsFlags:
+		program = append(program, renderFlags(query, flagPrefix, flagSeparator)...)
+	case queryAsArgsPositional:
+		program = append(program, renderPositionalArgs(query)...)
+	}
+
+	// The id is rendered from the configured query only, as injected keys
+	// such as the deadline change between runs.
+	id := defaultID
+
+	if !plan.IdTemplate.IsNull() {
+		rendered, err := renderIDTemplate(plan.IdTemplate.ValueString(), query)
+		if err != nil {
+			diags.AddAttributeError(path.Root("id_template"), "Invalid Id Template",
+				"The data source received an unexpected error while attempting to render the id template."+
+					fmt.Sprintf("\n\nError: %s", err))
+			return
+		}
+
+		id = rendered
+	}
+
+	// env is the environment of the program, which is inherited from
+	// Terraform while it is nil.
+	var env []string
+
+	if plan.CleanEnvironment.ValueBool() {
+		env = baseEnv
+	}
+
+	if plan.IncludeWorkspace.ValueBool() {
+		workspace := terraformWorkspace()
+
+		if _, ok := query[workspaceQueryKey]; !ok {
+			query[workspaceQueryKey] = workspace
+		}
+
+		if env == nil {
+			env = baseEnv
+		}
+
+		env = setEnv(env, "TF_WORKSPACE", workspace)
+	}
+
+	if plan.IncludeRunMetadata.ValueBool() {
+		if env == nil {
+			env = baseEnv
+		}
+
+		env = setEnv(env, "TF_EXTERNAL_WORKSPACE", terraformWorkspace())
+
+		if r.data != nil && r.data.runID != "" {
+			env = setEnv(env, "TF_EXTERNAL_RUN_ID", r.data.runID)
+		}
+
+		if r.data != nil && r.data.rootDir != "" {
+			env = setEnv(env, "TF_EXTERNAL_ROOT_MODULE_PATH", r.data.rootDir)
+		}
+	}
+
+	var environmentFiles []string
+	diags.Append(plan.EnvironmentFiles.ElementsAs(ctx, &environmentFiles, false)...)
+
+	environment := make(map[string]string, len(plan.Environment.Elements()))
+	diags.Append(plan.Environment.ElementsAs(ctx, &environment, false)...)
+
+	if diags.HasError() {
+		return
+	}
+
+	if env == nil && (len(environmentFiles) > 0 || len(environment) > 0) {
+		env = baseEnv
+	}
+
+	var fileVars []string
+
+	for idx, name := range environmentFiles {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(workingDir, name)
+		}
+
+		vars, err := readDotenvFile(name)
+		if err != nil {
+			diags.AddAttributeError(path.Root("environment_files").AtListIndex(idx), "Invalid Environment File",
+				"The data source received an unexpected error while attempting to read the environment file."+
+					fmt.Sprintf("\n\nFile: %s", name)+
+					fmt.Sprintf("\nError: %s", err))
+			continue
+		}
+
+		fileVars = append(fileVars, vars...)
+
+		for _, kv := range vars {
+			key, value, _ := strings.Cut(kv, "=")
+			env = setEnv(env, key, value)
+		}
+	}
+
+	if diags.HasError() {
+		return
+	}
+
+	plan.EnvironmentHash = types.StringNull()
+	if plan.ReplaceOnEnvChange.ValueBool() {
+		plan.EnvironmentHash = types.StringValue(environmentHash(fileVars, environment))
+	}
+
+	// The locale is set before the environment attribute, which may override
+	// either variable.
+	if locale := plan.Locale.ValueString(); locale != "" {
+		if env == nil {
+			env = baseEnv
+		}
+
+		env = setEnv(env, "LC_ALL", locale)
+		env = setEnv(env, "LANG", locale)
+	}
+
+	environmentKeys := make([]string, 0, len(environment))
+	for key := range environment {
+		environmentKeys = append(environmentKeys, key)
+	}
+	sort.Strings(environmentKeys)
+
+	for _, key := range environmentKeys {
+		env = setEnv(env, key, environment[key])
+	}
+
+	// The temporary directory replaces the working directory once the files
+	// relative to it have been read, and is removed when the run returns,
+	// whether the program succeeded, failed or was cancelled.
+	if plan.UseTempDir.ValueBool() {
+		tempDir, err := os.MkdirTemp("", "terraform-provider-exec-")
+		if err != nil {
+			diags.AddAttributeError(path.Root("use_temp_dir"), "Temporary Directory Creation Failed",
+				"The data source received an unexpected error while attempting to create the temporary directory."+
+					fmt.Sprintf("\n\nError: %s", err))
+			return
+		}
+		in.cleanup = append(in.cleanup, func() { os.RemoveAll(tempDir) })
+
+		// Programs relative to the working directory are still found there.
+		for _, args := range append([][]string{program}, pipe...) {
+			if filepath.IsAbs(args[0]) || !strings.ContainsRune(filepath.ToSlash(args[0]), '/') {
+				continue
+			}
+
+			if resolved, err := filepath.Abs(filepath.Join(workingDir, args[0])); err == nil {
+				args[0] = resolved
+			}
+		}
+
+		workingDir = tempDir
+
+		if _, ok := query[tempDirQueryKey]; !ok {
+			query[tempDirQueryKey] = tempDir
+		}
+
+		if env == nil {
+			env = baseEnv
+		}
+
+		env = setEnv(env, "TF_EXTERNAL_TEMP_DIR", tempDir)
+	}
+
+	if plan.Seed.IsUnknown() || plan.Seed.IsNull() {
+		plan.Seed = types.StringNull()
+
+		if plan.RandomSeed.ValueBool() {
+			seed, err := randomSeed()
+			if err != nil {
+				diags.AddError("Seed Generation Failed",
+					"The data source received an unexpected error while attempting to generate a random seed."+
+						fmt.Sprintf("\n\nError: %s", err))
+				return
+			}
+
+			plan.Seed = types.StringValue(seed)
+		}
+	}
+
+	if !plan.Seed.IsNull() {
+		if _, ok := query["seed"]; !ok {
+			query["seed"] = plan.Seed.ValueString()
+		}
+	}
+
+	// The invocation id is generated once, when the resource is created, and
+	// kept in the state for its lifetime.
+	if plan.InvocationId.IsUnknown() || plan.InvocationId.IsNull() {
+		invocationID, err := randomSeed()
+		if err != nil {
+			diags.AddError("Invocation Id Generation Failed",
+				"The data source received an unexpected error while attempting to generate the invocation id."+
+					fmt.Sprintf("\n\nError: %s", err))
+			return
+		}
+
+		plan.InvocationId = types.StringValue(invocationID)
+	}
+
+	if plan.PassInvocationId.ValueBool() {
+		if _, ok := query[invocationIDQueryKey]; !ok {
+			query[invocationIDQueryKey] = plan.InvocationId.ValueString()
+		}
+	}
+
+	// deadline is when the program is cancelled, or zero without a timeout.
+	var deadline time.Time
+
+	timeoutValue, timeoutAttribute := phaseTimeout(*plan, phase)
+
+	if timeout, ok := parseDurationAttribute(timeoutValue); ok {
+		deadline = time.Now().Add(timeout)
+
+		if plan.PassDeadline.ValueBool() {
+			if _, ok := query["deadline"]; !ok {
+				query["deadline"] = deadline.UTC().Format(time.RFC3339)
+			}
+		}
+	} else if plan.PassDeadline.ValueBool() {
+		diags.AddAttributeWarning(path.Root("pass_deadline"), "Program Deadline Not Passed",
+			"The pass_deadline attribute is set, but the timeout attribute is not, so the program has no deadline to pass.")
+	}
+
+	var pathPrepend []string
+	diags.Append(plan.PathPrepend.ElementsAs(ctx, &pathPrepend, false)...)
+	if diags.HasError() {
+		return
+	}
+
+	if len(pathPrepend) > 0 {
+		if env == nil {
+			env = baseEnv
+		}
+
+		prepended, err := prependPath(env, pathPrepend)
+		if err != nil {
+			diags.AddAttributeError(path.Root("path_prepend"), "Invalid Path Directory",
+				"The data source received an unexpected error while attempting to resolve the path_prepend directories."+
+					fmt.Sprintf("\n\nError: %s", err))
+			return
+		}
+
+		env = prepended
+
+		// exec.Command searches the PATH of Terraform rather than the program,
+		// so programs found in the prepended directories are resolved here.
+		if resolved := lookPathIn(program[0], pathPrepend); resolved != "" {
+			program[0] = resolved
+		}
+
+		for _, stage := range pipe {
+			if resolved := lookPathIn(stage[0], pathPrepend); resolved != "" {
+				stage[0] = resolved
+			}
+		}
+	}
+
+	if plan.IncludePlatform.ValueBool() {
+		if _, ok := query["os"]; !ok {
+			query["os"] = runtime.GOOS
+		}
+
+		if _, ok := query["arch"]; !ok {
+			query["arch"] = runtime.GOARCH
+		}
+	}
+
+	if !plan.ProtocolVersion.IsNull() {
+		if _, ok := query[protocolVersionKey]; !ok {
+			query[protocolVersionKey] = plan.ProtocolVersion.ValueString()
+		}
+	}
+
+	var queryEnvFile []byte
+
+	if plan.QueryEnvFile.ValueBool() {
+		content, err := formatQueryEnvFile(query)
+		if err != nil {
+			diags.AddAttributeError(path.Root("query_env_file"), "Invalid Query Environment File",
+				"The data source received an unexpected error while attempting to write the query as an environment file."+
+					fmt.Sprintf("\n\nProgram: %s", program[0])+
+					fmt.Sprintf("\nError: %s", err))
+			return
+		}
+
+		queryEnvFile = content
+	}
+
+	var secrets []byte
+
+	if !plan.Secrets.IsNull() && runtime.GOOS == "windows" {
+		diags.AddAttributeWarning(path.Root("secrets"), "Program Secrets Unsupported",
+			"The secrets attribute is set, but passing secrets on a file descriptor is not supported on Windows. "+
+				"The program will be run without its secrets."+
+				fmt.Sprintf("\n\nPlatform: %s", runtime.GOOS))
+	} else if !plan.Secrets.IsNull() {
+		secretValues := make(map[string]string, len(plan.Secrets.Elements()))
+		diags.Append(plan.Secrets.ElementsAs(ctx, &secretValues, false)...)
+		if diags.HasError() {
+			return
+		}
+
+		content, err := json.Marshal(secretValues)
+		if err != nil {
+			diags.AddError("Secrets Handling Failed",
+				"The data source received an unexpected error while attempting to encode the secrets. "+
+					"This is always a bug in the external provider code and should be reported to the provider developers."+
+					fmt.Sprintf("\n\nError: %s", err))
+			return
+		}
+
+		secrets = content
+	}
+
+	stdinObject := make(map[string]interface{}, len(query)+1)
+	for key, val := range query {
+		stdinObject[key] = val
+	}
+
+	// stdinOrder is the order keys are merged in: the configured query, the
+	// keys added by the provider, then the previous result.
+	stdinOrder := make([]string, 0, len(plan.Query.Elements())+len(injectedQueryKeys)+1)
+	for key := range plan.Query.Elements() {
+		stdinOrder = append(stdinOrder, key)
+	}
+	sort.Strings(stdinOrder)
+	stdinOrder = append(stdinOrder, injectedQueryKeys...)
+
+	// The previous result and exit code are only passed when the program is
+	// re-run by an update, not when an ephemeral resource is refreshed.
+	if phase == phaseUpdate && prior != nil {
+		if !prior.Result.IsNull() {
+			previousResult := make(map[string]string, len(prior.Result.Elements()))
+			diags.Append(prior.Result.ElementsAs(ctx, &previousResult, false)...)
+			if diags.HasError() {
+				return
+			}
+
+			previousResultKey := previousKey(plan.PreviousResultKey, defaultPreviousResultKey)
+			stdinObject[previousResultKey] = previousResult
+			stdinOrder = append(stdinOrder, previousResultKey)
+		}
+
+		if !prior.ExitCode.IsNull() && !prior.ExitCode.IsUnknown() {
+			previousExitCodeKey := previousKey(plan.PreviousExitCodeKey, defaultPreviousExitCodeKey)
+			stdinObject[previousExitCodeKey] = prior.ExitCode.ValueInt64()
+			stdinOrder = append(stdinOrder, previousExitCodeKey)
+		}
+	}
+
+	sortStdinKeys := plan.SortStdinKeys.IsNull() || plan.SortStdinKeys.ValueBool()
+
+	queryJson, err := marshalStdin(stdinObject, stdinOrder, sortStdinKeys, plan.PrettyStdin.ValueBool())
+	if err != nil {
+		diags.AddError("Query Handling Failed", "The data source received an unexpected error while attempting to parse the query. "+
+			"This is always a bug in the external provider code and should be reported to the provider developers.")
+		return
+	}
+
+	stdin := queryJson
+
+	if !plan.StdinTemplate.IsNull() {
+		stdin, err = renderStdinTemplate(plan.StdinTemplate.ValueString(), query)
+		if err != nil {
+			diags.AddAttributeError(path.Root("stdin_template"), "Stdin Template Rendering Failed",
+				"The data source received an unexpected error while attempting to render the stdin_template."+
+					fmt.Sprintf("\n\nError: %s", err))
+			return
+		}
+	}
+
+	// The records replace the query object, fanning them in to a single run.
+	var records []map[string]string
+	if !plan.QueryRecords.IsNull() {
+		if outputFormat != outputFormatJSONArray || !plan.StdinTemplate.IsNull() {
+			diags.AddAttributeError(path.Root("query_records"), "Invalid Query Records",
+				fmt.Sprintf("The query_records attribute can only be used when output_format is %q, ", outputFormatJSONArray)+
+					"and cannot be combined with stdin_template.")
+			return
+		}
+
+		diags.Append(plan.QueryRecords.ElementsAs(ctx, &records, false)...)
+		if diags.HasError() {
+			return
+		}
+
+		stdin, err = marshalRecords(records)
+		if err != nil {
+			diags.AddError("Query Records Handling Failed",
+				"The data source received an unexpected error while attempting to encode the query_records. "+
+					"This is always a bug in the external provider code and should be reported to the provider developers."+
+					fmt.Sprintf("\n\nError: %s", err))
+			return
+		}
+	}
+
+	stdinEncoding := textEncodingUTF8
+	if !plan.StdinEncoding.IsNull() {
+		stdinEncoding = plan.StdinEncoding.ValueString()
+	}
+
+	outputEncoding := textEncodingUTF8
+	if !plan.OutputEncoding.IsNull() {
+		outputEncoding = plan.OutputEncoding.ValueString()
+	}
+
+	stdin, err = encodeText(stdin, stdinEncoding)
+	if err != nil {
+		diags.AddAttributeError(path.Root("stdin_encoding"), "Stdin Encoding Failed",
+			"The data source received an unexpected error while attempting to encode the program input."+
+				fmt.Sprintf("\n\nEncoding: %s", stdinEncoding)+
+				fmt.Sprintf("\nError: %s", err))
+		return
+	}
+
+	chrootDir := plan.ChrootDir.ValueString()
+	if chrootDir != "" && runtime.GOOS != "linux" {
+		diags.AddWarning("Program Chroot Unsupported",
+			"The chroot_dir attribute is set, but programs can only be jailed on Linux. "+
+				"The program will run without a chroot."+
+				fmt.Sprintf("\n\nPlatform: %s", runtime.GOOS))
+		chrootDir = ""
+	}
+
+	networkNamespace := plan.NetworkNamespace.ValueString()
+	if networkNamespace != "" && runtime.GOOS != "linux" {
+		diags.AddWarning("Program Network Namespace Unsupported",
+			"The network_namespace attribute is set, but network namespaces are only supported on Linux. "+
+				"The program will run in the network namespace of Terraform."+
+				fmt.Sprintf("\n\nPlatform: %s", runtime.GOOS))
+		networkNamespace = ""
+	}
+
+	if networkNamespace != "" && chrootDir != "" {
+		diags.AddAttributeError(path.Root("network_namespace"), "Conflicting Network Namespace",
+			"The network_namespace attribute cannot be combined with chroot_dir, as nsenter and the namespace "+
+				"would have to be reachable inside the jail.")
+		return
+	}
+
+	pty := plan.Pty.ValueBool()
+	if pty && runtime.GOOS != "linux" {
+		diags.AddWarning("Program Pseudo-Terminal Unsupported",
+			"The pty attribute is set, but pseudo-terminals are only supported on Linux. "+
+				"The output of the program will be captured through a pipe."+
+				fmt.Sprintf("\n\nPlatform: %s", runtime.GOOS))
+		pty = false
+	}
+
+	// The login shell runs the program from a single -c argument, which
+	// redaction cannot see into, so the program itself is recorded.
+	var recordedProgram []string
+
+	if plan.LoginShell.ValueBool() {
+		if runtime.GOOS == "windows" {
+			diags.AddWarning("Login Shell Unsupported",
+				"The login_shell attribute is set, but login shells are not supported on Windows. "+
+					"The program will be run directly.")
+		} else {
+			recordedProgram = program
+			program = loginShellCommand(program)
+
+			for idx, stage := range pipe {
+				pipe[idx] = loginShellCommand(stage)
+			}
+		}
+	}
+
+	// first element is assumed to be an executable command, possibly found
+	// using the PATH environment variable. Inside a chroot the program is
+	// instead resolved relative to the new root directory when it starts.
+	lookupRetries := plan.LookupRetries.ValueInt64()
+	if lookupRetries < 0 {
+		diags.AddAttributeError(path.Root("lookup_retries"), "Invalid Lookup Retries",
+			fmt.Sprintf("The lookup_retries attribute must not be negative, got: %d", lookupRetries))
+		return
+	}
+
+	lookupRetryInterval := defaultRetryInterval
+	if d, ok := parseDurationAttribute(plan.LookupRetryInterval); ok {
+		lookupRetryInterval = d
+	}
+
+	programPath := filepath.Join(chrootDir, program[0])
+	if chrootDir == "" {
+		programPath, err = lookPath(ctx, program[0], lookupRetries, lookupRetryInterval)
+	}
+
+	if err != nil {
+		diags.AddError("External Program Lookup Failed",
+			`The data source received an unexpected error while attempting to find the program.
+
+The program must be accessible according to the platform where Terraform is running.
+
+If the expected program should be automatically found on the platform where Terraform is running, ensure that the program is in an expected directory. On Unix-based platforms, these directories are typically searched based on the '$PATH' environment variable. On Windows-based platforms, these directories are typically searched based on the '%PATH%' environment variable.
+
+If the expected program is relative to the Terraform configuration, it is recommended that the program name includes the interpolated value of 'path.module' before the program name to ensure that it is compatible with varying module usage. For example: "${path.module}/my-program"
+
+The program must also be executable according to the platform where Terraform is running. On Unix-based platforms, the file on the filesystem must have the executable bit set. On Windows-based platforms, no action is typically necessary.
+`+
+				fmt.Sprintf("\nPlatform: %s", runtime.GOOS)+
+				fmt.Sprintf("\nProgram: %s", program[0])+
+				fmt.Sprintf("\nError: %s", err))
+
+		return
+	}
+
+	if expected := plan.ProgramSha256.ValueString(); expected != "" {
+		sum, err := fileSHA256(programPath)
+		if err != nil {
+			diags.AddAttributeError(path.Root("program_sha256"), "Program Checksum Failed",
+				"The data source received an unexpected error while attempting to compute the checksum of the program."+
+					fmt.Sprintf("\n\nProgram: %s", programPath)+
+					fmt.Sprintf("\nError: %s", err))
+			return
+		}
+
+		if !strings.EqualFold(sum, expected) {
+			diags.AddAttributeError(path.Root("program_sha256"), "Program Checksum Mismatch",
+				"The SHA-256 checksum of the program does not match the program_sha256 attribute. "+
+					"Verify the expected program is installed, or update the program_sha256 attribute."+
+					fmt.Sprintf("\n\nProgram: %s", programPath)+
+					fmt.Sprintf("\nExpected: %s", expected)+
+					fmt.Sprintf("\nActual: %s", sum))
+			return
+		}
+	}
+
+	if owner := plan.RequireOwner.ValueString(); owner != "" {
+		if runtime.GOOS == "windows" {
+			diags.AddAttributeWarning(path.Root("require_owner"), "Program Owner Check Unsupported",
+				"The require_owner attribute is set, but file ownership cannot be checked on Windows. "+
+					"The program will be run without checking its owner.")
+		} else if err := checkFileOwner(programPath, owner); err != nil {
+			diags.AddAttributeError(path.Root("require_owner"), "External Program Owner Check Failed",
+				"The program is not owned by the user set by the require_owner attribute, or its owner could not be determined. "+
+					"Verify the program has not been replaced, or correct the require_owner attribute."+
+					fmt.Sprintf("\n\nProgram: %s", programPath)+
+					fmt.Sprintf("\nError: %s", err))
+			return
+		}
+	}
+
+	// Signals that cannot be parsed were reported by validateStatic, and are
+	// only accepted on Windows, where the program is killed instead.
+	var interruptSignal os.Signal
+
+	if name := plan.InterruptSignal.ValueString(); name != "" {
+		interruptSignal, _ = parseSignal(name)
+	}
+
+	retry := newRetryConfig(*plan, r.data)
+
+	heartbeatTimeout, _ := parseDurationAttribute(plan.HeartbeatTimeout)
+	heartbeatInterval, _ := parseDurationAttribute(plan.HeartbeatInterval)
+
+	if heartbeatTimeout > 0 && heartbeatInterval == 0 {
+		heartbeatInterval = heartbeatTimeout / 4
+	}
+
+	resultStream := streamStdout
+	if !plan.ResultStream.IsNull() {
+		resultStream = plan.ResultStream.ValueString()
+	}
+
+	var logStreams map[string]bool
+
+	if !plan.LogStreams.IsNull() {
+		var streams []string
+		diags.Append(plan.LogStreams.ElementsAs(ctx, &streams, false)...)
+		if diags.HasError() {
+			return
+		}
+
+		logStreams = make(map[string]bool, len(streams))
+
+		for _, stream := range streams {
+			logStreams[stream] = true
+		}
+
+		if !plan.StreamLogs.ValueBool() {
+			diags.AddAttributeWarning(path.Root("log_streams"), "Program Output Not Logged",
+				"The log_streams attribute is set, but stream_logs is not, so the output of the program is not logged.")
+		}
+	}
+
+	if heartbeatTimeout > 0 && !plan.StreamLogs.ValueBool() {
+		diags.AddAttributeWarning(path.Root("heartbeat_timeout"), "Program Heartbeat Not Checked",
+			"The heartbeat_timeout attribute is set, but stream_logs is not, so the output of the program is not "+
+				"watched as it is written and a stalled program is not detected.")
+		heartbeatTimeout = 0
+	}
+
+	maxTotalBytes := plan.MaxTotalBytes.ValueInt64()
+	if !plan.MaxTotalBytes.IsNull() && maxTotalBytes < 0 {
+		diags.AddAttributeError(path.Root("max_total_bytes"), "Invalid Output Limit",
+			fmt.Sprintf("The max_total_bytes attribute must not be negative, got: %d", maxTotalBytes))
+		return
+	}
+
+	limits := resourceLimits{
+		memoryBytes: plan.MemoryLimit.ValueInt64(),
+		cpuSeconds:  plan.CpuLimit.ValueInt64(),
+	}
+
+	if !limits.isZero() && runtime.GOOS != "linux" {
+		diags.AddWarning("Program Resource Limits Unsupported",
+			"The memory_limit or cpu_limit attribute is set, but resource limits are only supported on Linux. "+
+				"The program will run without them."+
+				fmt.Sprintf("\n\nPlatform: %s", runtime.GOOS))
+		limits = resourceLimits{}
+	}
+
+	exitCodeSeverity, d := parseExitCodeSeverity(ctx, plan.ExitCodeSeverity)
+	diags.Append(d...)
+	if diags.HasError() {
+		return
+	}
+
+	var onCancel []string
+	diags.Append(plan.OnCancel.ElementsAs(ctx, &onCancel, false)...)
+	if diags.HasError() {
+		return
+	}
+
+	if !plan.OnCancel.IsNull() && len(onCancel) == 0 {
+		diags.AddAttributeError(path.Root("on_cancel"), "Invalid On Cancel",
+			"The on_cancel attribute must contain at least the program to run.")
+		return
+	}
+
+	e := &execution{
+		program:              program,
+		recordedProgram:      recordedProgram,
+		pipe:                 pipe,
+		dir:                  workingDir,
+		env:                  env,
+		stdin:                stdin,
+		queryEnvFile:         queryEnvFile,
+		secrets:              secrets,
+		chrootDir:            chrootDir,
+		pty:                  pty,
+		networkNamespace:     networkNamespace,
+		limits:               limits,
+		outputEncoding:       outputEncoding,
+		streamLogs:           plan.StreamLogs.ValueBool(),
+		logStreams:           logStreams,
+		resultStream:         resultStream,
+		maxLineBytes:         plan.MaxLineBytes.ValueInt64(),
+		heartbeatTimeout:     heartbeatTimeout,
+		heartbeatInterval:    heartbeatInterval,
+		stripANSI:            plan.StripAnsi.ValueBool(),
+		normalizeLineEndings: plan.NormalizeLineEndings.ValueBool(),
+		interruptSignal:      interruptSignal,
+		retry:                retry,
+		maxTotalBytes:        -1,
+		logCommand:           r.data.logsCommand(),
+		logOutput:            r.data.logsOutput(),
+		audit:                r.data.audit(),
+	}
+
+	if !plan.MaxTotalBytes.IsNull() {
+		e.maxTotalBytes = maxTotalBytes
+	}
+
+	resultFromExitCode := plan.ResultFromExitCode.ValueBool()
+
+	// Expressions that do not compile were reported by validateStatic.
+	var successRegexp *regexp.Regexp
+	if !plan.SuccessRegexp.IsNull() {
+		successRegexp = regexp.MustCompile(plan.SuccessRegexp.ValueString())
+	}
+
+	var errorPattern *regexp.Regexp
+	if !plan.ErrorPattern.IsNull() {
+		errorPattern = regexp.MustCompile(plan.ErrorPattern.ValueString())
+	}
+
+	e.acceptExitCode = func(code int, output []byte) bool {
+		if severity, ok := exitCodeSeverity[code]; ok {
+			return severity != exitCodeSeverityError
+		}
+
+		return resultFromExitCode || (successRegexp != nil && successRegexp.Match(output))
+	}
+
+	in.e = e
+	in.program = program
+	in.pipe = pipe
+	in.id = id
+	in.transformProgram = transformProgram
+	in.workingDir = workingDir
+	in.chrootDir = chrootDir
+	in.outputFormat = outputFormat
+	in.outputEncoding = outputEncoding
+	in.query = query
+	in.queryJson = queryJson
+	in.records = records
+	in.deadline = deadline
+	in.timeoutValue = timeoutValue
+	in.timeoutAttribute = timeoutAttribute
+	in.onCancel = onCancel
+	in.exitCodeSeverity = exitCodeSeverity
+	in.successRegexp = successRegexp
+	in.errorPattern = errorPattern
+	in.resultFromExitCode = resultFromExitCode
+	in.maxTotalBytes = maxTotalBytes
+	in.limits = limits
+
+	return in, diags
+}
+
+// dryRun returns the dry_run_result as the output of the program, which is
+// not run.
+func (in *runInput) dryRun(ctx context.Context, plan execModelV0) (*runOutput, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	out := &runOutput{cmd: exec.Command(in.program[0], in.program[1:]...)}
+
+	output, err := dryRunOutput(ctx, plan.DryRunResult, in.outputFormat)
+	if err != nil {
+		diags.AddAttributeError(path.Root("dry_run_result"), "Dry Run Result Handling Failed",
+			"The data source received an unexpected error while attempting to encode the dry_run_result. "+
+				"This is always a bug in the external provider code and should be reported to the provider developers."+
+				fmt.Sprintf("\n\nError: %s", err))
+		return nil, diags
+	}
+
+	out.output, out.resultJSON = output, output
+
+	tflog.Debug(ctx, "Skipped executing external program for dry run", map[string]interface{}{"program": out.cmd.String()})
+
+	return out, diags
+}
+
+// execute runs the program, unless its output is cached or its guards skip
+// it, and converts its output to the JSON the result is parsed from.
+func (r *programResource) execute(ctx context.Context, plan execModelV0, in *runInput, phase string) (out *runOutput, diags diag.Diagnostics) {
+	var err error
+
+	var cacheDir, cacheKey string
+	var cached bool
+
+	if plan.Cache.ValueBool() {
+		if r.data != nil {
+			cacheDir = r.data.cacheDir
+		}
+
+		if cacheDir == "" {
+			diags.AddAttributeWarning(path.Root("cache"), "Program Cache Not Configured",
+				"The cache attribute is set, but the provider cache_dir attribute is not. "+
+					"The program will be run without caching its output.")
+		} else if cacheKey, err = in.e.cacheKey(); err != nil {
+			diags.AddError("Program Cache Handling Failed",
+				"The data source received an unexpected error while attempting to compute the cache key. "+
+					"This is always a bug in the external provider code and should be reported to the provider developers."+
+					fmt.Sprintf("\n\nError: %s", err))
+			return
+		}
+	}
+
+	var cmd *exec.Cmd
+	var resultJson []byte
+	var programExitCode int
+
+	// timedOut is set when the result is the partial output of a program
+	// stopped by its timeout.
+	var timedOut bool
+
+	if cacheKey != "" {
+		resultJson, programExitCode, cached, err = readCache(cacheDir, cacheKey)
+		if err != nil {
+			diags.AddWarning("Program Cache Read Failed",
+				"The data source received an unexpected error while attempting to read the cached program output. "+
+					"The program will be run instead."+
+					fmt.Sprintf("\n\nCache Directory: %s", cacheDir)+
+					fmt.Sprintf("\nError: %s", err))
+		}
+	}
+
+	// Guards decide whether the program runs, so they are skipped along with
+	// it for cached results.
+	skipped := false
+
+	if !cached && (!plan.OnlyIf.IsNull() || !plan.Unless.IsNull()) {
+		guardCtx := ctx
+
+		if !in.deadline.IsZero() {
+			var cancel context.CancelFunc
+			guardCtx, cancel = context.WithDeadline(ctx, in.deadline)
+			defer cancel()
+		}
+
+		var guardStdin []byte
+		if plan.GuardStdin.ValueBool() {
+			guardStdin = in.queryJson
+		}
+
+		var d diag.Diagnostics
+		skipped, d = runGuards(guardCtx, plan.OnlyIf, plan.Unless, in.e, guardStdin)
+		diags.Append(d...)
+		if diags.HasError() {
+			return
+		}
+	}
+
+	if cached {
+		cmd = exec.Command(in.program[0], in.program[1:]...)
+		err = nil
+
+		tflog.Debug(ctx, "Using cached external program output", map[string]interface{}{"program": cmd.String(), "cache_key": cacheKey})
+	} else if skipped {
+		cmd = exec.Command(in.program[0], in.program[1:]...)
+
+		tflog.Debug(ctx, "Skipped executing external program due to its guards", map[string]interface{}{"program": cmd.String()})
+	} else {
+		runCtx := ctx
+
+		if !in.deadline.IsZero() {
+			var cancel context.CancelFunc
+			runCtx, cancel = context.WithDeadline(ctx, in.deadline)
+			defer cancel()
+		}
+
+		if !plan.SelfTest.IsNull() {
+			diags.Append(r.selfTest(runCtx, plan.SelfTest, in.e)...)
+			if diags.HasError() {
+				return
+			}
+		}
+
+		for _, logFile := range []struct {
+			attribute string
+			name      types.String
+			writer    *io.Writer
+		}{
+			{"stdout_log_file", plan.StdoutLogFile, &in.e.stdoutLog},
+			{"stderr_log_file", plan.StderrLogFile, &in.e.stderrLog},
+		} {
+			if logFile.name.IsNull() {
+				continue
+			}
+
+			name := logFile.name.ValueString()
+			if !filepath.IsAbs(name) {
+				name = filepath.Join(in.workingDir, name)
+			}
+
+			// Appending lets both logs share a file without overwriting each other.
+			f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0o666)
+			if err != nil {
+				diags.AddAttributeError(path.Root(logFile.attribute), "Log File Creation Failed",
+					"The data source received an unexpected error while attempting to create the log file."+
+						fmt.Sprintf("\n\nFile: %s", name)+
+						fmt.Sprintf("\nError: %s", err))
+				return
+			}
+			defer f.Close()
+
+			*logFile.writer = f
+		}
+
+		start := time.Now()
+		resultJson, cmd, err = in.e.run(runCtx)
+
+		// The cleanup is best effort, so its failure is only logged.
+		if len(in.onCancel) > 0 && err != nil && runCtx.Err() != nil {
+			cancelStdin, cancelErr := cancelInput(in.query, resultJson)
+			if cancelErr == nil {
+				cancelErr = runCancelProgram(in.onCancel, in.e.dir, in.e.env, cancelStdin, r.data.audit())
+			}
+
+			if cancelErr != nil {
+				tflog.Warn(ctx, "Failed to run on_cancel program", map[string]interface{}{"program": strings.Join(in.onCancel, " "), "error": cancelErr.Error()})
+			} else {
+				tflog.Debug(ctx, "Ran on_cancel program", map[string]interface{}{"program": strings.Join(in.onCancel, " ")})
+			}
+		}
+
+		if metricsErr := r.data.metrics().record(newMetricsRecord(phase, in.e.recordedArgs(), start, err, in.e.retries, resultJson)); metricsErr != nil {
+			tflog.Warn(ctx, "Failed to record external program metrics", map[string]interface{}{"error": metricsErr.Error()})
+		}
+
+		if err != nil && ctx.Err() == nil && runCtx.Err() == context.DeadlineExceeded && plan.PartialOnTimeout.ValueBool() {
+			if partial, ok := partialOutput(resultJson, in.outputFormat); ok {
+				diags.AddAttributeWarning(path.Root("partial_on_timeout"), "External Program Timed Out",
+					"The program did not complete before the timeout and was stopped. The complete part of its output "+
+						"is used as the result, as partial_on_timeout is set, so the result may be incomplete."+
+						fmt.Sprintf("\n\nProgram: %s", cmd.Path)+
+						fmt.Sprintf("\nTimeout: %s", in.timeoutValue.ValueString()))
+
+				resultJson, err, timedOut = partial, nil, true
+			}
+		}
+
+		if err != nil && ctx.Err() == nil && runCtx.Err() == context.DeadlineExceeded {
+			diags.AddAttributeError(path.Root(in.timeoutAttribute), "External Program Timed Out",
+				"The program did not complete before the timeout and was stopped."+
+					fmt.Sprintf("\n\nProgram: %s", cmd.Path)+
+					fmt.Sprintf("\nTimeout: %s", in.timeoutValue.ValueString()))
+			return
+		}
+	}
+
+	if err == errOutputLimitExceeded {
+		diags.AddError("Program Output Limit Exceeded",
+			"The program was stopped because its output and error output exceeded the limit set by max_total_bytes."+
+				fmt.Sprintf("\n\nProgram: %s", cmd.Path)+
+				fmt.Sprintf("\nLimit: %d bytes", in.maxTotalBytes))
+		return
+	}
+
+	if err == errLineTooLong {
+		diags.AddAttributeError(path.Root("max_line_bytes"), "Output Line Too Long",
+			"The program was stopped because it wrote a line longer than the limit set by max_line_bytes."+
+				fmt.Sprintf("\n\nProgram: %s", cmd.Path)+
+				fmt.Sprintf("\nLimit: %d bytes", plan.MaxLineBytes.ValueInt64()))
+		return
+	}
+
+	if err == errProgramStalled {
+		diags.AddAttributeError(path.Root("heartbeat_timeout"), "Program Stalled",
+			"The program was stopped because it wrote no output for longer than the heartbeat_timeout."+
+				fmt.Sprintf("\n\nProgram: %s", cmd.Path)+
+				fmt.Sprintf("\nHeartbeat Timeout: %s", plan.HeartbeatTimeout.ValueString()))
+		return
+	}
+
+	var decodeErr *outputDecodeError
+	if errors.As(err, &decodeErr) {
+		diags.AddAttributeError(path.Root("output_encoding"), "Output Decoding Failed",
+			"The data source received an unexpected error while attempting to decode the program output."+
+				fmt.Sprintf("\n\nProgram: %s", cmd.Path)+
+				fmt.Sprintf("\nEncoding: %s", in.outputEncoding)+
+				fmt.Sprintf("\nError: %s", decodeErr.err))
+		return
+	}
+
+	if code, ok := exitCode(err); ok {
+		programExitCode = code
+
+		switch in.exitCodeSeverity[code] {
+		case exitCodeSeverityWarning:
+			var stderr string
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+				stderr = fmt.Sprintf("\nError Message: %s", string(exitErr.Stderr))
+			}
+
+			diags.AddWarning("External Program Exited With Warning",
+				"The program exited with a code that exit_code_severity maps to a warning. Its output is used as the result."+
+					fmt.Sprintf("\n\nProgram: %s", cmd.Path)+
+					fmt.Sprintf("\nExit Code: %d", code)+
+					stderr)
+			err = nil
+		case exitCodeSeverityIgnore:
+			err = nil
+		case "":
+			if in.resultFromExitCode {
+				err = nil
+			}
+		}
+	}
+
+	// Output matching success_regexp is the result, whatever the exit code.
+	var successResult map[string]string
+	if in.successRegexp != nil {
+		var matched bool
+		if successResult, matched = successRegexpResult(in.successRegexp, resultJson); matched {
+			if code, ok := exitCode(err); ok && in.exitCodeSeverity[code] != exitCodeSeverityError {
+				err = nil
+			}
+		}
+	}
+
+	if message, detail, ok := programReturnedError(resultJson, plan.ErrorKey.ValueString(), plan.ErrorDetailKey.ValueString()); ok && successResult == nil {
+		if detail != "" {
+			detail = "\n\n" + detail
+		}
+
+		diags.AddError("External Program Returned Error",
+			message+detail+
+				fmt.Sprintf("\n\nProgram: %s", cmd.Path))
+		return
+	}
+
+	if limit, ok := resourceLimitExceeded(err, in.limits); ok && ctx.Err() == nil {
+		diags.AddAttributeError(path.Root(limit), "Program Exceeded Resource Limit",
+			"The program was terminated, most likely for exceeding the resource limit set by the "+limit+" attribute."+
+				fmt.Sprintf("\n\nProgram: %s", cmd.Path)+
+				fmt.Sprintf("\nState: %s", err))
+		return
+	}
+
+	if err != nil {
+		var stage string
+
+		if pipeErr, ok := err.(*pipelineError); ok {
+			cmd, err = pipeErr.cmd, pipeErr.err
+
+			if len(in.pipe) > 0 {
+				stage = fmt.Sprintf("\nStage: %d of %d", pipeErr.stage+1, len(in.pipe)+1)
+			}
+
+			if len(in.transformProgram) > 0 && pipeErr.stage == len(in.pipe) {
+				stage += " (transform_program)"
+			}
+		}
+
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if match, ok := matchErrorPattern(in.errorPattern, exitErr.Stderr); ok {
+				summary := "External Program Execution Failed"
+				if match.summary != "" {
+					summary = match.summary
+				}
+
+				var fields string
+				for _, field := range match.fields {
+					fields += "\n" + field
+				}
+
+				diags.AddError(summary,
+					match.message+
+						fmt.Sprintf("\n\nProgram: %s", cmd.Path)+stage+fields+
+						fmt.Sprintf("\nState: %s", err))
+				return
+			}
+
+			if exitErr.Stderr != nil && len(exitErr.Stderr) > 0 {
+				diags.AddError("External Program Execution Failed",
+					"The data source received an unexpected error while attempting to execute the program."+
+						fmt.Sprintf("\n\nProgram: %s", cmd.Path)+stage+
+						fmt.Sprintf("\nError Message: %s", string(exitErr.Stderr))+
+						fmt.Sprintf("\nState: %s", err))
+				return
+			}
+
+			diags.AddError("External Program Execution Failed",
+				"The data source received an unexpected error while attempting to execute the program.\n\n"+
+					"The program was executed, however it returned no additional error messaging."+
+					fmt.Sprintf("\n\nProgram: %s", cmd.Path)+stage+
+					fmt.Sprintf("\nState: %s", err))
+			return
+		}
+
+		diags.AddError("External Program Execution Failed",
+			"The data source received an unexpected error while attempting to execute the program."+
+				fmt.Sprintf("\n\nProgram: %s", cmd.Path)+stage+
+				fmt.Sprintf("\nError: %s", err))
+		return
+	}
+
+	out = &runOutput{
+		cmd:      cmd,
+		output:   resultJson,
+		exitCode: programExitCode,
+		timedOut: timedOut,
+		skipped:  skipped,
+		cacheDir: cacheDir,
+		cacheKey: cacheKey,
+		cached:   cached,
+	}
+
+	// Output matching the no-change sentinel, or a program skipped by its
+	// guards, keeps the prior result.
+	if sentinel := plan.NoChangeOutput; skipped || (!sentinel.IsNull() &&
+		strings.TrimSpace(string(resultJson)) == sentinel.ValueString()) {
+		out.unchanged = true
+
+		return out, diags
+	}
+
+	out.resultJSON = resultJson
+
+	diags.Append(convertOutput(ctx, plan, in, out, successResult)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	return out, diags
+}
+
+// convertOutput converts the output of the program to the JSON the result is
+// parsed from, and reads the output files written alongside it.
+func convertOutput(ctx context.Context, plan execModelV0, in *runInput, out *runOutput, successResult map[string]string) (diags diag.Diagnostics) {
+	var err error
+
+	if !plan.OutputFiles.IsNull() {
+		outputFiles := make(map[string]string, len(plan.OutputFiles.Elements()))
+		diags.Append(plan.OutputFiles.ElementsAs(ctx, &outputFiles, false)...)
+		if diags.HasError() {
+			return
+		}
+
+		dir := in.workingDir
+		if in.chrootDir != "" {
+			dir = filepath.Join(in.chrootDir, dir)
+		}
+
+		var d diag.Diagnostics
+		out.fileResults, d = readOutputFiles(dir, outputFiles)
+		diags.Append(d...)
+		if diags.HasError() {
+			return
+		}
+	}
+
+	if !plan.OutputGlob.IsNull() {
+		dir := in.workingDir
+		if in.chrootDir != "" {
+			dir = filepath.Join(in.chrootDir, dir)
+		}
+
+		globFiles, d := readOutputGlob(dir, plan.OutputGlob.ValueString(), plan.OutputGlobSha256.ValueBool())
+		diags.Append(d...)
+		if diags.HasError() {
+			return
+		}
+
+		out.globFiles = globFiles
+	}
+
+	if plan.RequireCanonicalOutput.ValueBool() {
+		if in.outputFormat == outputFormatHCL {
+			diags.AddAttributeError(path.Root("require_canonical_output"), "Invalid Require Canonical Output",
+				fmt.Sprintf("The require_canonical_output attribute can only be used when output_format is %q or %q.",
+					outputFormatJSON, outputFormatJSONArray))
+			return
+		}
+
+		// Output that is not JSON is reported when it is parsed below.
+		if _, canonical, err := canonicalJSON(out.output); err == nil && !canonical {
+			diags.AddAttributeWarning(path.Root("require_canonical_output"), "Program Output Not Canonical",
+				"The program output is not canonical JSON, so its exact bytes may change between runs without "+
+					"its content changing. Canonical JSON has no whitespace outside strings, object keys in sorted "+
+					"order, no duplicate keys and no unnecessary escapes in strings."+
+					fmt.Sprintf("\n\nProgram: %s", out.cmd.Path))
+		}
+	}
+
+	// Matched output bypasses JSON parsing, storing the capture groups.
+	if in.successRegexp != nil {
+		if successResult == nil {
+			diags.AddError("Unexpected External Program Results",
+				"The data source received unexpected results after executing the program.\n\n"+
+					"Program output must match the success_regexp."+
+					fmt.Sprintf("\n\nProgram: %s", out.cmd.Path)+
+					fmt.Sprintf("\nSuccess Regexp: %s", in.successRegexp))
+			return
+		}
+
+		out.resultJSON, err = json.Marshal(successResult)
+		if err != nil {
+			diags.AddError("Success Regexp Result Handling Failed",
+				"The data source received an unexpected error while attempting to encode the success_regexp result. "+
+					"This is always a bug in the external provider code and should be reported to the provider developers."+
+					fmt.Sprintf("\n\nError: %s", err))
+			return
+		}
+	}
+
+	// Binary output is stored base64 encoded rather than parsed as JSON.
+	if plan.AutoDetectBinary.ValueBool() {
+		if isBinary(out.resultJSON) {
+			diags.AddAttributeWarning(path.Root("auto_detect_binary"), "Program Output Treated As Binary",
+				"The program output contains NUL bytes or invalid UTF-8, so it was not parsed as JSON. "+
+					fmt.Sprintf("The result holds the output base64 encoded under the %q key.", binaryOutputKey)+
+					fmt.Sprintf("\n\nProgram: %s", out.cmd.Path)+
+					fmt.Sprintf("\nOutput Bytes: %d", len(out.resultJSON)))
+
+			out.resultJSON, err = json.Marshal(map[string]string{binaryOutputKey: base64.StdEncoding.EncodeToString(out.resultJSON)})
+			if err != nil {
+				diags.AddError("Binary Output Handling Failed",
+					"The data source received an unexpected error while attempting to encode the binary output. "+
+						"This is always a bug in the external provider code and should be reported to the provider developers."+
+						fmt.Sprintf("\n\nError: %s", err))
+				return
+			}
+		}
+	}
+
+	if plan.ParseLastJson.ValueBool() {
+		if in.outputFormat != outputFormatJSON {
+			diags.AddAttributeError(path.Root("parse_last_json"), "Invalid Parse Last JSON",
+				fmt.Sprintf("The parse_last_json attribute can only be used when output_format is %q.", outputFormatJSON))
+			return
+		}
+
+		// Without an object the output is parsed as is, reporting the error.
+		if object, ok := lastJSONObject(out.resultJSON); ok {
+			out.resultJSON = object
+		}
+	}
+
+	// Each record produces a line of output, which is parsed as an element
+	// of the json_array output.
+	if in.records != nil {
+		var count int
+		out.resultJSON, count, err = recordsToArray(out.resultJSON)
+		if err != nil {
+			diags.AddError("Unexpected External Program Results",
+				"The data source received unexpected results after executing the program.\n\n"+
+					"Program output must be newline-delimited JSON, as query_records is set."+
+					fmt.Sprintf("\n\nProgram: %s", out.cmd.Path)+
+					fmt.Sprintf("\nResult Error: %s", err))
+			return
+		}
+
+		if count != len(in.records) {
+			diags.AddAttributeError(path.Root("query_records"), "Unexpected External Program Results",
+				"The data source received unexpected results after executing the program.\n\n"+
+					"Program output must contain one JSON object per line for each of the query_records, in order."+
+					fmt.Sprintf("\n\nProgram: %s", out.cmd.Path)+
+					fmt.Sprintf("\nRecords: %d", len(in.records))+
+					fmt.Sprintf("\nOutput Records: %d", count))
+			return
+		}
+	}
+
+	// Empty output is an empty result, rather than invalid JSON, when allowed.
+	emptyOutput := plan.AllowEmptyOutput.ValueBool() &&
+		strings.TrimSpace(string(out.resultJSON)) == ""
+
+	if emptyOutput {
+		out.resultJSON = []byte("{}")
+
+		if in.outputFormat == outputFormatJSONArray {
+			out.resultJSON = []byte("[]")
+		}
+	}
+
+	if in.resultFromExitCode {
+		if in.outputFormat != outputFormatJSON || !plan.NumericResultKey.IsNull() {
+			diags.AddAttributeError(path.Root("result_from_exit_code"), "Invalid Result From Exit Code",
+				fmt.Sprintf("The result_from_exit_code attribute can only be used when output_format is %q, ", outputFormatJSON)+
+					"and cannot be combined with numeric_result_key.")
+			return
+		}
+
+		out.resultJSON, err = json.Marshal(map[string]string{"code": strconv.Itoa(out.exitCode)})
+		if err != nil {
+			diags.AddError("Exit Code Result Handling Failed",
+				"The data source received an unexpected error while attempting to encode the exit code result. "+
+					"This is always a bug in the external provider code and should be reported to the provider developers."+
+					fmt.Sprintf("\n\nError: %s", err))
+			return
+		}
+	}
+
+	if key := plan.NumericResultKey.ValueString(); key != "" && !emptyOutput {
+		out.resultJSON, err = numericResultJSON(out.resultJSON, key)
+		if err != nil {
+			diags.AddError("Unexpected External Program Results",
+				"The data source received unexpected results after executing the program.\n\n"+
+					"Program output must be a single number, as numeric_result_key is set."+
+					fmt.Sprintf("\n\nProgram: %s", out.cmd.Path)+
+					fmt.Sprintf("\nResult Error: %s", err))
+			return
+		}
+	}
+
+	if in.outputFormat == outputFormatHCL && !emptyOutput {
+		out.resultJSON, err = hclToJSON(out.resultJSON)
+		if err != nil {
+			diags.AddError("Unexpected External Program Results",
+				"The data source received unexpected results after executing the program.\n\n"+
+					"Program output must be HCL native syntax containing only top-level attributes with constant values."+
+					fmt.Sprintf("\n\nProgram: %s", out.cmd.Path)+
+					fmt.Sprintf("\nResult Error: %s", err))
+			return
+		}
+	}
+
+	if plan.RejectDuplicateKeys.ValueBool() {
+		// Output that is not JSON is reported when it is parsed below.
+		if duplicates, err := duplicateKeys(out.resultJSON); err == nil && len(duplicates) > 0 {
+			diags.AddAttributeError(path.Root("reject_duplicate_keys"), "Duplicate Result Keys",
+				"The program output contains an object with a key that appears more than once, which is rejected "+
+					"as reject_duplicate_keys is set. Only the last value of such a key would otherwise be used."+
+					fmt.Sprintf("\n\nProgram: %s", out.cmd.Path)+
+					fmt.Sprintf("\nDuplicate Keys: %s", strings.Join(duplicates, ", ")))
+			return
+		}
+	}
+
+	return diags
+}
+
+// decodeResult parses the JSON converted from the output of the program into
+// the state, caching the output once it produced a valid result.
+func decodeResult(ctx context.Context, plan execModelV0, prior *execModelV0, in *runInput, out *runOutput) (i execModelV0, diags diag.Diagnostics) {
+	i = plan
+	i.Id = types.StringValue(in.id)
+	i.Result = types.MapNull(types.StringType)
+	i.Results = types.ListNull(types.MapType{ElemType: types.StringType})
+	i.Sections = types.MapNull(types.MapType{ElemType: types.StringType})
+	i.ResultJson = types.StringNull()
+	i.ResultObject = dynamicNull()
+	i.ResultDynamic = dynamicNull()
+	i.GlobFiles = types.MapNull(types.StringType)
+
+	outputSum := sha256.Sum256(out.output)
+	i.OutputBytes = types.Int64Value(int64(len(out.output)))
+	i.ExitCode = types.Int64Value(int64(out.exitCode))
+	i.TimedOut = types.BoolValue(out.timedOut)
+	i.OutputSha256 = types.StringValue(hex.EncodeToString(outputSum[:]))
+	i.Changed = types.BoolValue(!out.unchanged)
+
+	// The prior result is kept, or an empty result when the resource is
+	// created.
+	if out.unchanged {
+		if prior != nil {
+			i.Result = prior.Result
+			i.Results = prior.Results
+			i.Sections = prior.Sections
+			i.ResultJson = prior.ResultJson
+			i.ResultObject = prior.ResultObject
+			i.ResultDynamic = prior.ResultDynamic
+			i.GlobFiles = prior.GlobFiles
+			i.ResultFingerprint = prior.ResultFingerprint
+			i.TimedOut = prior.TimedOut
+
+			// A skipped program has no exit code of its own.
+			if out.skipped {
+				i.ExitCode = prior.ExitCode
+			}
+
+			return i, diags
+		}
+
+		i.Result = types.MapValueMust(types.StringType, map[string]attr.Value{})
+		i.ResultJson = types.StringValue("{}")
+		i.ResultDynamic, _ = dynamicValueFromJSON([]byte("{}"))
+
+		fingerprint, d := resultFingerprint(ctx, i.Result, i.Results)
+		diags.Append(d...)
+		i.ResultFingerprint = types.StringValue(fingerprint)
+
+		return i, diags
+	}
+
+	if out.globFiles != nil {
+		var d diag.Diagnostics
+		i.GlobFiles, d = types.MapValueFrom(ctx, types.StringType, out.globFiles)
+		diags.Append(d...)
+		if diags.HasError() {
+			return
+		}
+	}
+
+	var err error
+
+	var allowedResultKeys []string
+	if !plan.AllowedResultKeys.IsNull() {
+		allowedResultKeys = []string{}
+		diags.Append(plan.AllowedResultKeys.ElementsAs(ctx, &allowedResultKeys, false)...)
+		if diags.HasError() {
+			return
+		}
+	}
+
+	// The output is parsed below, so only valid JSON is stored here.
+	if compact, err := compactResultJSON(out.resultJSON); err == nil {
+		i.ResultJson = types.StringValue(compact)
+	}
+	if dynamic, err := dynamicValueFromJSON(out.resultJSON); err == nil {
+		i.ResultDynamic = dynamic
+	}
+
+	switch in.outputFormat {
+	case outputFormatJSONArray:
+		if !plan.ResultSections.IsNull() {
+			diags.AddAttributeError(path.Root("result_sections"), "Invalid Result Sections",
+				fmt.Sprintf("The result_sections attribute can only be used when output_format is %q.", outputFormatJSON))
+			return
+		}
+
+		if !plan.ResultTransforms.IsNull() {
+			diags.AddAttributeError(path.Root("result_transforms"), "Invalid Result Transforms",
+				fmt.Sprintf("The result_transforms attribute can only be used when output_format is %q.", outputFormatJSON))
+			return
+		}
+
+		if !plan.ResultAttributes.IsNull() {
+			diags.AddAttributeError(path.Root("result_attributes"), "Invalid Result Attributes",
+				fmt.Sprintf("The result_attributes attribute can only be used when output_format is %q.", outputFormatJSON))
+			return
+		}
+
+		if plan.ParseDiagnostics.ValueBool() {
+			diags.AddAttributeError(path.Root("parse_diagnostics"), "Invalid Parse Diagnostics",
+				fmt.Sprintf("The parse_diagnostics attribute can only be used when output_format is %q.", outputFormatJSON))
+			return
+		}
+
+		if plan.RequireProtocolVersion.ValueBool() {
+			diags.AddAttributeError(path.Root("require_protocol_version"), "Invalid Require Protocol Version",
+				fmt.Sprintf("The require_protocol_version attribute can only be used when output_format is %q.", outputFormatJSON))
+			return
+		}
+
+		if !plan.MergeResultKeys.IsNull() {
+			diags.AddAttributeError(path.Root("merge_result_keys"), "Invalid Merge Result Keys",
+				fmt.Sprintf("The merge_result_keys attribute can only be used when output_format is %q.", outputFormatJSON))
+			return
+		}
+
+		if plan.FlattenArrays.ValueBool() {
+			diags.AddAttributeError(path.Root("flatten_arrays"), "Invalid Flatten Arrays",
+				fmt.Sprintf("The flatten_arrays attribute can only be used when output_format is %q.", outputFormatJSON))
+			return
+		}
+
+		var results []interface{}
+		err = json.Unmarshal(out.resultJSON, &results)
+		if err != nil {
+			diags.AddError("Unexpected External Program Results",
+				`The data source received unexpected results after executing the program.
+
+Program output must be a JSON encoded array of maps of string keys and string values.
+
+If the error is unclear, the output can be viewed by enabling Terraform's logging at TRACE level. Terraform documentation on logging: https://www.terraform.io/internals/debugging
+`+
+					fmt.Sprintf("\nProgram: %s", out.cmd.Path)+
+					fmt.Sprintf("\nResult Error: %s", err))
+			return
+		}
+
+		for idx, elem := range results {
+			if _, ok := elem.(map[string]interface{}); !ok {
+				diags.AddError("Unexpected External Program Results",
+					"The data source received unexpected results after executing the program.\n\n"+
+						"Every element of the program output array must be a JSON encoded map of string keys and string values."+
+						fmt.Sprintf("\n\nProgram: %s", out.cmd.Path)+
+						fmt.Sprintf("\nElement: %d", idx)+
+						fmt.Sprintf("\nElement Type: %T", elem))
+			}
+		}
+		if diags.HasError() {
+			return
+		}
+
+		if allowedResultKeys != nil {
+			for idx, elem := range results {
+				if unexpected := unexpectedResultKeys(elem.(map[string]interface{}), allowedResultKeys); len(unexpected) > 0 {
+					diags.AddAttributeError(path.Root("allowed_result_keys"), "Unexpected External Program Result Keys",
+						"The data source received result keys that are not in allowed_result_keys."+
+							fmt.Sprintf("\n\nProgram: %s", out.cmd.Path)+
+							fmt.Sprintf("\nElement: %d", idx)+
+							fmt.Sprintf("\nUnexpected Keys: %s", strings.Join(unexpected, ", ")))
+				}
+			}
+			if diags.HasError() {
+				return
+			}
+		}
+
+		if plan.DedupeResults.ValueBool() {
+			results, err = dedupeResults(results)
+			if err != nil {
+				diags.AddError("Result Deduplication Failed",
+					"The data source received an unexpected error while attempting to remove duplicate results. "+
+						"This is always a bug in the external provider code and should be reported to the provider developers."+
+						fmt.Sprintf("\n\nError: %s", err))
+				return
+			}
+		}
+
+		values := make([]map[string]string, 0, len(results))
+		for _, elem := range results {
+			values = append(values, resultStrings(elem.(map[string]interface{})))
+		}
+
+		var d diag.Diagnostics
+		i.Results, d = types.ListValueFrom(ctx, types.MapType{ElemType: types.StringType}, values)
+		diags.Append(d...)
+
+		if len(out.fileResults) > 0 {
+			i.Result, d = types.MapValueFrom(ctx, types.StringType, out.fileResults)
+			diags.Append(d...)
+		}
+	default:
+		out.resultJSON, err = nonObjectOutput(out.resultJSON, plan.NonObjectOutput.ValueString(), plan.NonObjectKey.ValueString())
+		if err != nil {
+			diags.AddAttributeError(path.Root("non_object_output"), "Invalid Non-Object Output Handling",
+				"The data source received an unexpected error while attempting to handle program output that is not a JSON object."+
+					fmt.Sprintf("\n\nProgram: %s", out.cmd.Path)+
+					fmt.Sprintf("\nError: %s", err))
+			return
+		}
+
+		result := map[string]interface{}{}
+		err = json.Unmarshal(out.resultJSON, &result)
+		if err != nil {
+			diags.AddError("Unexpected External Program Results",
+				`The data source received unexpected results after executing the program.
+
+Program output must be a JSON encoded map of string keys and string values.
+
+If the error is unclear, the output can be viewed by enabling Terraform's logging at TRACE level. Terraform documentation on logging: https://www.terraform.io/internals/debugging
+`+
+					fmt.Sprintf("\nProgram: %s", out.cmd.Path)+
+					fmt.Sprintf("\nResult Error: %s", err))
+			return
+		}
+
+		if plan.ParseDiagnostics.ValueBool() {
+			programDiags, err := programDiagnostics(result)
+			if err != nil {
+				diags.AddAttributeError(path.Root("parse_diagnostics"), "Unexpected External Program Diagnostics",
+					"The data source received diagnostics from the program that could not be parsed."+
+						fmt.Sprintf("\n\nProgram: %s", out.cmd.Path)+
+						fmt.Sprintf("\nError: %s", err))
+				return
+			}
+
+			diags.Append(programDiags...)
+			if diags.HasError() {
+				return
+			}
+		}
+
+		if plan.RequireProtocolVersion.ValueBool() {
+			diags.Append(validateProtocolVersion(result, plan.ProtocolVersion.ValueString())...)
+			if diags.HasError() {
+				return
+			}
+		}
+
+		if key := plan.EchoKey.ValueString(); key != "" {
+			diags.Append(validateEcho(result, in.query, key)...)
+			if diags.HasError() {
+				return
+			}
+		}
+
+		resultTypes := make(map[string]string, len(plan.ResultTypes.Elements()))
+		diags.Append(plan.ResultTypes.ElementsAs(ctx, &resultTypes, false)...)
+		diags.Append(validateResultTypes(result, resultTypes)...)
+		if diags.HasError() {
+			return
+		}
+
+		if allowedResultKeys != nil {
+			if unexpected := unexpectedResultKeys(result, allowedResultKeys); len(unexpected) > 0 {
+				diags.AddAttributeError(path.Root("allowed_result_keys"), "Unexpected External Program Result Keys",
+					"The data source received result keys that are not in allowed_result_keys."+
+						fmt.Sprintf("\n\nProgram: %s", out.cmd.Path)+
+						fmt.Sprintf("\nUnexpected Keys: %s", strings.Join(unexpected, ", ")))
+				return
+			}
+		}
+
+		if !plan.ResultSections.IsNull() {
+			var names []string
+			diags.Append(plan.ResultSections.ElementsAs(ctx, &names, false)...)
+			if diags.HasError() {
+				return
+			}
+
+			sections, d := extractResultSections(result, names)
+			diags.Append(d...)
+			if diags.HasError() {
+				return
+			}
+
+			i.Sections, d = types.MapValueFrom(ctx, types.MapType{ElemType: types.StringType}, sections)
+			diags.Append(d...)
+		}
+
+		if plan.FlattenArrays.ValueBool() {
+			result = flattenResultArrays(result)
+		}
+
+		for key, val := range out.fileResults {
+			result[key] = val
+		}
+
+		if !plan.ResultTransforms.IsNull() {
+			transforms := make(map[string]string, len(plan.ResultTransforms.Elements()))
+			diags.Append(plan.ResultTransforms.ElementsAs(ctx, &transforms, false)...)
+			if diags.HasError() {
+				return
+			}
+
+			values := make(map[string]string, len(result))
+			for key, val := range result {
+				values[key] = resultValueString(val)
+			}
+
+			rendered, d := renderResultTransforms(transforms, values)
+			diags.Append(d...)
+			if diags.HasError() {
+				return
+			}
+
+			for key, val := range rendered {
+				result[key] = val
+			}
+		}
+
+		if prior != nil && !prior.Result.IsNull() && !plan.MergeResultKeys.IsNull() {
+			var keys []string
+			diags.Append(plan.MergeResultKeys.ElementsAs(ctx, &keys, false)...)
+
+			priorResult := make(map[string]string, len(prior.Result.Elements()))
+			diags.Append(prior.Result.ElementsAs(ctx, &priorResult, false)...)
+
+			if diags.HasError() {
+				return
+			}
+
+			result = mergeResultKeys(priorResult, result, keys)
+		}
+
+		if !plan.ResultAttributes.IsNull() {
+			attributes := make(map[string]string, len(plan.ResultAttributes.Elements()))
+			diags.Append(plan.ResultAttributes.ElementsAs(ctx, &attributes, false)...)
+			if diags.HasError() {
+				return
+			}
+
+			var d diag.Diagnostics
+			i.ResultObject, d = resultObject(result, attributes)
+			diags.Append(d...)
+			if diags.HasError() {
+				return
+			}
+		}
+
+		var d diag.Diagnostics
+		i.Result, d = types.MapValueFrom(ctx, types.StringType, resultStrings(result))
+		diags.Append(d...)
+	}
+
+	if diags.HasError() {
+		return
+	}
+
+	fingerprint, d := resultFingerprint(ctx, i.Result, i.Results)
+	diags.Append(d...)
+	if diags.HasError() {
+		return
+	}
+	i.ResultFingerprint = types.StringValue(fingerprint)
+
+	// Only complete output that produced a valid result is cached, so failed
+	// and timed out runs are retried on the next apply.
+	if out.cacheKey != "" && !out.cached && !out.timedOut {
+		if err := writeCache(out.cacheDir, out.cacheKey, out.output, out.exitCode); err != nil {
+			diags.AddWarning("Program Cache Write Failed",
+				"The data source received an unexpected error while attempting to cache the program output. "+
+					"The program will be run again on the next apply."+
+					fmt.Sprintf("\n\nCache Directory: %s", out.cacheDir)+
+					fmt.Sprintf("\nError: %s", err))
+		}
+	}
+
+	return i, diags
+}
+
+// previousKey returns the key a value of the previous run is passed under,
+// which is set by the attribute or else the default.
+func previousKey(attribute types.String, defaultKey string) string {
+	if key := attribute.ValueString(); key != "" {
+		return key
+	}
+
+	return defaultKey
+}
@@ -0,0 +1,1631 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestRun_OutputBytes(t *testing.T) {
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, diags := testRun(t, map[string]interface{}{
+		"program": []string{programPath, "cheese"},
+		"query":   map[string]string{"value": "pizza"},
+	}, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	output := `{"argument":"cheese","query_value":"pizza","result":"yes"}`
+	sum := sha256.Sum256([]byte(output))
+
+	if got := state.OutputBytes.ValueInt64(); got != int64(len(output)) {
+		t.Errorf("expected output_bytes %d, got %d", len(output), got)
+	}
+
+	if got := state.OutputSha256.ValueString(); got != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected output_sha256 %s, got %s", hex.EncodeToString(sum[:]), got)
+	}
+}
+
+func TestRun_IncludeWorkspace(t *testing.T) {
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TF_WORKSPACE", "staging")
+
+	testCases := map[string]struct {
+		cleanEnvironment bool
+	}{
+		"inherited-environment": {},
+		"clean-environment": {
+			cleanEnvironment: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			state, diags := testRun(t, map[string]interface{}{
+				"program":           []string{programPath},
+				"include_workspace": true,
+				"clean_environment": testCase.cleanEnvironment,
+				"query": map[string]string{
+					"env":  "TF_WORKSPACE",
+					"echo": workspaceQueryKey,
+				},
+			}, nil, phaseCreate)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			result := state.Result.Elements()
+
+			if got := result["env_value"]; got != types.StringValue("staging") {
+				t.Errorf("expected TF_WORKSPACE staging, got %s", got)
+			}
+
+			if got := result["echo_"+workspaceQueryKey]; got != types.StringValue("staging") {
+				t.Errorf("expected %s query value staging, got %s", workspaceQueryKey, got)
+			}
+		})
+	}
+}
+
+func TestRun_IncludePlatform(t *testing.T) {
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, diags := testRun(t, map[string]interface{}{
+		"program":          []string{programPath},
+		"include_platform": true,
+		"query":            map[string]string{"echo": "os,arch"},
+	}, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	result := state.Result.Elements()
+
+	if got := result["echo_os"]; got != types.StringValue(runtime.GOOS) {
+		t.Errorf("expected os %s, got %s", runtime.GOOS, got)
+	}
+
+	if got := result["echo_arch"]; got != types.StringValue(runtime.GOARCH) {
+		t.Errorf("expected arch %s, got %s", runtime.GOARCH, got)
+	}
+}
+
+func TestRun_Seed(t *testing.T) {
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		attributes map[string]interface{}
+		expected   *regexp.Regexp
+	}{
+		"seed": {
+			attributes: map[string]interface{}{"seed": "1234"},
+			expected:   regexp.MustCompile(`^1234$`),
+		},
+		"random-seed": {
+			attributes: map[string]interface{}{"random_seed": true},
+			expected:   regexp.MustCompile(`^[0-9a-f]{32}$`),
+		},
+		"seed-over-random-seed": {
+			attributes: map[string]interface{}{"seed": "1234", "random_seed": true},
+			expected:   regexp.MustCompile(`^1234$`),
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			attributes := map[string]interface{}{
+				"program": []string{programPath},
+				"query":   map[string]string{"echo": "seed"},
+			}
+			for key, value := range testCase.attributes {
+				attributes[key] = value
+			}
+
+			state, diags := testRun(t, attributes, nil, phaseCreate)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			seed := state.Seed.ValueString()
+			if !testCase.expected.MatchString(seed) {
+				t.Errorf("unexpected seed: %q", seed)
+			}
+
+			if got := state.Result.Elements()["echo_seed"]; got != types.StringValue(seed) {
+				t.Errorf("expected seed %q in the query, got %s", seed, got)
+			}
+		})
+	}
+}
+
+func TestRun_DryRun(t *testing.T) {
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The program would fail, and no_change_output would match its output,
+	// if it was run.
+	state, diags := testRun(t, map[string]interface{}{
+		"program":          []string{programPath},
+		"dry_run":          true,
+		"dry_run_result":   map[string]string{"planned": "value"},
+		"no_change_output": "{}",
+		"query":            map[string]string{"fail": "true"},
+	}, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if got := state.Result.Elements()["planned"]; got != types.StringValue("value") {
+		t.Errorf("expected dry_run_result in the result, got %s", state.Result)
+	}
+
+	if !state.Changed.ValueBool() {
+		t.Errorf("expected changed to be true")
+	}
+}
+
+func TestRun_PreviousRun(t *testing.T) {
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attributes := map[string]interface{}{
+		"program": []string{programPath},
+		"query":   map[string]string{"value": "pizza"},
+	}
+
+	prior, diags := testRun(t, attributes, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	testCases := map[string]struct {
+		phase    string
+		expected bool
+	}{
+		"update": {
+			phase:    phaseUpdate,
+			expected: true,
+		},
+		"refresh": {
+			phase: phaseRead,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			state, diags := testRun(t, attributes, &prior, testCase.phase)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			result := state.Result.Elements()
+
+			for key, expected := range map[string]string{"previous_query_value": "pizza", "previous_exit_code": "0"} {
+				value, ok := result[key]
+
+				if ok != testCase.expected {
+					t.Errorf("expected %s to be passed: %t, got: %s", key, testCase.expected, value)
+				}
+
+				if ok && value != types.StringValue(expected) {
+					t.Errorf("expected %s %q, got: %s", key, expected, value)
+				}
+			}
+		})
+	}
+}
+
+func TestRun_StrictQuery(t *testing.T) {
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := map[string]string{
+		"value":  "pizza",
+		"empty":  "",
+		"quoted": `say "cheese"`,
+	}
+
+	state, diags := testRun(t, map[string]interface{}{
+		"program": []string{programPath},
+		"query":   query,
+	}, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics without strict_query: %v", diags)
+	}
+
+	if got := state.Result.Elements()["query_value"]; got != types.StringValue("pizza") {
+		t.Errorf("expected query_value pizza, got %s", got)
+	}
+
+	_, diags = testRun(t, map[string]interface{}{
+		"program":      []string{programPath},
+		"query":        query,
+		"strict_query": true,
+	}, nil, phaseCreate)
+
+	var keys []string
+	for _, d := range diags {
+		if d.Summary() != "Query Value Not Preserved" {
+			t.Errorf("unexpected diagnostic: %s: %s", d.Summary(), d.Detail())
+			continue
+		}
+
+		keys = append(keys, d.(diag.DiagnosticWithPath).Path().String())
+	}
+
+	sort.Strings(keys)
+
+	if expected := []string{`query["empty"]`, `query["quoted"]`}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected diagnostics for %v, got %v", expected, keys)
+	}
+}
+
+func TestRun_PrettyStdin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	// The script returns the number of lines of its input.
+	script := "lines=$(wc -l | tr -d ' ')\nprintf '{\"lines\":\"%s\"}' \"$lines\"\n"
+
+	testCases := map[string]struct {
+		prettyStdin bool
+		expected    string
+	}{
+		"compact": {
+			expected: "0",
+		},
+		"pretty": {
+			prettyStdin: true,
+			expected:    "3",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			state, diags := testRun(t, map[string]interface{}{
+				"script":       script,
+				"pretty_stdin": testCase.prettyStdin,
+				"query":        map[string]string{"first": "1", "second": "2"},
+			}, nil, phaseCreate)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			if got := state.Result.Elements()["lines"]; got != types.StringValue(testCase.expected) {
+				t.Errorf("expected %s lines of input, got %s", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func TestRun_OutputEncoding(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	testCases := map[string]struct {
+		outputEncoding string
+		output         []byte
+	}{
+		"utf16le": {
+			outputEncoding: "utf16le",
+			output: []byte{
+				0xff, 0xfe, '{', 0, '"', 0, 'a', 0, '"', 0, ':', 0, '"', 0, 0xe9, 0, '"', 0, '}', 0,
+			},
+		},
+		"latin1": {
+			outputEncoding: "latin1",
+			output:         []byte{'{', '"', 'a', '"', ':', '"', 0xe9, '"', '}'},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			// The script prints the encoded output byte by byte.
+			var script strings.Builder
+			script.WriteString("printf '")
+			for _, b := range testCase.output {
+				fmt.Fprintf(&script, "\\%03o", b)
+			}
+			script.WriteString("'\n")
+
+			state, diags := testRun(t, map[string]interface{}{
+				"script":          script.String(),
+				"output_encoding": testCase.outputEncoding,
+			}, nil, phaseCreate)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			if got := state.Result.Elements()["a"]; got != types.StringValue("é") {
+				t.Errorf("expected decoded value %q, got %s", "é", got)
+			}
+		})
+	}
+}
+
+func TestRun_StreamLogs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	state, diags := (&programResource{}).run(ctx, testModel(t, map[string]interface{}{
+		"script":      "echo 'progress 1' >&2\necho 'progress 2' >&2\nprintf '{\"a\":\"b\"}\\n'\n",
+		"stream_logs": true,
+	}), nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	// The output is still parsed once the program exits.
+	if got := state.Result.Elements()["a"]; got != types.StringValue("b") {
+		t.Errorf("expected result %q, got %s", "b", got)
+	}
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	if err != nil {
+		t.Fatalf("unexpected error decoding logs: %s", err)
+	}
+
+	var streamed []string
+	for _, entry := range entries {
+		if entry["@level"] == "info" {
+			streamed = append(streamed, entry["@message"].(string))
+		}
+	}
+
+	for _, expected := range []string{"progress 1", "progress 2", `{"a":"b"}`} {
+		found := false
+		for _, line := range streamed {
+			found = found || line == expected
+		}
+		if !found {
+			t.Errorf("expected line %q to be logged, got: %q", expected, streamed)
+		}
+	}
+}
+
+func TestRun_LogStreams(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	// Each script writes the result to one stream and a message to the other.
+	stdoutResult := "echo 'message on stderr' >&2\nprintf '{\"from\":\"stdout\"}\\n'\n"
+	stderrResult := "echo 'message on stdout'\nprintf '{\"from\":\"stderr\"}\\n' >&2\n"
+
+	testCases := map[string]struct {
+		script         string
+		resultStream   string
+		logStreams     []string
+		expectedFrom   string
+		expectedLogged []string
+	}{
+		"default": {
+			script:         stdoutResult,
+			expectedFrom:   "stdout",
+			expectedLogged: []string{"message on stderr", `{"from":"stdout"}`},
+		},
+		"stdout": {
+			script:         stdoutResult,
+			logStreams:     []string{"stdout"},
+			expectedFrom:   "stdout",
+			expectedLogged: []string{`{"from":"stdout"}`},
+		},
+		"stderr": {
+			script:         stdoutResult,
+			logStreams:     []string{"stderr"},
+			expectedFrom:   "stdout",
+			expectedLogged: []string{"message on stderr"},
+		},
+		"both": {
+			script:         stdoutResult,
+			logStreams:     []string{"stdout", "stderr"},
+			expectedFrom:   "stdout",
+			expectedLogged: []string{"message on stderr", `{"from":"stdout"}`},
+		},
+		"result-stderr": {
+			script:         stderrResult,
+			resultStream:   "stderr",
+			expectedFrom:   "stderr",
+			expectedLogged: []string{"message on stdout", `{"from":"stderr"}`},
+		},
+		"result-stderr-log-stdout": {
+			script:         stderrResult,
+			resultStream:   "stderr",
+			logStreams:     []string{"stdout"},
+			expectedFrom:   "stderr",
+			expectedLogged: []string{"message on stdout"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			var output bytes.Buffer
+			ctx := tflogtest.RootLogger(context.Background(), &output)
+
+			attributes := map[string]interface{}{
+				"script":      testCase.script,
+				"stream_logs": true,
+			}
+			if testCase.resultStream != "" {
+				attributes["result_stream"] = testCase.resultStream
+			}
+			if testCase.logStreams != nil {
+				attributes["log_streams"] = testCase.logStreams
+			}
+
+			state, diags := (&programResource{}).run(ctx, testModel(t, attributes), nil, phaseCreate)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			if got := state.Result.Elements()["from"]; got != types.StringValue(testCase.expectedFrom) {
+				t.Errorf("expected the result to be parsed from %s, got %s", testCase.expectedFrom, got)
+			}
+
+			entries, err := tflogtest.MultilineJSONDecode(&output)
+			if err != nil {
+				t.Fatalf("unexpected error decoding logs: %s", err)
+			}
+
+			var streamed []string
+			for _, entry := range entries {
+				if entry["@level"] == "info" {
+					streamed = append(streamed, entry["@message"].(string))
+				}
+			}
+
+			sort.Strings(streamed)
+			sort.Strings(testCase.expectedLogged)
+
+			if !reflect.DeepEqual(streamed, testCase.expectedLogged) {
+				t.Errorf("expected logged lines %q, got %q", testCase.expectedLogged, streamed)
+			}
+		})
+	}
+}
+
+func TestRun_MaxLineBytes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	testCases := map[string]struct {
+		script      string
+		expectError bool
+	}{
+		"short-lines": {
+			script: "echo 'progress' >&2\nprintf '{\"a\":\"b\"}\\n'\n",
+		},
+		"long-line": {
+			script:      "echo 'a progress line longer than the limit' >&2\nsleep 5\nprintf '{\"a\":\"b\"}\\n'\n",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			_, diags := testRun(t, map[string]interface{}{
+				"script":         testCase.script,
+				"stream_logs":    true,
+				"max_line_bytes": 16,
+			}, nil, phaseCreate)
+
+			d := testDiagnostic(diags, "Output Line Too Long")
+
+			if !testCase.expectError {
+				if diags.HasError() {
+					t.Fatalf("unexpected diagnostics: %v", diags)
+				}
+				return
+			}
+
+			if d == nil {
+				t.Fatalf("expected Output Line Too Long diagnostic, got: %v", diags)
+			}
+
+			if got := d.(diag.DiagnosticWithPath).Path().String(); got != "max_line_bytes" {
+				t.Errorf("expected diagnostic for max_line_bytes, got %s", got)
+			}
+		})
+	}
+}
+
+func TestRun_PassDeadline(t *testing.T) {
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now().Truncate(time.Second)
+
+	state, diags := testRun(t, map[string]interface{}{
+		"program":       []string{programPath},
+		"pass_deadline": true,
+		"timeout":       "30s",
+		"query":         map[string]string{"echo": "deadline"},
+	}, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	got := state.Result.Elements()["echo_deadline"].(types.String).ValueString()
+
+	deadline, err := time.Parse(time.RFC3339, got)
+	if err != nil {
+		t.Fatalf("expected an RFC 3339 deadline, got %q: %s", got, err)
+	}
+
+	if deadline.Before(start.Add(30*time.Second)) || deadline.After(time.Now().Add(30*time.Second)) {
+		t.Errorf("expected a deadline 30s after the program started, got %s", deadline)
+	}
+
+	// A deadline key in the query takes precedence.
+	state, diags = testRun(t, map[string]interface{}{
+		"program":       []string{programPath},
+		"pass_deadline": true,
+		"timeout":       "30s",
+		"query":         map[string]string{"echo": "deadline", "deadline": "never"},
+	}, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if got := state.Result.Elements()["echo_deadline"]; got != types.StringValue("never") {
+		t.Errorf("expected query deadline never, got %s", got)
+	}
+
+	// Without a timeout there is no deadline to pass.
+	state, diags = testRun(t, map[string]interface{}{
+		"program":       []string{programPath},
+		"pass_deadline": true,
+		"query":         map[string]string{"echo": "deadline"},
+	}, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if testDiagnostic(diags, "Program Deadline Not Passed") == nil {
+		t.Errorf("expected Program Deadline Not Passed warning, got: %v", diags)
+	}
+
+	if got := state.Result.Elements()["echo_deadline"]; got != types.StringValue("") {
+		t.Errorf("expected no deadline, got %s", got)
+	}
+}
+
+func TestRun_IncludeRunMetadata(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	t.Setenv("TF_WORKSPACE", "staging")
+
+	// The script returns the run metadata variables it received.
+	script := "printf '{\"workspace\":\"%s\",\"run_id\":\"%s\",\"root\":\"%s\"}' " +
+		"\"$TF_EXTERNAL_WORKSPACE\" \"$TF_EXTERNAL_RUN_ID\" \"$TF_EXTERNAL_ROOT_MODULE_PATH\"\n"
+
+	resource := &programResource{data: &providerData{runID: "run-1", rootDir: "/root-module"}}
+
+	testCases := map[string]struct {
+		attributes map[string]interface{}
+		expected   map[string]string
+	}{
+		"disabled": {
+			attributes: map[string]interface{}{},
+			expected:   map[string]string{"workspace": "", "run_id": "", "root": ""},
+		},
+		"enabled": {
+			attributes: map[string]interface{}{
+				"include_run_metadata": true,
+			},
+			expected: map[string]string{"workspace": "staging", "run_id": "run-1", "root": "/root-module"},
+		},
+		"environment-precedence": {
+			attributes: map[string]interface{}{
+				"include_run_metadata": true,
+				"environment":          map[string]string{"TF_EXTERNAL_RUN_ID": "override"},
+			},
+			expected: map[string]string{"workspace": "staging", "run_id": "override", "root": "/root-module"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			testCase.attributes["script"] = script
+
+			state, diags := resource.run(context.Background(), testModel(t, testCase.attributes), nil, phaseCreate)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			for key, expected := range testCase.expected {
+				if got := state.Result.Elements()[key]; got != types.StringValue(expected) {
+					t.Errorf("expected %s %q, got %s", key, expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRun_NoChangeOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	unchanged := map[string]interface{}{
+		"script":           "printf '  unchanged\\n'\n",
+		"no_change_output": "unchanged",
+	}
+
+	// There is no previous result when the resource is created.
+	state, diags := testRun(t, unchanged, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if got := state.Result; !got.Equal(types.MapValueMust(types.StringType, map[string]attr.Value{})) {
+		t.Errorf("expected empty result on create, got %s", got)
+	}
+
+	if got := state.Changed; got != types.BoolValue(false) {
+		t.Errorf("expected changed false on create, got %s", got)
+	}
+
+	prior, diags := testRun(t, map[string]interface{}{
+		"script":           "printf '{\"a\":\"b\"}'\n",
+		"no_change_output": "unchanged",
+	}, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if got := prior.Changed; got != types.BoolValue(true) {
+		t.Errorf("expected changed true for a result, got %s", got)
+	}
+
+	// An update re-running the program keeps the prior result.
+	state, diags = testRun(t, unchanged, &prior, phaseUpdate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if got := state.Result; !got.Equal(prior.Result) {
+		t.Errorf("expected prior result %s on update, got %s", prior.Result, got)
+	}
+
+	if got := state.ResultFingerprint; got != prior.ResultFingerprint {
+		t.Errorf("expected prior result_fingerprint %s on update, got %s", prior.ResultFingerprint, got)
+	}
+
+	if got := state.Changed; got != types.BoolValue(false) {
+		t.Errorf("expected changed false on update, got %s", got)
+	}
+}
+
+func TestRun_AllowEmptyOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	testCases := map[string]struct {
+		script           string
+		outputFormat     string
+		allowEmptyOutput bool
+		expectedJSON     string
+		expectError      bool
+	}{
+		"empty": {
+			script:           "true\n",
+			allowEmptyOutput: true,
+			expectedJSON:     "{}",
+		},
+		"whitespace": {
+			script:           "printf '  \\n\\t\\n'\n",
+			allowEmptyOutput: true,
+			expectedJSON:     "{}",
+		},
+		"json-array": {
+			script:           "true\n",
+			outputFormat:     outputFormatJSONArray,
+			allowEmptyOutput: true,
+			expectedJSON:     "[]",
+		},
+		"not-allowed": {
+			script:      "true\n",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			attributes := map[string]interface{}{
+				"script":             testCase.script,
+				"allow_empty_output": testCase.allowEmptyOutput,
+			}
+			if testCase.outputFormat != "" {
+				attributes["output_format"] = testCase.outputFormat
+			}
+
+			state, diags := testRun(t, attributes, nil, phaseCreate)
+
+			if testCase.expectError {
+				if !diags.HasError() {
+					t.Fatal("expected error")
+				}
+				return
+			}
+
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			if got := state.ResultJson; got != types.StringValue(testCase.expectedJSON) {
+				t.Errorf("expected result_json %s, got %s", testCase.expectedJSON, got)
+			}
+
+			if testCase.outputFormat == outputFormatJSONArray {
+				if got := len(state.Results.Elements()); state.Results.IsNull() || got != 0 {
+					t.Errorf("expected empty results, got %s", state.Results)
+				}
+				return
+			}
+
+			if got := len(state.Result.Elements()); state.Result.IsNull() || got != 0 {
+				t.Errorf("expected empty result, got %s", state.Result)
+			}
+		})
+	}
+}
+
+func TestRun_StartupJitter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	testCases := map[string]struct {
+		phase       string
+		expectError bool
+	}{
+		// The program is not run once the wait for its start is cancelled.
+		"create-cancelled": {
+			phase:       phaseCreate,
+			expectError: true,
+		},
+		// Only the creation of resources is spread out.
+		"update": {
+			phase: phaseUpdate,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			marker := filepath.Join(t.TempDir(), "ran")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+
+			_, diags := (&programResource{}).run(ctx, testModel(t, map[string]interface{}{
+				"script":         fmt.Sprintf("touch '%s'\nprintf '{}'\n", marker),
+				"startup_jitter": "1h",
+			}), nil, testCase.phase)
+
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Errorf("expected the wait to end with the context, took %s", elapsed)
+			}
+
+			_, err := os.Stat(marker)
+
+			if !testCase.expectError {
+				if diags.HasError() {
+					t.Fatalf("unexpected diagnostics: %v", diags)
+				}
+				if err != nil {
+					t.Errorf("expected the program to run: %s", err)
+				}
+				return
+			}
+
+			if testDiagnostic(diags, "Startup Jitter Interrupted") == nil {
+				t.Fatalf("expected Startup Jitter Interrupted error, got: %v", diags)
+			}
+
+			if err == nil {
+				t.Error("expected the program not to run")
+			}
+		})
+	}
+}
+
+func TestRun_Locale(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	// The script returns the locale variables it received.
+	script := "printf '{\"lc_all\":\"%s\",\"lang\":\"%s\"}' \"$LC_ALL\" \"$LANG\"\n"
+
+	testCases := map[string]struct {
+		attributes    map[string]interface{}
+		expectedLcAll string
+		expectedLang  string
+	}{
+		"locale": {
+			attributes:    map[string]interface{}{"locale": "C"},
+			expectedLcAll: "C",
+			expectedLang:  "C",
+		},
+		"clean-environment": {
+			attributes:    map[string]interface{}{"locale": "C.UTF-8", "clean_environment": true},
+			expectedLcAll: "C.UTF-8",
+			expectedLang:  "C.UTF-8",
+		},
+		"environment-precedence": {
+			attributes: map[string]interface{}{
+				"locale":      "C",
+				"environment": map[string]string{"LANG": "en_US.UTF-8"},
+			},
+			expectedLcAll: "C",
+			expectedLang:  "en_US.UTF-8",
+		},
+		"inherited": {
+			attributes:    map[string]interface{}{},
+			expectedLcAll: "POSIX",
+			expectedLang:  "POSIX",
+		},
+	}
+
+	t.Setenv("LC_ALL", "POSIX")
+	t.Setenv("LANG", "POSIX")
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			testCase.attributes["script"] = script
+
+			state, diags := testRun(t, testCase.attributes, nil, phaseCreate)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			if got := state.Result.Elements()["lc_all"]; got != types.StringValue(testCase.expectedLcAll) {
+				t.Errorf("expected LC_ALL %q, got %s", testCase.expectedLcAll, got)
+			}
+
+			if got := state.Result.Elements()["lang"]; got != types.StringValue(testCase.expectedLang) {
+				t.Errorf("expected LANG %q, got %s", testCase.expectedLang, got)
+			}
+		})
+	}
+}
+
+func TestRun_UseTempDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	// Each script records its working directory in the marker file, as the
+	// temporary directory is removed once it has run.
+	testCases := map[string]struct {
+		script      string
+		timeout     string
+		expectError bool
+	}{
+		"success": {
+			script: "pwd > \"$MARKER\"\n" +
+				"query=$(cat)\n" +
+				"temp_dir=$(printf '%s' \"$query\" | sed 's/.*\"temp_dir\":\"\\([^\"]*\\)\".*/\\1/')\n" +
+				"printf '{\"pwd\":\"%s\",\"query\":\"%s\",\"env\":\"%s\"}' \"$(pwd)\" \"$temp_dir\" \"$TF_EXTERNAL_TEMP_DIR\"\n",
+		},
+		"failure": {
+			script:      "pwd > \"$MARKER\"\ntouch output\nexit 1\n",
+			expectError: true,
+		},
+		"killed": {
+			script:      "pwd > \"$MARKER\"\ntouch output\nexec sleep 30\n",
+			timeout:     "1s",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			marker := filepath.Join(t.TempDir(), "marker")
+
+			attributes := map[string]interface{}{
+				"script":       testCase.script,
+				"use_temp_dir": true,
+				"environment":  map[string]string{"MARKER": marker},
+			}
+			if testCase.timeout != "" {
+				attributes["timeout"] = testCase.timeout
+			}
+
+			state, diags := testRun(t, attributes, nil, phaseCreate)
+
+			if diags.HasError() != testCase.expectError {
+				t.Fatalf("expected error: %t, got: %v", testCase.expectError, diags)
+			}
+
+			b, err := os.ReadFile(marker)
+			if err != nil {
+				t.Fatalf("expected the program to run: %s", err)
+			}
+
+			dir := strings.TrimSpace(string(b))
+			if dir == "" {
+				t.Fatal("expected a working directory")
+			}
+
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				t.Errorf("expected the temporary directory %s to be removed, got: %v", dir, err)
+			}
+
+			if testCase.expectError {
+				return
+			}
+
+			for _, key := range []string{"pwd", "query", "env"} {
+				if got := state.Result.Elements()[key]; got != types.StringValue(dir) {
+					t.Errorf("expected %s %s, got %s", key, dir, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRun_InvocationId(t *testing.T) {
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without pass_invocation_id the id is generated but not passed.
+	state, diags := testRun(t, map[string]interface{}{
+		"program": []string{programPath},
+		"query":   map[string]string{"echo": invocationIDQueryKey},
+	}, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(state.InvocationId.ValueString()) {
+		t.Errorf("expected a generated invocation_id, got %s", state.InvocationId)
+	}
+
+	if got := state.Result.Elements()["echo_"+invocationIDQueryKey]; got != types.StringValue("") {
+		t.Errorf("expected no %s query key, got %s", invocationIDQueryKey, got)
+	}
+
+	prior, diags := testRun(t, map[string]interface{}{
+		"program":            []string{programPath},
+		"pass_invocation_id": true,
+		"query":              map[string]string{"echo": invocationIDQueryKey},
+	}, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	invocationID := prior.InvocationId.ValueString()
+
+	if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(invocationID) {
+		t.Fatalf("expected a generated invocation_id, got %s", prior.InvocationId)
+	}
+
+	if invocationID == state.InvocationId.ValueString() {
+		t.Errorf("expected a different invocation_id for each resource, got %s twice", invocationID)
+	}
+
+	if got := prior.Result.Elements()["echo_"+invocationIDQueryKey]; got != types.StringValue(invocationID) {
+		t.Errorf("expected %s query key %s, got %s", invocationIDQueryKey, invocationID, got)
+	}
+
+	// The id planned from the state is kept when the program is re-run.
+	state, diags = testRun(t, map[string]interface{}{
+		"program":            []string{programPath},
+		"pass_invocation_id": true,
+		"invocation_id":      invocationID,
+		"query":              map[string]string{"echo": invocationIDQueryKey},
+	}, &prior, phaseUpdate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if got := state.InvocationId; got != prior.InvocationId {
+		t.Errorf("expected invocation_id %s to be kept, got %s", prior.InvocationId, got)
+	}
+
+	if got := state.Result.Elements()["echo_"+invocationIDQueryKey]; got != types.StringValue(invocationID) {
+		t.Errorf("expected %s query key %s on update, got %s", invocationIDQueryKey, invocationID, got)
+	}
+}
+
+func TestRun_ProgramEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+
+	// Each program reports which of them was run, and the arguments that
+	// followed it in the program attribute.
+	programs := map[string]string{}
+	for _, name := range []string{"env", "fallback"} {
+		programs[name] = filepath.Join(dir, name+".sh")
+		script := fmt.Sprintf("#!/bin/sh\nprintf '{\"program\":\"%s\",\"args\":\"%%s\"}' \"$*\"\n", name)
+		if err := os.WriteFile(programs[name], []byte(script), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := map[string]struct {
+		value           string
+		program         []string
+		expectedProgram string
+		expectedArgs    string
+		expectError     bool
+	}{
+		"set": {
+			value:           programs["env"],
+			program:         []string{programs["fallback"], "--verbose"},
+			expectedProgram: "env",
+			expectedArgs:    "--verbose",
+		},
+		"set-without-program": {
+			value:           programs["env"],
+			expectedProgram: "env",
+		},
+		"unset-fallback": {
+			program:         []string{programs["fallback"], "--verbose"},
+			expectedProgram: "fallback",
+			expectedArgs:    "--verbose",
+		},
+		"unset-without-program": {
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			// An empty variable is treated as unset.
+			t.Setenv("TF_EXTERNAL_TEST_PROGRAM", testCase.value)
+
+			attributes := map[string]interface{}{"program_env": "TF_EXTERNAL_TEST_PROGRAM"}
+			if testCase.program != nil {
+				attributes["program"] = testCase.program
+			}
+
+			state, diags := testRun(t, attributes, nil, phaseCreate)
+
+			if testCase.expectError {
+				d := testDiagnostic(diags, "Program Environment Variable Unset")
+				if d == nil {
+					t.Fatalf("expected unset variable error, got: %v", diags)
+				}
+				if got := d.(diag.DiagnosticWithPath).Path().String(); got != "program_env" {
+					t.Errorf("expected diagnostic on program_env, got %s", got)
+				}
+				return
+			}
+
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			if got := state.Result.Elements()["program"]; got != types.StringValue(testCase.expectedProgram) {
+				t.Errorf("expected program %q to run, got %s", testCase.expectedProgram, got)
+			}
+
+			if got := state.Result.Elements()["args"]; got != types.StringValue(testCase.expectedArgs) {
+				t.Errorf("expected arguments %q, got %s", testCase.expectedArgs, got)
+			}
+		})
+	}
+}
+
+func TestRun_TransformProgram(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	testCases := map[string]struct {
+		script           string
+		transform        []string
+		expectedResult   map[string]string
+		expectErrorLabel bool
+	}{
+		"transformed": {
+			script:         "printf 'name=web\\nport=8080\\n'\n",
+			transform:      []string{"sh", "-c", "printf '{'; sed 's/\\(.*\\)=\\(.*\\)/\"\\1\":\"\\2\"/' | paste -sd, -; printf '}'"},
+			expectedResult: map[string]string{"name": "web", "port": "8080"},
+		},
+		"transform-failed": {
+			script:           "printf '{\"name\":\"web\"}'\n",
+			transform:        []string{"sh", "-c", "cat > /dev/null; exit 3"},
+			expectErrorLabel: true,
+		},
+		"program-failed": {
+			script:    "exit 3\n",
+			transform: []string{"cat"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			state, diags := testRun(t, map[string]interface{}{
+				"script":            testCase.script,
+				"transform_program": testCase.transform,
+			}, nil, phaseCreate)
+
+			if testCase.expectedResult == nil {
+				d := testDiagnostic(diags, "External Program Execution Failed")
+				if d == nil {
+					t.Fatalf("expected execution error, got: %v", diags)
+				}
+
+				labelled := strings.Contains(d.Detail(), " (transform_program)")
+				if labelled != testCase.expectErrorLabel {
+					t.Errorf("expected transform_program label %t, got detail: %s", testCase.expectErrorLabel, d.Detail())
+				}
+				return
+			}
+
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			// The output of the transform, not of the program, is the result.
+			for key, expected := range testCase.expectedResult {
+				if got := state.Result.Elements()[key]; got != types.StringValue(expected) {
+					t.Errorf("expected result %q to be %q, got %s", key, expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRun_ResultFromExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	testCases := map[string]struct {
+		attributes   map[string]interface{}
+		expectedCode string
+		expectError  string
+	}{
+		"zero": {
+			attributes:   map[string]interface{}{"script": "echo 'not json'\n"},
+			expectedCode: "0",
+		},
+		"non-zero": {
+			attributes:   map[string]interface{}{"script": "echo 'not json'\nexit 3\n"},
+			expectedCode: "3",
+		},
+		"severity-error": {
+			attributes: map[string]interface{}{
+				"script":             "exit 3\n",
+				"exit_code_severity": map[string]string{"3": "error"},
+			},
+			expectError: "External Program Execution Failed",
+		},
+		"output-format": {
+			attributes: map[string]interface{}{
+				"script":        "exit 0\n",
+				"output_format": outputFormatJSONArray,
+			},
+			expectError: "Invalid Result From Exit Code",
+		},
+		"numeric-result-key": {
+			attributes: map[string]interface{}{
+				"script":             "exit 0\n",
+				"numeric_result_key": "count",
+			},
+			expectError: "Invalid Result From Exit Code",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			testCase.attributes["result_from_exit_code"] = true
+
+			state, diags := testRun(t, testCase.attributes, nil, phaseCreate)
+
+			if testCase.expectError != "" {
+				if testDiagnostic(diags, testCase.expectError) == nil {
+					t.Fatalf("expected %q diagnostic, got: %v", testCase.expectError, diags)
+				}
+				return
+			}
+
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			expected := map[string]string{"code": testCase.expectedCode}
+			result := make(map[string]string, len(state.Result.Elements()))
+			for key, value := range state.Result.Elements() {
+				result[key] = value.(types.String).ValueString()
+			}
+
+			if !reflect.DeepEqual(result, expected) {
+				t.Errorf("expected result %v, got %v", expected, result)
+			}
+		})
+	}
+}
+
+func TestRun_NormalizeLineEndings(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	testCases := map[string]struct {
+		normalize     bool
+		expectedBytes int64
+	}{
+		"disabled": {
+			expectedBytes: int64(len("{\"a\":\"b\"}\r\n")),
+		},
+		"enabled": {
+			normalize:     true,
+			expectedBytes: int64(len("{\"a\":\"b\"}\n")),
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			state, diags := testRun(t, map[string]interface{}{
+				"script":                 "printf '{\"a\":\"b\"}\\r\\n'\n",
+				"normalize_line_endings": testCase.normalize,
+			}, nil, phaseCreate)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			if got := state.OutputBytes.ValueInt64(); got != testCase.expectedBytes {
+				t.Errorf("expected %d output bytes, got %d", testCase.expectedBytes, got)
+			}
+		})
+	}
+
+	// The error output of a failed program is normalized too.
+	_, diags := testRun(t, map[string]interface{}{
+		"script":                 "printf 'first\\r\\nsecond\\r\\n' >&2\nexit 1\n",
+		"normalize_line_endings": true,
+	}, nil, phaseCreate)
+
+	d := testDiagnostic(diags, "External Program Execution Failed")
+	if d == nil {
+		t.Fatalf("expected execution error, got: %v", diags)
+	}
+
+	if !strings.Contains(d.Detail(), "first\nsecond") || strings.Contains(d.Detail(), "\r") {
+		t.Errorf("expected normalized error output, got detail: %q", d.Detail())
+	}
+}
+
+func TestRun_RootRelative(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	rootDir := t.TempDir()
+
+	for _, dir := range []string{"bin", "work"} {
+		if err := os.Mkdir(filepath.Join(rootDir, dir), 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	script := "#!/bin/sh\nprintf '{\"pwd\":\"%s\"}' \"$(pwd)\"\n"
+	if err := os.WriteFile(filepath.Join(rootDir, "bin", "program"), []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	otherDir := t.TempDir()
+
+	testCases := map[string]struct {
+		program     []string
+		workingDir  string
+		rootDir     string
+		expectedPwd string
+		expectError string
+	}{
+		"relative": {
+			program:     []string{filepath.Join("bin", "program")},
+			workingDir:  "work",
+			rootDir:     rootDir,
+			expectedPwd: filepath.Join(rootDir, "work"),
+		},
+		"absolute-working-dir": {
+			program:     []string{filepath.Join("bin", "program")},
+			workingDir:  otherDir,
+			rootDir:     rootDir,
+			expectedPwd: otherDir,
+		},
+		// Programs without a directory are still found using PATH, and
+		// their arguments are left unchanged.
+		"path-lookup": {
+			program:     []string{"sh", filepath.Join("..", "bin", "program")},
+			workingDir:  "work",
+			rootDir:     rootDir,
+			expectedPwd: filepath.Join(rootDir, "work"),
+		},
+		"root-unknown": {
+			program:     []string{filepath.Join("bin", "program")},
+			workingDir:  "work",
+			expectError: "Root Module Directory Unknown",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			r := &programResource{data: &providerData{rootDir: testCase.rootDir}}
+
+			state, diags := r.run(context.Background(), testModel(t, map[string]interface{}{
+				"program":       testCase.program,
+				"working_dir":   testCase.workingDir,
+				"root_relative": true,
+			}), nil, phaseCreate)
+
+			if testCase.expectError != "" {
+				if testDiagnostic(diags, testCase.expectError) == nil {
+					t.Fatalf("expected %q diagnostic, got: %v", testCase.expectError, diags)
+				}
+				return
+			}
+
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			expected, err := filepath.EvalSymlinks(testCase.expectedPwd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := state.Result.Elements()["pwd"]; got != types.StringValue(expected) {
+				t.Errorf("expected working directory %q, got %s", expected, got)
+			}
+		})
+	}
+}
+
+func TestRun_LogOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	testCases := map[string]struct {
+		data           *providerData
+		expectExecuted bool
+		expectCommand  bool
+		expectOutput   bool
+	}{
+		"unconfigured": {
+			expectExecuted: true,
+			expectCommand:  true,
+			expectOutput:   true,
+		},
+		"all": {
+			data:           &providerData{logOutput: logOutputAll},
+			expectExecuted: true,
+			expectCommand:  true,
+			expectOutput:   true,
+		},
+		"command": {
+			data:           &providerData{logOutput: logOutputCommand},
+			expectExecuted: true,
+			expectCommand:  true,
+		},
+		"output": {
+			data:           &providerData{logOutput: logOutputOutput},
+			expectExecuted: true,
+			expectOutput:   true,
+		},
+		"none": {
+			data: &providerData{logOutput: logOutputNone},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			var output bytes.Buffer
+			ctx := tflogtest.RootLogger(context.Background(), &output)
+
+			r := &programResource{data: testCase.data}
+
+			_, diags := r.run(ctx, testModel(t, map[string]interface{}{
+				"script": "printf '{\"secret\":\"value\"}'\n",
+			}), nil, phaseCreate)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			entries, err := tflogtest.MultilineJSONDecode(&output)
+			if err != nil {
+				t.Fatalf("unexpected error decoding logs: %s", err)
+			}
+
+			var executing, executed map[string]interface{}
+			for _, entry := range entries {
+				switch entry["@message"] {
+				case "Executing external program":
+					executing = entry
+				case "Executed external program":
+					executed = entry
+				}
+			}
+
+			if (executing != nil) != testCase.expectCommand {
+				t.Errorf("expected command logged before execution %t, got %v", testCase.expectCommand, executing)
+			}
+
+			if (executed != nil) != testCase.expectExecuted {
+				t.Fatalf("expected execution logged %t, got %v", testCase.expectExecuted, executed)
+			}
+
+			if executed == nil {
+				return
+			}
+
+			if _, ok := executed["program"]; ok != testCase.expectCommand {
+				t.Errorf("expected program logged %t, got %v", testCase.expectCommand, executed)
+			}
+
+			if got, ok := executed["output"]; ok != testCase.expectOutput || (ok && got != `{"secret":"value"}`) {
+				t.Errorf("expected output logged %t, got %v", testCase.expectOutput, executed)
+			}
+		})
+	}
+}
+
+func TestRun_ChrootDir(t *testing.T) {
+	jail := t.TempDir()
+
+	if runtime.GOOS != "linux" {
+		_, diags := testRun(t, map[string]interface{}{
+			"program":    []string{"/bin/program"},
+			"chroot_dir": jail,
+		}, nil, phaseCreate)
+
+		if testDiagnostic(diags, "Program Chroot Unsupported") == nil {
+			t.Fatalf("expected warning, got: %v", diags)
+		}
+		return
+	}
+
+	if err := os.Mkdir(filepath.Join(jail, "bin"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	programPath, err := buildDataSourceTestProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	program, err := os.ReadFile(programPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(jail, "bin", "program"), program, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	// The program is resolved relative to the jail rather than using PATH, so
+	// the checksum is that of the program inside it.
+	_, diags := testRun(t, map[string]interface{}{
+		"program":        []string{"/bin/program"},
+		"chroot_dir":     jail,
+		"program_sha256": strings.Repeat("0", 64),
+	}, nil, phaseCreate)
+
+	d := testDiagnostic(diags, "Program Checksum Mismatch")
+	if d == nil {
+		t.Fatalf("expected checksum mismatch, got: %v", diags)
+	}
+
+	if expected := filepath.Join(jail, "bin", "program"); !strings.Contains(d.Detail(), "Program: "+expected) {
+		t.Errorf("expected the program resolved to %s, got detail: %s", expected, d.Detail())
+	}
+
+	// Changing the root directory requires privileges.
+	if os.Geteuid() != 0 {
+		return
+	}
+
+	state, diags := testRun(t, map[string]interface{}{
+		"program":    []string{"/bin/program"},
+		"chroot_dir": jail,
+		"query":      map[string]string{"value": "jailed"},
+	}, nil, phaseCreate)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if got := state.Result.Elements()["query_value"]; got != types.StringValue("jailed") {
+		t.Errorf("expected the jailed program to run, got %s", got)
+	}
+}
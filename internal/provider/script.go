@@ -0,0 +1,43 @@
+package provider
+
+import (
+	"os"
+	"runtime"
+)
+
+// defaultInterpreter runs the script when the interpreter is not set. There is
+// none on Windows, where the interpreter must be given.
+func defaultInterpreter() []string {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	return []string{"/bin/sh"}
+}
+
+// writeScript writes the script to a new temporary file, executable by its
+// owner on Unix, and returns its name. The caller removes the file.
+func writeScript(script string) (string, error) {
+	f, err := os.CreateTemp("", "terraform-provider-exec-script-")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := f.WriteString(script); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	if err := os.Chmod(f.Name(), 0o700); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
@@ -0,0 +1,40 @@
+package provider
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+)
+
+func TestWriteScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the default interpreter is not available on Windows")
+	}
+
+	name, err := writeScript("#!/bin/sh\necho '{\"greeting\":\"hello\"}'\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.Remove(name)
+
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if mode := info.Mode().Perm(); mode != 0o700 {
+		t.Errorf("expected mode 0700, got %o", mode)
+	}
+
+	args := append(defaultInterpreter(), name)
+
+	output, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if expected := "{\"greeting\":\"hello\"}\n"; string(output) != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"os"
+)
+
+// secretsFD is the file descriptor the secrets are read from by the program,
+// the first after standard input, output and error.
+const secretsFD = 3
+
+// secretsPipe returns the read end of a pipe the payload is written to, to be
+// passed to the program in exec.Cmd.ExtraFiles. The payload is written in the
+// background, so it may be larger than the pipe buffer, and the write end is
+// closed after, so the program reads until end of file. The caller closes the
+// returned file once the program has started.
+func secretsPipe(payload []byte) (*os.File, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		// A program that does not read its secrets makes the write fail
+		// once it exits, which is not an error.
+		_, _ = w.Write(payload)
+		w.Close()
+	}()
+
+	return r, nil
+}
@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSecretsPipe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("extra file descriptors are not supported on Windows")
+	}
+
+	testCases := map[string]struct {
+		payload string
+		script  string
+	}{
+		"read": {
+			payload: `{"token":"s3cr3t"}`,
+			script:  "cat <&" + strconv.Itoa(secretsFD),
+		},
+		"larger-than-pipe-buffer": {
+			payload: strings.Repeat("x", 1<<20),
+			script:  "cat <&" + strconv.Itoa(secretsFD),
+		},
+		"not-read": {
+			payload: strings.Repeat("x", 1<<20),
+			script:  "true",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			r, err := secretsPipe([]byte(testCase.payload))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer r.Close()
+
+			cmd := exec.Command("sh", "-c", testCase.script)
+			cmd.ExtraFiles = append(cmd.ExtraFiles, r)
+
+			output, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			expected := testCase.payload
+			if testCase.script == "true" {
+				expected = ""
+			}
+
+			if string(output) != expected {
+				t.Errorf("expected %d bytes of output, got %d", len(expected), len(output))
+			}
+		})
+	}
+}
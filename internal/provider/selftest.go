@@ -0,0 +1,41 @@
+package provider
+
+import (
+	"sync"
+)
+
+// selfTests records the outcome of each self-test run by the provider, so
+// that a self-test is only run once per provider process regardless of how
+// many resources share it.
+type selfTests struct {
+	mu      sync.Mutex
+	results map[string]*selfTestResult
+}
+
+type selfTestResult struct {
+	once sync.Once
+	err  error
+}
+
+func newSelfTests() *selfTests {
+	return &selfTests{results: make(map[string]*selfTestResult)}
+}
+
+// run calls test the first time it is called for key, and returns the error
+// of that call for every call with the same key. Concurrent callers wait for
+// the first call to complete.
+func (s *selfTests) run(key string, test func() error) error {
+	s.mu.Lock()
+	result, ok := s.results[key]
+	if !ok {
+		result = &selfTestResult{}
+		s.results[key] = result
+	}
+	s.mu.Unlock()
+
+	result.once.Do(func() {
+		result.err = test()
+	})
+
+	return result.err
+}
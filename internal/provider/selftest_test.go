@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestSelfTests(t *testing.T) {
+	tests := newSelfTests()
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+
+	test := func(key string, err error) func() error {
+		return func() error {
+			mu.Lock()
+			calls[key]++
+			mu.Unlock()
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := tests.run("ok", test("ok", nil)); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	failure := errors.New("failed")
+	for i := 0; i < 3; i++ {
+		if err := tests.run("fail", test("fail", failure)); err != failure {
+			t.Errorf("expected self-test failure, got: %v", err)
+		}
+	}
+
+	if calls["ok"] != 1 || calls["fail"] != 1 {
+		t.Errorf("expected each self-test to run once, got: %v", calls)
+	}
+}
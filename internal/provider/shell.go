@@ -0,0 +1,29 @@
+package provider
+
+import (
+	"os"
+	"strings"
+)
+
+// loginShellCommand returns a command that runs program through the user's
+// login shell, so that PATH changes made in profile scripts apply when the
+// program is resolved. $SHELL is used when set, falling back to /bin/sh.
+func loginShellCommand(program []string) []string {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+
+	quoted := make([]string, 0, len(program))
+	for _, arg := range program {
+		quoted = append(quoted, shellQuote(arg))
+	}
+
+	return []string{shell, "-l", "-c", "exec " + strings.Join(quoted, " ")}
+}
+
+// shellQuote quotes s so that a POSIX shell treats it as a single word with
+// no expansions.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
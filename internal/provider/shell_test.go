@@ -0,0 +1,24 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	testCases := map[string]string{
+		"":             "''",
+		"plain":        "'plain'",
+		"with space":   "'with space'",
+		"it's":         `'it'\''s'`,
+		"$HOME `pwd`":  "'$HOME `pwd`'",
+		`"double"`:     `'"double"'`,
+		"multi\nline":  "'multi\nline'",
+		"semi;colon&&": "'semi;colon&&'",
+	}
+
+	for input, expected := range testCases {
+		if got := shellQuote(input); got != expected {
+			t.Errorf("shellQuote(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
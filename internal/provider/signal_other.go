@@ -0,0 +1,37 @@
+//go:build !windows
+// +build !windows
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+var interruptSignals = map[string]syscall.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
+
+// parseSignal returns the signal with the given name, such as "SIGTERM". The
+// "SIG" prefix is optional and the name is not case sensitive.
+func parseSignal(name string) (os.Signal, error) {
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	sig, ok := interruptSignals[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported signal %q", name)
+	}
+
+	return sig, nil
+}
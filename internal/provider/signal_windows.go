@@ -0,0 +1,12 @@
+package provider
+
+import (
+	"errors"
+	"os"
+)
+
+// parseSignal always fails on Windows, where processes cannot be sent
+// arbitrary signals and are killed instead.
+func parseSignal(string) (os.Signal, error) {
+	return nil, errors.New("signals are not supported on windows")
+}
@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// injectedQueryKeys are the keys the provider may add to the query, in the
+// order they are added.
+var injectedQueryKeys = []string{
+	workspaceQueryKey,
+	tempDirQueryKey,
+	"seed",
+	invocationIDQueryKey,
+	"deadline",
+	"os",
+	"arch",
+	protocolVersionKey,
+}
+
+// marshalStdin encodes the stdin object as JSON, indented when pretty is
+// set. With sortKeys, keys are sorted, as json.Marshal does for maps.
+// Otherwise keys are written in the given order, with any keys missing from
+// it following in sorted order.
+func marshalStdin(object map[string]interface{}, order []string, sortKeys, pretty bool) ([]byte, error) {
+	if sortKeys {
+		if pretty {
+			return json.MarshalIndent(object, "", "  ")
+		}
+
+		return json.Marshal(object)
+	}
+
+	keys := make([]string, 0, len(object))
+	seen := make(map[string]bool, len(object))
+
+	for _, key := range order {
+		if _, ok := object[key]; ok && !seen[key] {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+
+	remaining := make([]string, 0, len(object)-len(keys))
+	for key := range object {
+		if !seen[key] {
+			remaining = append(remaining, key)
+		}
+	}
+	sort.Strings(remaining)
+	keys = append(keys, remaining...)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for idx, key := range keys {
+		if idx > 0 {
+			buf.WriteByte(',')
+		}
+
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := json.Marshal(object[key])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+
+	buf.WriteByte('}')
+
+	if !pretty {
+		return buf.Bytes(), nil
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+
+	return indented.Bytes(), nil
+}
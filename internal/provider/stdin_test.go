@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestMarshalStdin(t *testing.T) {
+	// The object merges configured query keys, keys added by the provider
+	// and the previous result.
+	object := map[string]interface{}{
+		"zone":            "b",
+		"name":            "example",
+		"seed":            "1234",
+		"os":              "linux",
+		"previous_result": map[string]string{"z": "1", "a": "2"},
+	}
+	order := append([]string{"name", "zone"}, injectedQueryKeys...)
+	order = append(order, "previous_result")
+
+	testCases := map[string]struct {
+		sortKeys bool
+		pretty   bool
+		expected string
+	}{
+		"sorted": {
+			sortKeys: true,
+			expected: `{"name":"example","os":"linux","previous_result":{"a":"2","z":"1"},"seed":"1234","zone":"b"}`,
+		},
+		"merge-order": {
+			expected: `{"name":"example","zone":"b","seed":"1234","os":"linux","previous_result":{"a":"2","z":"1"}}`,
+		},
+		"merge-order-pretty": {
+			pretty:   true,
+			expected: "{\n  \"name\": \"example\",\n  \"zone\": \"b\",\n  \"seed\": \"1234\",\n  \"os\": \"linux\",\n  \"previous_result\": {\n    \"a\": \"2\",\n    \"z\": \"1\"\n  }\n}",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			// Repeated runs must give the same output despite map ordering.
+			for i := 0; i < 10; i++ {
+				actual, err := marshalStdin(object, order, testCase.sortKeys, testCase.pretty)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				if string(actual) != testCase.expected {
+					t.Fatalf("expected %s, got %s", testCase.expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestMarshalStdin_UnorderedKeys(t *testing.T) {
+	object := map[string]interface{}{"b": "2", "a": "1", "c": "3"}
+
+	actual, err := marshalStdin(object, []string{"c"}, false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if expected := `{"c":"3","a":"1","b":"2"}`; string(actual) != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+}
@@ -0,0 +1,29 @@
+package provider
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// successRegexpResult returns the result of output matching re, with each
+// capture group stored under a match_N key, where match_0 is the whole match,
+// and each named group also stored under its name. It reports false when the
+// output does not match.
+func successRegexpResult(re *regexp.Regexp, output []byte) (map[string]string, bool) {
+	match := re.FindSubmatch(output)
+	if match == nil {
+		return nil, false
+	}
+
+	result := make(map[string]string, len(match))
+
+	for index, name := range re.SubexpNames() {
+		result["match_"+strconv.Itoa(index)] = string(match[index])
+
+		if name != "" {
+			result[name] = string(match[index])
+		}
+	}
+
+	return result, true
+}
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestSuccessRegexpResult(t *testing.T) {
+	testCases := map[string]struct {
+		pattern  string
+		output   string
+		expected map[string]string
+		ok       bool
+	}{
+		"no-groups": {
+			pattern:  `BUILD SUCCESSFUL`,
+			output:   "compiling\nBUILD SUCCESSFUL in 3s\n",
+			expected: map[string]string{"match_0": "BUILD SUCCESSFUL"},
+			ok:       true,
+		},
+		"named-groups": {
+			pattern: `deployed (?P<name>\S+) version (?P<version>\d+)`,
+			output:  "deployed web version 42\n",
+			expected: map[string]string{
+				"match_0": "deployed web version 42",
+				"match_1": "web",
+				"match_2": "42",
+				"name":    "web",
+				"version": "42",
+			},
+			ok: true,
+		},
+		"unnamed-and-unmatched-groups": {
+			pattern: `status: (\w+)(?: \((?P<reason>[^)]*)\))?`,
+			output:  "status: ok\n",
+			expected: map[string]string{
+				"match_0": "status: ok",
+				"match_1": "ok",
+				"match_2": "",
+				"reason":  "",
+			},
+			ok: true,
+		},
+		"no-match": {
+			pattern: `BUILD SUCCESSFUL`,
+			output:  "BUILD FAILED\n",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual, ok := successRegexpResult(regexp.MustCompile(testCase.pattern), []byte(testCase.output))
+
+			if ok != testCase.ok {
+				t.Fatalf("expected ok %t, got %t", testCase.ok, ok)
+			}
+
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
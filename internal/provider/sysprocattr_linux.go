@@ -0,0 +1,17 @@
+package provider
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setChroot configures the command to run with its root directory changed
+// to dir. The provider process must have the privileges (typically root or
+// CAP_SYS_CHROOT) required to call chroot(2) for the command to start.
+func setChroot(cmd *exec.Cmd, dir string) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Chroot = dir
+}
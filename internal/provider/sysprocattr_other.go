@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package provider
+
+import (
+	"os/exec"
+)
+
+// setChroot is a no-op on platforms other than Linux.
+func setChroot(_ *exec.Cmd, _ string) {}
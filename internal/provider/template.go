@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// renderStdinTemplate renders the stdin_template text against the query.
+func renderStdinTemplate(text string, query map[string]string) ([]byte, error) {
+	tmpl, err := template.New("stdin_template").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, query); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// defaultID is the id of resources without an id_template.
+const defaultID = "example-id"
+
+// renderIDTemplate renders the id_template text against the configured
+// query, using the same functions as result_transforms. An empty id is an
+// error.
+func renderIDTemplate(text string, query map[string]string) (string, error) {
+	tmpl, err := template.New("id_template").Option("missingkey=error").Funcs(resultTransformFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, query); err != nil {
+		return "", err
+	}
+
+	if buf.Len() == 0 {
+		return "", fmt.Errorf("template rendered an empty id")
+	}
+
+	return buf.String(), nil
+}
+
+// renderWorkingDirTemplate renders the working_dir text against the
+// configured query, using the same functions as result_transforms. An empty
+// directory is an error.
+func renderWorkingDirTemplate(text string, query map[string]string) (string, error) {
+	tmpl, err := template.New("working_dir").Option("missingkey=error").Funcs(resultTransformFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, query); err != nil {
+		return "", err
+	}
+
+	if buf.Len() == 0 {
+		return "", fmt.Errorf("template rendered an empty directory")
+	}
+
+	return buf.String(), nil
+}
+
+// resultTransformFuncs are the functions available to result_transforms, in
+// addition to the text/template builtins. Functions taking a string take it
+// as their last argument so they can be used in pipelines.
+var resultTransformFuncs = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+}
+
+// renderResultTransforms renders each result_transforms template against the
+// result, returning the rendered values by key. Templates see the result as
+// it was returned by the program, not the output of other transforms.
+func renderResultTransforms(transforms map[string]string, result map[string]string) (map[string]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	rendered := make(map[string]string, len(transforms))
+
+	for key, text := range transforms {
+		tmpl, err := template.New(key).Option("missingkey=error").Funcs(resultTransformFuncs).Parse(text)
+		if err == nil {
+			var buf bytes.Buffer
+			err = tmpl.Execute(&buf, result)
+			rendered[key] = buf.String()
+		}
+
+		if err != nil {
+			diags.AddAttributeError(path.Root("result_transforms").AtMapKey(key), "Result Transform Failed",
+				"The data source received an unexpected error while attempting to evaluate the result transform."+
+					fmt.Sprintf("\n\nKey: %s", key)+
+					fmt.Sprintf("\nError: %s", err))
+		}
+	}
+
+	return rendered, diags
+}
@@ -0,0 +1,229 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestRenderStdinTemplate(t *testing.T) {
+	query := map[string]string{
+		"name":   "example",
+		"region": "us-east-1",
+	}
+
+	testCases := map[string]struct {
+		text        string
+		expected    string
+		expectError bool
+	}{
+		"render": {
+			text:     "name={{ .name }}\nregion={{ .region }}\n",
+			expected: "name=example\nregion=us-east-1\n",
+		},
+		"literal": {
+			text:     "static input",
+			expected: "static input",
+		},
+		"missing-key": {
+			text:        "{{ .missing }}",
+			expectError: true,
+		},
+		"invalid": {
+			text:        "{{ .name",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			rendered, err := renderStdinTemplate(testCase.text, query)
+
+			if testCase.expectError {
+				if err == nil {
+					t.Errorf("expected error, got %q", rendered)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if string(rendered) != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, rendered)
+			}
+		})
+	}
+}
+
+func TestRenderResultTransforms(t *testing.T) {
+	result := map[string]string{
+		"name":   " Example ",
+		"region": "us-east-1",
+	}
+
+	testCases := map[string]struct {
+		transform   string
+		expected    string
+		expectError bool
+	}{
+		"concat": {
+			transform: "{{ .region }}/{{ trim .name }}",
+			expected:  "us-east-1/Example",
+		},
+		"pipeline": {
+			transform: "{{ .name | trim | lower }}",
+			expected:  "example",
+		},
+		"replace": {
+			transform: `{{ .region | replace "-" "_" | upper }}`,
+			expected:  "US_EAST_1",
+		},
+		"trim-prefix": {
+			transform: `{{ .region | trimPrefix "us-" }}`,
+			expected:  "east-1",
+		},
+		"missing-key": {
+			transform:   "{{ .missing }}",
+			expectError: true,
+		},
+		"invalid": {
+			transform:   "{{ .name",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			rendered, diags := renderResultTransforms(map[string]string{"key": testCase.transform}, result)
+
+			if testCase.expectError {
+				if !diags.HasError() {
+					t.Error("expected error")
+				}
+				return
+			}
+
+			if diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+
+			if rendered["key"] != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, rendered["key"])
+			}
+		})
+	}
+}
+
+func TestRenderIDTemplate(t *testing.T) {
+	query := map[string]string{
+		"name":   "Example",
+		"region": "us-east-1",
+	}
+
+	testCases := map[string]struct {
+		template    string
+		expected    string
+		expectError bool
+	}{
+		"query": {
+			template: "{{ .region }}/{{ .name | lower }}",
+			expected: "us-east-1/example",
+		},
+		"constant": {
+			template: "fixed",
+			expected: "fixed",
+		},
+		"empty": {
+			template:    `{{ "" }}`,
+			expectError: true,
+		},
+		"missing-key": {
+			template:    "{{ .missing }}",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual, err := renderIDTemplate(testCase.template, query)
+
+			if testCase.expectError {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if actual != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRenderWorkingDirTemplate(t *testing.T) {
+	query := map[string]string{
+		"environment": "Staging",
+		"service":     "api",
+	}
+
+	testCases := map[string]struct {
+		template    string
+		expected    string
+		expectError bool
+	}{
+		"query": {
+			template: "builds/{{ .environment | lower }}/{{ .service }}",
+			expected: "builds/staging/api",
+		},
+		"no-actions": {
+			template: "/var/lib/builds",
+			expected: "/var/lib/builds",
+		},
+		"empty": {
+			template:    `{{ "" }}`,
+			expectError: true,
+		},
+		"missing-key": {
+			template:    "builds/{{ .region }}",
+			expectError: true,
+		},
+		"invalid": {
+			template:    "builds/{{ .service",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			actual, err := renderWorkingDirTemplate(testCase.template, query)
+
+			if testCase.expectError {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if actual != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 )
 
 // This is a minimal implementation of the external data source protocol
@@ -20,17 +21,41 @@ func main() {
 		panic(err)
 	}
 
-	var query map[string]string
-	err = json.Unmarshal(queryBytes, &query)
+	var input map[string]interface{}
+	err = json.Unmarshal(queryBytes, &input)
 	if err != nil {
 		panic(err)
 	}
 
+	// Query values are always strings, but the previous result is passed as
+	// an object when the program is re-run by update_in_place.
+	query := make(map[string]string)
+	previousResult, _ := input["previous_result"].(map[string]interface{})
+
+	for key, val := range input {
+		if str, ok := val.(string); ok {
+			query[key] = str
+		}
+	}
+
 	if query["fail"] != "" {
 		fmt.Fprintf(os.Stderr, "I was asked to fail\n")
 		os.Exit(1)
 	}
 
+	if query["array"] != "" {
+		resultBytes, err := json.Marshal([]map[string]string{
+			{"index": "0", "query_value": query["value"]},
+			{"index": "1", "query_value": query["value"]},
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		os.Stdout.Write(resultBytes)
+		os.Exit(0)
+	}
+
 	var result = map[string]string{
 		"result":      "yes",
 		"query_value": query["value"],
@@ -40,6 +65,27 @@ func main() {
 		result["argument"] = os.Args[1]
 	}
 
+	if query["env"] != "" {
+		result["env_value"] = os.Getenv(query["env"])
+	}
+
+	// The echo query key names other query keys, separated by commas, to
+	// return under an "echo_" prefix, such as keys added by the provider.
+	if query["echo"] != "" {
+		for _, key := range strings.Split(query["echo"], ",") {
+			result["echo_"+key] = query[key]
+		}
+	}
+
+	if previousResult != nil {
+		result["previous_query_value"], _ = previousResult["query_value"].(string)
+	}
+
+	// The previous exit code is passed as a number on update_in_place re-runs.
+	if previousExitCode, ok := input["previous_exit_code"].(float64); ok {
+		result["previous_exit_code"] = fmt.Sprint(previousExitCode)
+	}
+
 	resultBytes, err := json.Marshal(result)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Lifecycle phases in which the program runs, each of which may have its own
+// timeout.
+const (
+	phaseCreate  = "create"
+	phaseRead    = "read"
+	phaseUpdate  = "update"
+	phaseDestroy = "destroy"
+)
+
+// phaseTimeout returns the timeout of the phase and the name of the attribute
+// it is set by, falling back to the timeout attribute when the phase has no
+// timeout of its own.
+func phaseTimeout(plan execModelV0, phase string) (types.String, string) {
+	var timeout types.String
+
+	switch phase {
+	case phaseCreate:
+		timeout = plan.CreateTimeout
+	case phaseUpdate:
+		timeout = plan.UpdateTimeout
+	case phaseDestroy:
+		timeout = plan.DestroyTimeout
+	}
+
+	if timeout.IsNull() || timeout.IsUnknown() {
+		return plan.Timeout, "timeout"
+	}
+
+	return timeout, phase + "_timeout"
+}
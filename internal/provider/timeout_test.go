@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestPhaseTimeout(t *testing.T) {
+	plan := execModelV0{
+		Timeout:        types.StringValue("1m"),
+		CreateTimeout:  types.StringValue("10m"),
+		UpdateTimeout:  types.StringNull(),
+		DestroyTimeout: types.StringValue("30s"),
+	}
+
+	testCases := map[string]struct {
+		expectedTimeout   string
+		expectedAttribute string
+	}{
+		phaseCreate: {
+			expectedTimeout:   "10m",
+			expectedAttribute: "create_timeout",
+		},
+		phaseRead: {
+			expectedTimeout:   "1m",
+			expectedAttribute: "timeout",
+		},
+		phaseUpdate: {
+			expectedTimeout:   "1m",
+			expectedAttribute: "timeout",
+		},
+		phaseDestroy: {
+			expectedTimeout:   "30s",
+			expectedAttribute: "destroy_timeout",
+		},
+	}
+
+	for phase, testCase := range testCases {
+		phase, testCase := phase, testCase
+
+		t.Run(phase, func(t *testing.T) {
+			timeout, attribute := phaseTimeout(plan, phase)
+
+			if timeout.ValueString() != testCase.expectedTimeout {
+				t.Errorf("expected timeout %q, got %q", testCase.expectedTimeout, timeout.ValueString())
+			}
+
+			if attribute != testCase.expectedAttribute {
+				t.Errorf("expected attribute %q, got %q", testCase.expectedAttribute, attribute)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// validateStatic checks the attributes of the configuration that can be
+// validated without running the program. Values that are not yet known are
+// not checked, so the checks are repeated when applying.
+func validateStatic(config execModelV0) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	outputFormat := config.OutputFormat.ValueString()
+
+	switch outputFormat {
+	case "", outputFormatJSON, outputFormatJSONArray, outputFormatHCL:
+	default:
+		diags.AddAttributeError(path.Root("output_format"), "Invalid Output Format",
+			fmt.Sprintf("The output_format must be one of %q, %q or %q, got: %q", outputFormatJSON, outputFormatJSONArray, outputFormatHCL, outputFormat))
+	}
+
+	// The output format is json when unset. Checks that combine it with other
+	// attributes are skipped while it is unknown.
+	if config.OutputFormat.IsNull() {
+		outputFormat = outputFormatJSON
+	}
+
+	if key := config.NumericResultKey; !key.IsNull() && !key.IsUnknown() && !config.OutputFormat.IsUnknown() &&
+		key.ValueString() != "" && outputFormat != outputFormatJSON {
+		diags.AddAttributeError(path.Root("numeric_result_key"), "Invalid Numeric Result Key",
+			fmt.Sprintf("The numeric_result_key attribute can only be used when output_format is %q.", outputFormatJSON))
+	}
+
+	if config.DedupeResults.ValueBool() && !config.OutputFormat.IsUnknown() && outputFormat != outputFormatJSONArray {
+		diags.AddAttributeError(path.Root("dedupe_results"), "Invalid Dedupe Results",
+			fmt.Sprintf("The dedupe_results attribute can only be used when output_format is %q.", outputFormatJSONArray))
+	}
+
+	if config.AutoDetectBinary.ValueBool() && !config.OutputFormat.IsUnknown() && !config.SuccessRegexp.IsUnknown() &&
+		!config.ResultFromExitCode.IsUnknown() && !config.NumericResultKey.IsUnknown() &&
+		(outputFormat != outputFormatJSON || !config.SuccessRegexp.IsNull() || config.ResultFromExitCode.ValueBool() ||
+			!config.NumericResultKey.IsNull()) {
+		diags.AddAttributeError(path.Root("auto_detect_binary"), "Invalid Auto Detect Binary",
+			fmt.Sprintf("The auto_detect_binary attribute can only be used when output_format is %q, ", outputFormatJSON)+
+				"and cannot be combined with success_regexp, result_from_exit_code or numeric_result_key.")
+	}
+
+	if successRegexp := config.SuccessRegexp; !successRegexp.IsNull() && !successRegexp.IsUnknown() {
+		if !config.OutputFormat.IsUnknown() && !config.ResultFromExitCode.IsUnknown() && !config.NumericResultKey.IsUnknown() &&
+			(outputFormat != outputFormatJSON || config.ResultFromExitCode.ValueBool() || !config.NumericResultKey.IsNull()) {
+			diags.AddAttributeError(path.Root("success_regexp"), "Invalid Success Regexp",
+				fmt.Sprintf("The success_regexp attribute can only be used when output_format is %q, ", outputFormatJSON)+
+					"and cannot be combined with result_from_exit_code or numeric_result_key.")
+		}
+
+		if _, err := regexp.Compile(successRegexp.ValueString()); err != nil {
+			diags.AddAttributeError(path.Root("success_regexp"), "Invalid Success Regexp",
+				"The success_regexp attribute must be a valid regular expression."+
+					fmt.Sprintf("\n\nValue: %s", successRegexp.ValueString())+
+					fmt.Sprintf("\nError: %s", err))
+		}
+	}
+
+	if errorPattern := config.ErrorPattern; !errorPattern.IsNull() && !errorPattern.IsUnknown() {
+		if _, err := regexp.Compile(errorPattern.ValueString()); err != nil {
+			diags.AddAttributeError(path.Root("error_pattern"), "Invalid Error Pattern",
+				"The error_pattern attribute must be a valid regular expression."+
+					fmt.Sprintf("\n\nValue: %s", errorPattern.ValueString())+
+					fmt.Sprintf("\nError: %s", err))
+		}
+	}
+
+	if d, ok := parseDurationAttribute(config.StartupJitter); ok && d < 0 {
+		diags.AddAttributeError(path.Root("startup_jitter"), "Invalid Startup Jitter",
+			"The startup_jitter must be a non-negative duration string, such as \"5s\"."+
+				fmt.Sprintf("\n\nValue: %s", config.StartupJitter.ValueString()))
+	}
+
+	for _, timeout := range []struct {
+		name  string
+		value types.String
+	}{
+		{"timeout", config.Timeout},
+		{"create_timeout", config.CreateTimeout},
+		{"update_timeout", config.UpdateTimeout},
+		{"destroy_timeout", config.DestroyTimeout},
+	} {
+		if d, ok := parseDurationAttribute(timeout.value); ok && d <= 0 {
+			diags.AddAttributeError(path.Root(timeout.name), "Invalid Timeout",
+				fmt.Sprintf("The %s must be a positive duration string, such as \"30s\" or \"5m\".", timeout.name)+
+					fmt.Sprintf("\n\nValue: %s", timeout.value.ValueString()))
+		}
+	}
+
+	diags.Append(validateRetry("", config.Retries, config.RetryInterval, config.RetryBackoff)...)
+
+	if d, ok := parseDurationAttribute(config.LookupRetryInterval); ok && d <= 0 {
+		diags.AddAttributeError(path.Root("lookup_retry_interval"), "Invalid Lookup Retry Interval",
+			"The lookup_retry_interval must be a positive duration string, such as \"2s\"."+
+				fmt.Sprintf("\n\nValue: %s", config.LookupRetryInterval.ValueString()))
+	}
+
+	for _, heartbeat := range []struct {
+		name  string
+		value types.String
+	}{
+		{"heartbeat_timeout", config.HeartbeatTimeout},
+		{"heartbeat_interval", config.HeartbeatInterval},
+	} {
+		if d, ok := parseDurationAttribute(heartbeat.value); ok && d <= 0 {
+			diags.AddAttributeError(path.Root(heartbeat.name), "Invalid Heartbeat Duration",
+				fmt.Sprintf("The %s must be a positive duration string, such as \"30s\".", heartbeat.name)+
+					fmt.Sprintf("\n\nValue: %s", heartbeat.value.ValueString()))
+		}
+	}
+
+	if name := config.InterruptSignal.ValueString(); name != "" {
+		if _, err := parseSignal(name); err != nil {
+			if runtime.GOOS == "windows" {
+				diags.AddAttributeWarning(path.Root("interrupt_signal"), "Interrupt Signal Unsupported",
+					"The interrupt_signal attribute is set, but signals cannot be sent to programs on Windows. "+
+						"The program will be killed when Terraform is interrupted.")
+			} else {
+				diags.AddAttributeError(path.Root("interrupt_signal"), "Invalid Interrupt Signal",
+					"The interrupt_signal attribute must name a supported signal, such as \"SIGTERM\", \"SIGINT\" or \"SIGHUP\"."+
+						fmt.Sprintf("\n\nError: %s", err))
+			}
+		}
+	}
+
+	if !config.TransformProgram.IsNull() && !config.TransformProgram.IsUnknown() {
+		elements := config.TransformProgram.Elements()
+		if len(elements) == 0 || (!elements[0].IsUnknown() && elements[0].Equal(types.StringValue(""))) {
+			diags.AddAttributeError(path.Root("transform_program"), "Invalid Transform Program",
+				"The transform_program attribute must contain at least the program to run.")
+		}
+	}
+
+	if flagPrefix := config.FlagPrefix; !flagPrefix.IsNull() && !flagPrefix.IsUnknown() {
+		validPrefix := false
+		for _, prefix := range flagPrefixes {
+			validPrefix = validPrefix || flagPrefix.ValueString() == prefix
+		}
+
+		if !validPrefix {
+			diags.AddAttributeError(path.Root("flag_prefix"), "Invalid Flag Prefix",
+				fmt.Sprintf("The flag_prefix must be one of %q, %q or %q, got: %q", flagPrefixes[0], flagPrefixes[1], flagPrefixes[2], flagPrefix.ValueString()))
+		}
+	}
+
+	if flagSeparator := config.FlagSeparator; !flagSeparator.IsNull() && !flagSeparator.IsUnknown() {
+		if value := flagSeparator.ValueString(); value != flagSeparatorArgument && value != flagSeparatorEquals {
+			diags.AddAttributeError(path.Root("flag_separator"), "Invalid Flag Separator",
+				fmt.Sprintf("The flag_separator must be one of %q or %q, got: %q", flagSeparatorArgument, flagSeparatorEquals, value))
+		}
+	}
+
+	if queryAsArgs := config.QueryAsArgs; !queryAsArgs.IsNull() && !queryAsArgs.IsUnknown() {
+		switch value := queryAsArgs.ValueString(); value {
+		case queryAsArgsNone, queryAsArgsFlags, queryAsArgsPositional:
+		default:
+			diags.AddAttributeError(path.Root("query_as_args"), "Invalid Query As Args",
+				fmt.Sprintf("The query_as_args must be one of %q, %q or %q, got: %q",
+					queryAsArgsNone, queryAsArgsFlags, queryAsArgsPositional, value))
+		}
+	}
+
+	if !config.MaxLineBytes.IsNull() && !config.MaxLineBytes.IsUnknown() && config.MaxLineBytes.ValueInt64() <= 0 {
+		diags.AddAttributeError(path.Root("max_line_bytes"), "Invalid Line Limit",
+			fmt.Sprintf("The max_line_bytes attribute must be positive, got: %d", config.MaxLineBytes.ValueInt64()))
+	}
+
+	if resultStream := config.ResultStream; !resultStream.IsNull() && !resultStream.IsUnknown() {
+		if value := resultStream.ValueString(); value != streamStdout && value != streamStderr {
+			diags.AddAttributeError(path.Root("result_stream"), "Invalid Result Stream",
+				fmt.Sprintf("The result_stream must be one of %q or %q, got: %q", streamStdout, streamStderr, value))
+		}
+	}
+
+	for idx, element := range config.LogStreams.Elements() {
+		stream, ok := element.(types.String)
+		if !ok || stream.IsNull() || stream.IsUnknown() {
+			continue
+		}
+
+		if value := stream.ValueString(); value != streamStdout && value != streamStderr {
+			diags.AddAttributeError(path.Root("log_streams").AtListIndex(idx), "Invalid Log Stream",
+				fmt.Sprintf("Each of the log_streams must be one of %q or %q, got: %q", streamStdout, streamStderr, value))
+		}
+	}
+
+	for _, encoding := range []struct {
+		name  string
+		value types.String
+	}{
+		{"stdin_encoding", config.StdinEncoding},
+		{"output_encoding", config.OutputEncoding},
+	} {
+		if !encoding.value.IsNull() && !encoding.value.IsUnknown() && !validTextEncoding(encoding.value.ValueString()) {
+			diags.AddAttributeError(path.Root(encoding.name), "Invalid Text Encoding",
+				fmt.Sprintf("The %s must be one of %q, %q or %q, got: %q",
+					encoding.name, textEncodingUTF8, textEncodingUTF16LE, textEncodingLatin1, encoding.value.ValueString()))
+		}
+	}
+
+	for _, limit := range []struct {
+		name  string
+		value types.Int64
+	}{
+		{"memory_limit", config.MemoryLimit},
+		{"cpu_limit", config.CpuLimit},
+	} {
+		if !limit.value.IsNull() && !limit.value.IsUnknown() && limit.value.ValueInt64() <= 0 {
+			diags.AddAttributeError(path.Root(limit.name), "Invalid Resource Limit",
+				fmt.Sprintf("The %s attribute must be positive, got: %d", limit.name, limit.value.ValueInt64()))
+		}
+	}
+
+	if config.RequireProtocolVersion.ValueBool() && config.ProtocolVersion.IsNull() {
+		diags.AddAttributeError(path.Root("require_protocol_version"), "Protocol Version Missing",
+			"The require_protocol_version attribute is set, but the protocol_version attribute is not, so there is "+
+				"no version to require.")
+	}
+
+	if !config.ChrootDir.IsNull() {
+		if !config.Script.IsNull() {
+			diags.AddAttributeError(path.Root("script"), "Conflicting Script",
+				"The script attribute cannot be combined with chroot_dir, as the script would be written outside the jail.")
+		}
+
+		if config.UseTempDir.ValueBool() {
+			diags.AddAttributeError(path.Root("use_temp_dir"), "Conflicting Temporary Directory",
+				"The use_temp_dir attribute cannot be combined with chroot_dir, as the directory would be created "+
+					"outside the jail.")
+		}
+	}
+
+	if !config.ReplaceWhenResultChanges.IsNull() && !config.Ephemeral.IsUnknown() && !config.Ephemeral.ValueBool() {
+		diags.AddAttributeError(path.Root("replace_when_result_changes"), "Ephemeral Required",
+			"The replace_when_result_changes attribute requires ephemeral to be true, as a change of the result is "+
+				"only detected when a refresh re-runs the program.")
+	}
+
+	// The values of the previous run would replace the query values under the
+	// same key.
+	if !config.Query.IsUnknown() {
+		for _, previous := range []struct {
+			name       string
+			value      types.String
+			defaultKey string
+		}{
+			{"previous_result_key", config.PreviousResultKey, defaultPreviousResultKey},
+			{"previous_exit_code_key", config.PreviousExitCodeKey, defaultPreviousExitCodeKey},
+		} {
+			if previous.value.IsUnknown() {
+				continue
+			}
+
+			key := previousKey(previous.value, previous.defaultKey)
+			if _, ok := config.Query.Elements()[key]; ok {
+				diags.AddAttributeError(path.Root(previous.name), "Conflicting Previous Run Key",
+					fmt.Sprintf("The %s %q is also a key of the query, whose value it would replace when the ", previous.name, key)+
+						fmt.Sprintf("program is re-run by an update. Rename the query key, or set %s to another key.", previous.name))
+			}
+		}
+	}
+
+	return diags
+}
+
+// parseDurationAttribute parses a duration attribute, returning false when
+// the value is null or unknown. Values that are not valid durations are
+// returned as -1, so they fail both the positive and non-negative checks.
+func parseDurationAttribute(value types.String) (time.Duration, bool) {
+	if value.IsNull() || value.IsUnknown() {
+		return 0, false
+	}
+
+	d, err := time.ParseDuration(value.ValueString())
+	if err != nil {
+		return -1, true
+	}
+
+	return d, true
+}
@@ -0,0 +1,265 @@
+package provider
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestValidateStatic(t *testing.T) {
+	testCases := map[string]struct {
+		attributes map[string]interface{}
+		expected   string
+	}{
+		"valid": {
+			attributes: map[string]interface{}{
+				"output_format":     outputFormatJSONArray,
+				"startup_jitter":    "0s",
+				"timeout":           "30s",
+				"heartbeat_timeout": "1m",
+				"transform_program": []string{"jq"},
+			},
+		},
+		"output-format": {
+			attributes: map[string]interface{}{"output_format": "yaml"},
+			expected:   "Invalid Output Format",
+		},
+		"output-format-unknown": {
+			attributes: map[string]interface{}{"output_format": tftypes.NewValue(tftypes.String, tftypes.UnknownValue)},
+		},
+		"numeric-result-key": {
+			attributes: map[string]interface{}{"numeric_result_key": "count", "output_format": outputFormatHCL},
+			expected:   "Invalid Numeric Result Key",
+		},
+		"numeric-result-key-format-unknown": {
+			attributes: map[string]interface{}{
+				"numeric_result_key": "count",
+				"output_format":      tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			},
+		},
+		"dedupe-results": {
+			attributes: map[string]interface{}{"dedupe_results": true},
+			expected:   "Invalid Dedupe Results",
+		},
+		"dedupe-results-json-array": {
+			attributes: map[string]interface{}{"dedupe_results": true, "output_format": outputFormatJSONArray},
+		},
+		"auto-detect-binary": {
+			attributes: map[string]interface{}{"auto_detect_binary": true, "output_format": outputFormatHCL},
+			expected:   "Invalid Auto Detect Binary",
+		},
+		"auto-detect-binary-success-regexp": {
+			attributes: map[string]interface{}{"auto_detect_binary": true, "success_regexp": "^ok$"},
+			expected:   "Invalid Auto Detect Binary",
+		},
+		"auto-detect-binary-json": {
+			attributes: map[string]interface{}{"auto_detect_binary": true},
+		},
+		"success-regexp": {
+			attributes: map[string]interface{}{"success_regexp": "ok("},
+			expected:   "Invalid Success Regexp",
+		},
+		"success-regexp-valid": {
+			attributes: map[string]interface{}{"success_regexp": "^ok (\\d+)$"},
+		},
+		"success-regexp-output-format": {
+			attributes: map[string]interface{}{"success_regexp": "^ok$", "output_format": outputFormatJSONArray},
+			expected:   "Invalid Success Regexp",
+		},
+		"success-regexp-result-from-exit-code": {
+			attributes: map[string]interface{}{"success_regexp": "^ok$", "result_from_exit_code": true},
+			expected:   "Invalid Success Regexp",
+		},
+		"success-regexp-numeric-result-key": {
+			attributes: map[string]interface{}{"success_regexp": "^ok$", "numeric_result_key": "count"},
+			expected:   "Invalid Success Regexp",
+		},
+		"success-regexp-unknown": {
+			attributes: map[string]interface{}{
+				"success_regexp": "^ok$",
+				"output_format":  tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			},
+		},
+		"error-pattern": {
+			attributes: map[string]interface{}{"error_pattern": "[error"},
+			expected:   "Invalid Error Pattern",
+		},
+		"error-pattern-unknown": {
+			attributes: map[string]interface{}{"error_pattern": tftypes.NewValue(tftypes.String, tftypes.UnknownValue)},
+		},
+		"startup-jitter-negative": {
+			attributes: map[string]interface{}{"startup_jitter": "-5s"},
+			expected:   "Invalid Startup Jitter",
+		},
+		"startup-jitter-invalid": {
+			attributes: map[string]interface{}{"startup_jitter": "soon"},
+			expected:   "Invalid Startup Jitter",
+		},
+		"timeout-zero": {
+			attributes: map[string]interface{}{"create_timeout": "0s"},
+			expected:   "Invalid Timeout",
+		},
+		"retry-interval-negative": {
+			attributes: map[string]interface{}{"retry_interval": "-1s"},
+			expected:   "Invalid Retry Interval",
+		},
+		"lookup-retry-interval": {
+			attributes: map[string]interface{}{"lookup_retry_interval": "2"},
+			expected:   "Invalid Lookup Retry Interval",
+		},
+		"heartbeat-interval": {
+			attributes: map[string]interface{}{"heartbeat_interval": "-1s"},
+			expected:   "Invalid Heartbeat Duration",
+		},
+		"transform-program-empty": {
+			attributes: map[string]interface{}{"transform_program": []string{}},
+			expected:   "Invalid Transform Program",
+		},
+		"transform-program-empty-string": {
+			attributes: map[string]interface{}{"transform_program": []string{"", "-r"}},
+			expected:   "Invalid Transform Program",
+		},
+		"flag-prefix": {
+			attributes: map[string]interface{}{"flag_prefix": "+"},
+			expected:   "Invalid Flag Prefix",
+		},
+		"flag-prefix-unknown": {
+			attributes: map[string]interface{}{"flag_prefix": tftypes.NewValue(tftypes.String, tftypes.UnknownValue)},
+		},
+		"flag-separator": {
+			attributes: map[string]interface{}{"flag_separator": ":"},
+			expected:   "Invalid Flag Separator",
+		},
+		"flag-separator-equals": {
+			attributes: map[string]interface{}{"flag_prefix": "-", "flag_separator": flagSeparatorEquals},
+		},
+		"query-as-args": {
+			attributes: map[string]interface{}{"query_as_args": "named"},
+			expected:   "Invalid Query As Args",
+		},
+		"query-as-args-positional": {
+			attributes: map[string]interface{}{"query_as_args": queryAsArgsPositional},
+		},
+		"max-line-bytes-zero": {
+			attributes: map[string]interface{}{"max_line_bytes": 0},
+			expected:   "Invalid Line Limit",
+		},
+		"max-line-bytes-unknown": {
+			attributes: map[string]interface{}{"max_line_bytes": tftypes.NewValue(tftypes.Number, tftypes.UnknownValue)},
+		},
+		"result-stream": {
+			attributes: map[string]interface{}{"result_stream": "stdin"},
+			expected:   "Invalid Result Stream",
+		},
+		"log-streams": {
+			attributes: map[string]interface{}{"log_streams": []string{streamStderr, "stdin"}},
+			expected:   "Invalid Log Stream",
+		},
+		"log-streams-valid": {
+			attributes: map[string]interface{}{"result_stream": streamStderr, "log_streams": []string{streamStdout, streamStderr}},
+		},
+		"stdin-encoding": {
+			attributes: map[string]interface{}{"stdin_encoding": "utf-32"},
+			expected:   "Invalid Text Encoding",
+		},
+		"output-encoding": {
+			attributes: map[string]interface{}{"output_encoding": "ascii"},
+			expected:   "Invalid Text Encoding",
+		},
+		"output-encoding-valid": {
+			attributes: map[string]interface{}{"stdin_encoding": textEncodingLatin1, "output_encoding": textEncodingUTF16LE},
+		},
+		"memory-limit-zero": {
+			attributes: map[string]interface{}{"memory_limit": 0},
+			expected:   "Invalid Resource Limit",
+		},
+		"cpu-limit-negative": {
+			attributes: map[string]interface{}{"cpu_limit": -1},
+			expected:   "Invalid Resource Limit",
+		},
+		"cpu-limit-unknown": {
+			attributes: map[string]interface{}{"cpu_limit": tftypes.NewValue(tftypes.Number, tftypes.UnknownValue)},
+		},
+		"transform-program-unknown-program": {
+			attributes: map[string]interface{}{
+				"transform_program": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+					tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+				}),
+			},
+		},
+		"require-protocol-version": {
+			attributes: map[string]interface{}{"require_protocol_version": true},
+			expected:   "Protocol Version Missing",
+		},
+		"require-protocol-version-set": {
+			attributes: map[string]interface{}{"require_protocol_version": true, "protocol_version": "2"},
+		},
+		"chroot-script": {
+			attributes: map[string]interface{}{"chroot_dir": "/jail", "script": "echo {}"},
+			expected:   "Conflicting Script",
+		},
+		"previous-result-key-default": {
+			attributes: map[string]interface{}{"query": map[string]string{"previous_result": "value"}},
+			expected:   "Conflicting Previous Run Key",
+		},
+		"previous-exit-code-key": {
+			attributes: map[string]interface{}{
+				"previous_exit_code_key": "code",
+				"query":                  map[string]string{"code": "value"},
+			},
+			expected: "Conflicting Previous Run Key",
+		},
+		"previous-result-key-renamed": {
+			attributes: map[string]interface{}{
+				"previous_result_key": "prior",
+				"query":               map[string]string{"previous_result": "value"},
+			},
+		},
+		"replace-when-result-changes": {
+			attributes: map[string]interface{}{"replace_when_result_changes": []string{"version"}},
+			expected:   "Ephemeral Required",
+		},
+		"replace-when-result-changes-ephemeral": {
+			attributes: map[string]interface{}{"replace_when_result_changes": []string{"version"}, "ephemeral": true},
+		},
+		"chroot-use-temp-dir": {
+			attributes: map[string]interface{}{"chroot_dir": "/jail", "use_temp_dir": true},
+			expected:   "Conflicting Temporary Directory",
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			diags := validateStatic(testModel(t, testCase.attributes))
+
+			if testCase.expected == "" {
+				if diags.HasError() {
+					t.Fatalf("unexpected diagnostics: %v", diags)
+				}
+				return
+			}
+
+			if !diags.HasError() || testDiagnostic(diags, testCase.expected) == nil {
+				t.Fatalf("expected %q diagnostic, got: %v", testCase.expected, diags)
+			}
+		})
+	}
+}
+
+func TestValidateStatic_InterruptSignal(t *testing.T) {
+	diags := validateStatic(testModel(t, map[string]interface{}{"interrupt_signal": "SIGNOPE"}))
+
+	if runtime.GOOS == "windows" {
+		if diags.HasError() || testDiagnostic(diags, "Interrupt Signal Unsupported") == nil {
+			t.Fatalf("expected warning, got: %v", diags)
+		}
+		return
+	}
+
+	if testDiagnostic(diags, "Invalid Interrupt Signal") == nil {
+		t.Fatalf("expected error, got: %v", diags)
+	}
+}
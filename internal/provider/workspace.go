@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultWorkspace = "default"
+
+// terraformWorkspace returns the name of the Terraform workspace in use.
+//
+// Terraform does not pass the workspace to providers, so it is determined in
+// the same way the CLI does: the TF_WORKSPACE environment variable takes
+// precedence, followed by the workspace recorded by "terraform workspace
+// select" in the data directory (TF_DATA_DIR, or .terraform relative to the
+// directory Terraform was run from). If neither is available the workspace
+// is assumed to be "default".
+func terraformWorkspace() string {
+	if ws := os.Getenv("TF_WORKSPACE"); ws != "" {
+		return ws
+	}
+
+	dataDir := os.Getenv("TF_DATA_DIR")
+	if dataDir == "" {
+		dataDir = ".terraform"
+	}
+
+	contents, err := os.ReadFile(filepath.Join(dataDir, "environment"))
+	if err != nil {
+		return defaultWorkspace
+	}
+
+	if ws := strings.TrimSpace(string(contents)); ws != "" {
+		return ws
+	}
+
+	return defaultWorkspace
+}